@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"syscall"
 	"time"
 	"webgos/internal/bootstrap"
+	"webgos/internal/cli"
 	"webgos/internal/config"
 	"webgos/internal/routes"
 	"webgos/internal/xlog"
@@ -33,20 +35,28 @@ import (
 
 // @BasePath /
 func main() {
-	defer bootstrap.Close()
-	// 解析命令行参数
-	configPath := flag.String("c", "./config/config.yaml", "Specify the config file path")
-	flag.Parse()
+	app := &cli.App{Out: os.Stdout, In: os.Stdin, Serve: serve}
+	err := app.Run(os.Args[1:])
+	bootstrap.Close()
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
 
+// serve 初始化项目并启动 HTTP 服务，收到 SIGINT/SIGTERM 后优雅关闭
+func serve(configPath string) error {
 	// 初始化项目
-	if err := bootstrap.Initialize(*configPath); err != nil {
-		panic("failed to initialize project: " + err.Error())
+	if err := bootstrap.Initialize(configPath); err != nil {
+		return fmt.Errorf("failed to initialize project: %w", err)
 	}
-	globalConfig := config.GlobalConfig
+	globalConfig := config.Get()
 
 	// 创建 http.Server
 	srv := &http.Server{
-		Addr:              fmt.Sprintf(":%d", globalConfig.Server.Port),
+		Addr:              fmt.Sprintf("%s:%d", globalConfig.Server.Host, globalConfig.Server.Port),
 		Handler:           routes.REngine,
 		ReadTimeout:       30 * time.Second,  // 读取请求超时
 		WriteTimeout:      60 * time.Second,  // 写入响应超时
@@ -66,7 +76,7 @@ func main() {
 
 	// 按需开启 pprof 性能分析（独立 debug 端口，不影响业务路由）
 	if globalConfig.Server.Pprof {
-		pprofServer(quit)
+		pprofServer(globalConfig.Server.PprofAddr, quit)
 	}
 
 	idleConnsClosed := make(chan struct{})
@@ -84,12 +94,13 @@ func main() {
 
 	xlog.Access("Server started on port %d", globalConfig.Server.Port)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		fmt.Printf("listen: %s\n", err)
+		return fmt.Errorf("listen: %w", err)
 	}
 	<-idleConnsClosed
+	return nil
 }
 
-func pprofServer(quit chan os.Signal) {
+func pprofServer(addr string, quit chan os.Signal) {
 	pprofMux := http.NewServeMux()
 	pprofMux.HandleFunc("/debug/pprof/", pprof.Index)
 	pprofMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
@@ -103,11 +114,11 @@ func pprofServer(quit chan os.Signal) {
 	pprofMux.Handle("/debug/pprof/mutex", pprof.Handler("mutex"))
 
 	pprofSrv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", 6060),
+		Addr:    addr,
 		Handler: pprofMux,
 	}
 	go func() {
-		xlog.Access("pprof server started on port 6060")
+		xlog.Access("pprof server started on %s", addr)
 		if err := pprofSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			xlog.Access("pprof server listen error: %v", err)
 		}
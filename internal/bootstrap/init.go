@@ -1,29 +1,39 @@
 package bootstrap
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"webgos/internal/cache"
 	"webgos/internal/config"
+	"webgos/internal/jwtkey"
+	"webgos/internal/routes"
 	"webgos/internal/xdb"
 	"webgos/internal/xdb/migrate"
-	"webgos/internal/routes"
+	"webgos/internal/xdb/seed"
 	"webgos/internal/xlog"
 )
 
+// Initialize 初始化 HTTP 服务所需的全部组件：配置、日志、安全审计、数据库、缓存、迁移、路由与权限点同步、种子数据
 func Initialize(configPath string) error {
-	// 配置初始化,必须最先执行！
-	globalConfig, err := config.LoadConfig(configPath)
+	globalConfig, err := Setup(configPath)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 
-	// 初始化日志
-	if err = xlog.InitLogger(); err != nil {
-		return fmt.Errorf("failed to initialize logger: %v", err)
+	// 安全审计：release 模式存在严重问题时拒绝启动，debug 模式仅输出警告
+	if err = auditConfig(globalConfig); err != nil {
+		return err
 	}
 
-	// 初始化数据库
-	if err = xdb.InitDB(); err != nil {
-		return fmt.Errorf("Database initialization error: %v", err)
+	// 加载 JWT 签名密钥，密钥文件缺失或格式错误时拒绝启动
+	if err = jwtkey.Init(); err != nil {
+		return fmt.Errorf("JWT key initialization error: %v", err)
+	}
+
+	if err = SetupDatabase(); err != nil {
+		return err
 	}
 
 	// 自动迁移模型
@@ -31,19 +41,85 @@ func Initialize(configPath string) error {
 		return fmt.Errorf("Model migration error: %v", err)
 	}
 
+	// 初始化缓存（memory 或 redis）与失效广播，db 广播依赖迁移创建的事件表
+	if err = cache.Init(); err != nil {
+		return fmt.Errorf("Cache initialization error: %v", err)
+	}
+
 	// 注册路由
 	routes.New(globalConfig)
 
-	// 同步权限到数据库（根据配置决定是否收集）
-	if err := routes.SyncPermissions(xdb.GetDB()); err != nil {
-		return fmt.Errorf("Failed to sync permissions: %v", err)
+	// 同步权限到数据库
+	if globalConfig.AutoRBACPoint {
+		if err := routes.SyncPermissions(xdb.GetDB()); err != nil {
+			return fmt.Errorf("Failed to sync permissions: %v", err)
+		}
+	}
+
+	// 加载种子数据，补齐超级管理员、角色、菜单等基础数据
+	if len(globalConfig.SeedFiles) > 0 {
+		result, err := seed.LoadFiles(context.Background(), globalConfig.SeedFiles...)
+		if err != nil {
+			return fmt.Errorf("Failed to load seed files: %v", err)
+		}
+		xlog.Access("Seed data loaded: %s", result)
 	}
 
+	// 监听配置变更，支持不重启更新日志级别、限流、跨域等配置
+	watchConfig(configPath)
+
+	return nil
+}
+
+// Setup 加载配置并初始化日志，所有命令（含 serve）的公共前置步骤
+func Setup(configPath string) (*config.Config, error) {
+	// 配置初始化,必须最先执行！
+	globalConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// 初始化日志
+	if err = xlog.InitLogger(); err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %v", err)
+	}
+
+	// 输出各配置项的生效来源（不输出值，避免泄露密钥）
+	for _, source := range config.Sources() {
+		xlog.Access("config %s <- %s", source.Path, source)
+	}
+	return globalConfig, nil
+}
+
+// SetupDatabase 初始化数据库连接，需在 Setup 之后调用
+func SetupDatabase() error {
+	if err := xdb.InitDB(); err != nil {
+		return fmt.Errorf("Database initialization error: %v", err)
+	}
+	return nil
+}
+
+// auditConfig 执行配置安全审计并输出报告
+func auditConfig(cfg *config.Config) error {
+	findings := config.Audit(cfg)
+	if len(findings) == 0 {
+		return nil
+	}
+	report := config.FormatFindings(findings)
+	if cfg.Server.Mode == "release" && config.HasCritical(findings) {
+		xlog.Error("%s", report)
+		return errors.New(report)
+	}
+	for _, line := range strings.Split(report, "\n") {
+		xlog.Warn("[SECURITY] %s", line)
+	}
 	return nil
 }
 
 func Close() {
 	xlog.Access("Closing resources...")
+	stopWatchConfig()
+	cache.Close()
 	xdb.CloseDB()
 	if xlog.Xlogger != nil {
 		xlog.Xlogger.Close()
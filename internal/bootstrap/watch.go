@@ -0,0 +1,75 @@
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"webgos/internal/config"
+	"webgos/internal/xlog"
+)
+
+// 配置文件变更轮询间隔
+const configPollInterval = 3 * time.Second
+
+var watchStop chan struct{}
+
+// watchConfig 监听 SIGHUP 信号与配置文件变更，触发配置热更新
+// 热更新失败（校验失败或修改了需重启的字段）时保留旧配置并记录错误日志
+func watchConfig(configPath string) {
+	stop := make(chan struct{})
+	watchStop = stop
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+
+		lastStamp := fileStamp(configPath)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-hup:
+				xlog.Access("SIGHUP received, reloading config")
+				lastStamp = fileStamp(configPath)
+				reloadConfig(configPath)
+			case <-ticker.C:
+				if stamp := fileStamp(configPath); stamp != lastStamp {
+					lastStamp = stamp
+					reloadConfig(configPath)
+				}
+			}
+		}
+	}()
+}
+
+// stopWatchConfig 停止配置监听
+func stopWatchConfig() {
+	if watchStop != nil {
+		close(watchStop)
+		watchStop = nil
+	}
+}
+
+func reloadConfig(configPath string) {
+	snap, err := config.Reload(configPath)
+	if err != nil {
+		xlog.Error("config reload rejected: %v", err)
+		return
+	}
+	xlog.Access("config reloaded, version %d", snap.Version)
+}
+
+// fileStamp 以修改时间与大小标识文件版本，文件不存在时返回空串
+func fileStamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,515 @@
+package cache
+
+import (
+	"container/heap"
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 淘汰策略
+const (
+	PolicyLRU = "lru" // 最近最少使用
+	PolicyLFU = "lfu" // 最不经常使用，访问次数相同时淘汰最久未使用的
+)
+
+// EvictReason 缓存项被移除的原因
+type EvictReason string
+
+const (
+	EvictCapacity EvictReason = "capacity" // 超过总条数或总字节预算
+	EvictQuota    EvictReason = "quota"    // 超过前缀配额
+	EvictExpired  EvictReason = "expired"  // 过期
+)
+
+// Sizer 缓存值可实现该接口提供准确的字节数，否则按反射估算
+type Sizer interface {
+	CacheSize() int64
+}
+
+// BoundedOptions 有界缓存选项
+type BoundedOptions struct {
+	MaxEntries      int            // 最大条数，0 表示不限制
+	MaxBytes        int64          // 近似字节预算（键 + 值 + 固定开销），0 表示不限制
+	Policy          string         // lru（默认）或 lfu
+	DefaultTTL      time.Duration  // DefaultExpiration 对应的过期时间，默认 5 分钟
+	CleanupInterval time.Duration  // 过期清理间隔，默认 1 分钟，负数关闭后台清理（仅读取时惰性清理）
+	Quotas          map[string]int // 前缀配额：键以该前缀开头的缓存最多保留的条数，多个前缀匹配时取最长的
+	OnEvicted       func(key string, value any, reason EvictReason)
+}
+
+// entryOverhead 每个缓存项在 map、堆与 entry 结构上的近似固定开销（字节）
+const entryOverhead = 96
+
+type boundedEntry struct {
+	key       string
+	value     any
+	size      int64
+	expiresAt time.Time // 零值表示永不过期
+	freq      uint64
+	tick      uint64 // 最近访问序号
+	segment   *segment
+	index     int // 在 segment 堆中的位置
+}
+
+func (e *boundedEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// segment 一个配额前缀（或默认分组）下的缓存项，按淘汰顺序组织为最小堆，堆顶为下一个淘汰对象
+type segment struct {
+	prefix  string
+	quota   int
+	entries []*boundedEntry
+	lfu     bool
+}
+
+// before 淘汰顺序：LRU 按最近访问序号，LFU 先按访问次数再按最近访问序号
+func (s *segment) before(a, b *boundedEntry) bool {
+	if s.lfu && a.freq != b.freq {
+		return a.freq < b.freq
+	}
+	return a.tick < b.tick
+}
+
+func (s *segment) Len() int           { return len(s.entries) }
+func (s *segment) Less(i, j int) bool { return s.before(s.entries[i], s.entries[j]) }
+func (s *segment) Swap(i, j int) {
+	s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+	s.entries[i].index = i
+	s.entries[j].index = j
+}
+func (s *segment) Push(x any) {
+	e := x.(*boundedEntry)
+	e.index = len(s.entries)
+	s.entries = append(s.entries, e)
+}
+func (s *segment) Pop() any {
+	n := len(s.entries)
+	e := s.entries[n-1]
+	s.entries[n-1] = nil
+	s.entries = s.entries[:n-1]
+	e.index = -1
+	return e
+}
+
+func (s *segment) victim() *boundedEntry {
+	if len(s.entries) == 0 {
+		return nil
+	}
+	return s.entries[0]
+}
+
+type evicted struct {
+	key    string
+	value  any
+	reason EvictReason
+}
+
+// BoundedCache 有界进程内缓存：限制总条数、近似字节数与前缀配额，按 LRU/LFU 淘汰
+// 与 Cache 一样维护 "前缀@哈希" 键的前缀索引，任何移除（删除、过期、淘汰）都会同步更新索引
+type BoundedCache struct {
+	mu          sync.Mutex
+	opts        BoundedOptions
+	items       map[string]*boundedEntry
+	segments    []*segment // 最后一个为默认分组（无配额）
+	prefixIndex map[string]map[string]struct{}
+	tagIndex    tagIndex
+	bytes       int64
+	tick        uint64
+	stop        chan struct{}
+	stopOnce    sync.Once
+	counterMu   sync.Mutex // 串行执行 Incr 的读改写
+}
+
+// NewBoundedCache 创建有界缓存，CleanupInterval 不为负时启动后台过期清理，需调用 Close 停止
+func NewBoundedCache(opts BoundedOptions) *BoundedCache {
+	if opts.DefaultTTL <= 0 {
+		opts.DefaultTTL = 5 * time.Minute
+	}
+	if opts.CleanupInterval == 0 {
+		opts.CleanupInterval = time.Minute
+	}
+	lfu := opts.Policy == PolicyLFU
+	c := &BoundedCache{
+		opts:        opts,
+		items:       make(map[string]*boundedEntry),
+		prefixIndex: make(map[string]map[string]struct{}),
+		tagIndex:    newTagIndex(),
+		stop:        make(chan struct{}),
+	}
+	for prefix, quota := range opts.Quotas {
+		c.segments = append(c.segments, &segment{prefix: prefix, quota: quota, lfu: lfu})
+	}
+	c.segments = append(c.segments, &segment{lfu: lfu})
+	if opts.CleanupInterval > 0 {
+		go c.janitor(opts.CleanupInterval)
+	}
+	return c
+}
+
+// segmentOf 键所属的分组：匹配最长的配额前缀，否则为默认分组
+func (c *BoundedCache) segmentOf(key string) *segment {
+	var best *segment
+	for _, s := range c.segments[:len(c.segments)-1] {
+		if strings.HasPrefix(key, s.prefix) && (best == nil || len(s.prefix) > len(best.prefix)) {
+			best = s
+		}
+	}
+	if best == nil {
+		return c.segments[len(c.segments)-1]
+	}
+	return best
+}
+
+func (c *BoundedCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	e, ok := c.items[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil, false
+	}
+	if e.expired(time.Now()) {
+		removed := []evicted{c.remove(e, EvictExpired)}
+		c.mu.Unlock()
+		c.notify(removed)
+		return nil, false
+	}
+	c.tick++
+	e.tick = c.tick
+	e.freq++
+	heap.Fix(e.segment, e.index)
+	value := e.value
+	c.mu.Unlock()
+	return value, true
+}
+
+func (c *BoundedCache) Set(key string, value any, duration time.Duration) {
+	c.SetWithTags(key, value, duration)
+}
+
+func (c *BoundedCache) SetWithTags(key string, value any, duration time.Duration, tags ...string) {
+	size := int64(len(key)) + approxSize(value) + entryOverhead
+	var expiresAt time.Time
+	switch duration {
+	case DefaultExpiration:
+		expiresAt = time.Now().Add(c.opts.DefaultTTL)
+	case NoExpiration:
+	default:
+		expiresAt = time.Now().Add(duration)
+	}
+
+	c.mu.Lock()
+	var removed []evicted
+	// 覆盖写入保留访问次数，避免 LFU 下频繁更新的热点项被当作新项淘汰
+	freq := uint64(1)
+	if old, ok := c.items[key]; ok {
+		freq = old.freq + 1
+		c.remove(old, "")
+	}
+	// 单个值超过总预算时不缓存
+	if c.opts.MaxBytes > 0 && size > c.opts.MaxBytes {
+		c.mu.Unlock()
+		return
+	}
+
+	// 写入前腾出空间：先满足前缀配额，再满足总量限制；新项不参与本次淘汰，避免 LFU 下刚写入的低频项被立即淘汰
+	seg := c.segmentOf(key)
+	for seg.quota > 0 && seg.Len() >= seg.quota {
+		removed = append(removed, c.evict(seg.victim(), EvictQuota))
+	}
+	for c.overCapacity(1, size) {
+		victim := c.globalVictim()
+		if victim == nil {
+			break
+		}
+		removed = append(removed, c.evict(victim, EvictCapacity))
+	}
+
+	c.tick++
+	e := &boundedEntry{key: key, value: value, size: size, expiresAt: expiresAt, freq: freq, tick: c.tick, segment: seg}
+	c.items[key] = e
+	c.bytes += size
+	heap.Push(seg, e)
+	c.addToIndex(key)
+	c.tagIndex.set(key, tags)
+	c.mu.Unlock()
+	c.notify(removed)
+}
+
+// overCapacity 再写入 n 条共 size 字节后是否超出总量限制
+func (c *BoundedCache) overCapacity(n int, size int64) bool {
+	return (c.opts.MaxEntries > 0 && len(c.items)+n > c.opts.MaxEntries) ||
+		(c.opts.MaxBytes > 0 && c.bytes+size > c.opts.MaxBytes)
+}
+
+// evict 淘汰缓存项，已过期的项按过期原因回调
+func (c *BoundedCache) evict(e *boundedEntry, reason EvictReason) evicted {
+	if e.expired(time.Now()) {
+		reason = EvictExpired
+	}
+	return c.remove(e, reason)
+}
+
+// globalVictim 各分组堆顶中最先应淘汰的项，堆顶已过期时优先淘汰
+func (c *BoundedCache) globalVictim() *boundedEntry {
+	var victim *boundedEntry
+	now := time.Now()
+	for _, s := range c.segments {
+		candidate := s.victim()
+		if candidate == nil {
+			continue
+		}
+		if candidate.expired(now) {
+			return candidate
+		}
+		if victim == nil || s.before(candidate, victim) {
+			victim = candidate
+		}
+	}
+	return victim
+}
+
+func (c *BoundedCache) Delete(key string) {
+	c.mu.Lock()
+	if e, ok := c.items[key]; ok {
+		c.remove(e, "")
+	}
+	c.mu.Unlock()
+}
+
+// Incr 与 Cache 一致，计数器以 int64 保存，参与淘汰
+func (c *BoundedCache) Incr(key string, duration time.Duration) int64 {
+	c.counterMu.Lock()
+	defer c.counterMu.Unlock()
+	count := c.Counter(key) + 1
+	c.Set(key, count, duration)
+	return count
+}
+
+func (c *BoundedCache) Counter(key string) int64 {
+	value, _ := c.Get(key)
+	count, _ := value.(int64)
+	return count
+}
+
+// DeleteByPrefix 与 Cache 一致：前缀索引命中时按索引删除，否则全表匹配
+func (c *BoundedCache) DeleteByPrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if keys, ok := c.prefixIndex[prefix]; ok {
+		for key := range keys {
+			if e, ok := c.items[key]; ok {
+				c.remove(e, "")
+			}
+		}
+		delete(c.prefixIndex, prefix)
+		return
+	}
+	for key, e := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(e, "")
+		}
+	}
+}
+
+func (c *BoundedCache) InvalidateTags(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range c.tagIndex.keysOf(tags) {
+		if e, ok := c.items[key]; ok {
+			c.remove(e, "")
+		}
+	}
+}
+
+func (c *BoundedCache) Flush() {
+	c.mu.Lock()
+	c.items = make(map[string]*boundedEntry)
+	c.prefixIndex = make(map[string]map[string]struct{})
+	c.tagIndex = newTagIndex()
+	c.bytes = 0
+	for _, s := range c.segments {
+		s.entries = nil
+	}
+	c.mu.Unlock()
+}
+
+// ScanKeys 遍历未过期的键，遍历的是调用时的快照
+func (c *BoundedCache) ScanKeys(ctx context.Context, fn func(key string) bool) error {
+	now := time.Now()
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.items))
+	for key, e := range c.items {
+		if !e.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	c.mu.Unlock()
+	for _, key := range keys {
+		if !fn(key) {
+			break
+		}
+	}
+	return nil
+}
+
+// Len 当前条数
+func (c *BoundedCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Bytes 当前占用的近似字节数
+func (c *BoundedCache) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+// Close 停止后台过期清理
+func (c *BoundedCache) Close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	return nil
+}
+
+// remove 移除缓存项并同步更新字节数、淘汰堆、前缀索引与标签索引，reason 为空表示主动删除（不回调）
+func (c *BoundedCache) remove(e *boundedEntry, reason EvictReason) evicted {
+	delete(c.items, e.key)
+	c.bytes -= e.size
+	if e.index >= 0 {
+		heap.Remove(e.segment, e.index)
+	}
+	c.removeFromIndex(e.key)
+	c.tagIndex.remove(e.key)
+	return evicted{key: e.key, value: e.value, reason: reason}
+}
+
+// notify 在锁外调用淘汰回调，回调中可以安全地访问缓存
+func (c *BoundedCache) notify(removed []evicted) {
+	if c.opts.OnEvicted == nil {
+		return
+	}
+	for _, r := range removed {
+		if r.reason != "" {
+			c.opts.OnEvicted(r.key, r.value, r.reason)
+		}
+	}
+}
+
+func (c *BoundedCache) addToIndex(key string) {
+	prefix := keyPrefix(key)
+	if prefix == "" {
+		return
+	}
+	if _, ok := c.prefixIndex[prefix]; !ok {
+		c.prefixIndex[prefix] = make(map[string]struct{})
+	}
+	c.prefixIndex[prefix][key] = struct{}{}
+}
+
+func (c *BoundedCache) removeFromIndex(key string) {
+	prefix := keyPrefix(key)
+	if prefix == "" {
+		return
+	}
+	if keys, ok := c.prefixIndex[prefix]; ok {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.prefixIndex, prefix)
+		}
+	}
+}
+
+func (c *BoundedCache) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+func (c *BoundedCache) deleteExpired() {
+	now := time.Now()
+	var removed []evicted
+	c.mu.Lock()
+	for _, e := range c.items {
+		if e.expired(now) {
+			removed = append(removed, c.remove(e, EvictExpired))
+		}
+	}
+	c.mu.Unlock()
+	c.notify(removed)
+}
+
+// approxSize 估算值占用的字节数，实现 Sizer 的值使用其返回值
+func approxSize(value any) int64 {
+	return sizeOf(reflect.ValueOf(value), 0)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func sizeOf(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+	if v.CanInterface() {
+		if s, ok := v.Interface().(Sizer); ok {
+			return s.CacheSize()
+		}
+	}
+	// 限制递归深度，避免深层或循环引用的结构估算过慢
+	if depth > 8 {
+		return int64(v.Type().Size())
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Slice:
+		n := int64(v.Type().Size())
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return n + int64(v.Len())
+		}
+		for i := 0; i < v.Len(); i++ {
+			n += sizeOf(v.Index(i), depth+1)
+		}
+		return n
+	case reflect.Array:
+		var n int64
+		for i := 0; i < v.Len(); i++ {
+			n += sizeOf(v.Index(i), depth+1)
+		}
+		return n
+	case reflect.Map:
+		n := int64(48)
+		iter := v.MapRange()
+		for iter.Next() {
+			n += sizeOf(iter.Key(), depth+1) + sizeOf(iter.Value(), depth+1)
+		}
+		return n
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return int64(v.Type().Size())
+		}
+		var n int64
+		for i := 0; i < v.NumField(); i++ {
+			n += sizeOf(v.Field(i), depth+1)
+		}
+		return n
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return 8
+		}
+		return 8 + sizeOf(v.Elem(), depth+1)
+	default:
+		return int64(v.Type().Size())
+	}
+}
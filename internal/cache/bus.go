@@ -0,0 +1,320 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"webgos/common/json"
+	"webgos/internal/config"
+	"webgos/internal/models"
+	"webgos/internal/xlog"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// 缓存失效事件类型
+const (
+	OpDelete        = "delete"
+	OpDeletePrefix  = "delete_prefix"
+	OpFlush         = "flush"
+	OpInvalidateTag = "invalidate_tag"
+)
+
+// Event 缓存失效事件，Key 为 Delete 的键、DeleteByPrefix 的前缀或 InvalidateTags 的单个标签
+type Event struct {
+	Op     string `json:"op"`
+	Key    string `json:"key,omitempty"`
+	NodeID string `json:"node"`
+}
+
+// Bus 缓存失效广播总线，把一个实例上的删除操作广播到所有实例
+// Subscribe 可多次调用，每个处理函数都会收到所有事件（包括本实例发出的，由订阅方按 NodeID 过滤）
+type Bus interface {
+	Publish(ctx context.Context, event Event) error
+	Subscribe(handler func(Event)) error
+	Close() error
+}
+
+var (
+	nodeIDOnce sync.Once
+	nodeID     string
+)
+
+// NodeID 当前实例标识：配置 cache.bus.node_id 优先，否则为 主机名-随机串，进程内保持不变
+func NodeID() string {
+	if cfg := config.Get(); cfg != nil && cfg.Cache.Bus.NodeID != "" {
+		return cfg.Cache.Bus.NodeID
+	}
+	nodeIDOnce.Do(func() {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			host = "node"
+		}
+		nodeID = host + "-" + uuid.NewString()[:8]
+	})
+	return nodeID
+}
+
+// BroadcastCache 为本地缓存增加跨实例失效：Delete/DeleteByPrefix/InvalidateTags/Flush 先在本地执行再广播，
+// 收到其他实例的事件时只作用于本地缓存，不再次广播；自身发出的事件按 NodeID 忽略
+type BroadcastCache struct {
+	ICache
+	bus    Bus
+	nodeID string
+}
+
+// NewBroadcastCache 包装本地缓存并订阅总线
+func NewBroadcastCache(local ICache, bus Bus, nodeID string) (*BroadcastCache, error) {
+	c := &BroadcastCache{ICache: local, bus: bus, nodeID: nodeID}
+	if err := bus.Subscribe(c.apply); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *BroadcastCache) Delete(key string) {
+	c.ICache.Delete(key)
+	c.publish(Event{Op: OpDelete, Key: key})
+}
+
+func (c *BroadcastCache) DeleteByPrefix(prefix string) {
+	c.ICache.DeleteByPrefix(prefix)
+	c.publish(Event{Op: OpDeletePrefix, Key: prefix})
+}
+
+// InvalidateTags 每个标签广播一个事件
+func (c *BroadcastCache) InvalidateTags(tags ...string) {
+	c.ICache.InvalidateTags(tags...)
+	for _, tag := range tags {
+		c.publish(Event{Op: OpInvalidateTag, Key: tag})
+	}
+}
+
+func (c *BroadcastCache) Flush() {
+	c.ICache.Flush()
+	c.publish(Event{Op: OpFlush})
+}
+
+// ScanKeys 遍历本地缓存的键
+func (c *BroadcastCache) ScanKeys(ctx context.Context, fn func(key string) bool) error {
+	return scanKeys(ctx, c.ICache, fn)
+}
+
+// Close 关闭总线及本地缓存
+func (c *BroadcastCache) Close() error {
+	err := c.bus.Close()
+	if closer, ok := c.ICache.(interface{ Close() error }); ok {
+		closer.Close()
+	}
+	return err
+}
+
+// publish 广播失败只记录日志，本地缓存已失效，其他实例最迟在缓存过期后恢复一致
+func (c *BroadcastCache) publish(event Event) {
+	event.NodeID = c.nodeID
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := c.bus.Publish(ctx, event); err != nil {
+		xlog.Error("cache invalidation publish %s %s failed: %v", event.Op, event.Key, err)
+	}
+}
+
+func (c *BroadcastCache) apply(event Event) {
+	if event.NodeID == c.nodeID {
+		return
+	}
+	switch event.Op {
+	case OpDelete:
+		c.ICache.Delete(event.Key)
+	case OpDeletePrefix:
+		c.ICache.DeleteByPrefix(event.Key)
+	case OpInvalidateTag:
+		c.ICache.InvalidateTags(event.Key)
+	case OpFlush:
+		c.ICache.Flush()
+	}
+}
+
+// MemoryBus 进程内总线，同步分发事件，用于单实例部署与测试
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers []func(Event)
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := append([]func(Event){}, b.handlers...)
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(handler func(Event)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	b.handlers = nil
+	b.mu.Unlock()
+	return nil
+}
+
+const (
+	dbBusBatch     = 500              // 每次轮询读取的最大事件数
+	dbBusRetention = 10 * time.Minute // 事件保留时间，超过后由任一实例清理
+)
+
+// DBBus 数据库轮询总线：事件写入 cache_invalidations 表，各实例按自增 ID 定时拉取新事件
+// 无需额外中间件，延迟为一个轮询间隔；订阅从订阅时刻的最大 ID 开始，不回放历史事件
+type DBBus struct {
+	db       *gorm.DB
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+func NewDBBus(db *gorm.DB, interval time.Duration) *DBBus {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &DBBus{db: db, interval: interval, stop: make(chan struct{})}
+}
+
+func (b *DBBus) Publish(ctx context.Context, event Event) error {
+	return b.db.WithContext(ctx).Create(&models.CacheInvalidation{
+		Op:       event.Op,
+		CacheKey: event.Key,
+		NodeID:   event.NodeID,
+	}).Error
+}
+
+func (b *DBBus) Subscribe(handler func(Event)) error {
+	var lastID int64
+	if err := b.db.Model(&models.CacheInvalidation{}).Select("COALESCE(MAX(id), 0)").Scan(&lastID).Error; err != nil {
+		return err
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		cleanupEvery := max(int(time.Minute/b.interval), 1)
+		for tick := 1; ; tick++ {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+			}
+			lastID = b.poll(lastID, handler)
+			if tick%cleanupEvery == 0 {
+				b.cleanup()
+			}
+		}
+	}()
+	return nil
+}
+
+// poll 拉取并分发 lastID 之后的事件，返回新的 lastID
+func (b *DBBus) poll(lastID int64, handler func(Event)) int64 {
+	for {
+		var rows []models.CacheInvalidation
+		if err := b.db.Where("id > ?", lastID).Order("id").Limit(dbBusBatch).Find(&rows).Error; err != nil {
+			xlog.Error("cache invalidation poll failed: %v", err)
+			return lastID
+		}
+		for _, row := range rows {
+			handler(Event{Op: row.Op, Key: row.CacheKey, NodeID: row.NodeID})
+			lastID = row.ID
+		}
+		if len(rows) < dbBusBatch {
+			return lastID
+		}
+	}
+}
+
+func (b *DBBus) cleanup() {
+	if err := b.db.Where("created_at < ?", time.Now().Add(-dbBusRetention)).Delete(&models.CacheInvalidation{}).Error; err != nil {
+		xlog.Error("cache invalidation cleanup failed: %v", err)
+	}
+}
+
+func (b *DBBus) Close() error {
+	b.once.Do(func() { close(b.stop) })
+	b.wg.Wait()
+	return nil
+}
+
+// RedisBus Redis 发布订阅总线，事件实时送达；订阅断线期间的事件会丢失，由缓存过期兜底
+type RedisBus struct {
+	client  redis.UniversalClient
+	channel string
+	mu      sync.Mutex
+	subs    []*redis.PubSub
+	wg      sync.WaitGroup
+}
+
+func NewRedisBus(client redis.UniversalClient, channel string) *RedisBus {
+	return &RedisBus{client: client, channel: channel}
+}
+
+func (b *RedisBus) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+func (b *RedisBus) Subscribe(handler func(Event)) error {
+	sub := b.client.Subscribe(context.Background(), b.channel)
+	// 等待订阅确认，保证 Subscribe 返回后发布的事件都能收到
+	if _, err := sub.Receive(context.Background()); err != nil {
+		sub.Close()
+		return err
+	}
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for msg := range sub.Channel() {
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				xlog.Error("cache invalidation decode failed: %v", err)
+				continue
+			}
+			handler(event)
+		}
+	}()
+	return nil
+}
+
+// Close 取消订阅，不关闭 Redis 客户端（可能与缓存共用）
+func (b *RedisBus) Close() error {
+	b.mu.Lock()
+	subs := b.subs
+	b.subs = nil
+	b.mu.Unlock()
+	for _, sub := range subs {
+		sub.Close()
+	}
+	b.wg.Wait()
+	return nil
+}
@@ -1,16 +1,23 @@
 package cache
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"webgos/common/json"
+	"webgos/internal/config"
+	"webgos/internal/xdb"
 
 	gocache "github.com/patrickmn/go-cache"
+	"github.com/redis/go-redis/v9"
 )
 
 const (
@@ -21,48 +28,195 @@ const (
 var (
 	defaultCache *Cache
 	once         sync.Once
+	// current 当前使用的缓存实现，由 Init/SetCache 设置，未设置时使用进程内缓存
+	current atomic.Pointer[cacheHolder]
 )
 
+type cacheHolder struct {
+	cache ICache
+}
+
 // 使用go-cache注意集群部署时缓存不一致问题
-// 解决方法：使用分布式缓存，如Redis
+// 解决方法：配置 cache.driver: redis 使用分布式缓存 RedisCache
 type Cache struct {
 	cache       *gocache.Cache
 	mu          sync.RWMutex
 	prefixIndex map[string]map[string]struct{}
+	tagIndex    tagIndex
+	counterMu   sync.Mutex // 串行执行 Incr 的读改写
 }
 
 // ICache 是缓存存储抽象接口。
 type ICache interface {
 	Get(key string) (any, bool)
 	Set(key string, value any, duration time.Duration)
+	// SetWithTags 写入缓存并打上标签，替换键原有的标签
+	SetWithTags(key string, value any, duration time.Duration, tags ...string)
 	Delete(key string)
 	DeleteByPrefix(prefix string)
+	// Incr 原子地将计数器加 1 并返回新值，键不存在或已过期时从 0 开始；每次递增后过期时间重新计为 duration，
+	// 多个请求并发修改同一计数（如登录失败次数）时使用，避免 Get/Set 读改写丢失更新
+	Incr(key string, duration time.Duration) int64
+	// Counter 读取 Incr 维护的计数器，不存在时返回 0
+	Counter(key string) int64
+	// InvalidateTags 删除带有任一标签的缓存
+	InvalidateTags(tags ...string)
 	Flush()
 }
 
-// 保留扩展其它类型缓存
+// GetCache 返回当前缓存实现，未调用 Init 时使用进程内缓存
 func GetCache() ICache {
-	once.Do(func() {
-		defaultCache = &Cache{
-			cache:       gocache.New(5*time.Minute, 10*time.Minute),
-			prefixIndex: make(map[string]map[string]struct{}),
+	if h := current.Load(); h != nil {
+		return h.cache
+	}
+	return memoryCache()
+}
+
+// SetCache 替换当前缓存实现（如测试或自定义后端），返回原实现
+func SetCache(c ICache) ICache {
+	old := GetCache()
+	current.Store(&cacheHolder{cache: c})
+	return old
+}
+
+// Init 按 cache 配置初始化缓存：memory 使用进程内缓存，bounded 使用有界进程内缓存，redis 连接 Redis 并校验连通性；
+// 配置了 cache.bus 时以 BroadcastCache 包装，跨实例广播删除操作；最外层以 StatsCache 记录按前缀的统计（GetStats）。
+// db 总线依赖数据库，需在迁移之后调用
+func Init() error {
+	// 重复初始化时先释放上一次创建的连接与订阅
+	Close()
+	cfg := config.Get().Cache
+	var local ICache = memoryCache()
+	var client *redis.Client
+	if cfg.Driver == "redis" || cfg.Bus.Driver == "redis" {
+		client = redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Username: cfg.Redis.Username,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+			PoolSize: cfg.Redis.PoolSize,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			return fmt.Errorf("failed to connect redis %s: %w", cfg.Redis.Addr, err)
 		}
-		defaultCache.cache.OnEvicted(func(k string, v any) {
-			defaultCache.removeFromIndex(k)
+	}
+	if cfg.Driver == "bounded" {
+		local = NewBoundedCache(BoundedOptions{
+			MaxEntries: cfg.Bounded.MaxEntries,
+			MaxBytes:   int64(cfg.Bounded.MaxMemory) << 20,
+			Policy:     cfg.Bounded.Policy,
+			Quotas:     cfg.Bounded.Quotas,
+			OnEvicted:  defaultStats.OnEvicted,
 		})
+	}
+	if cfg.Driver == "redis" {
+		codec, err := NewCodec(cfg.Codec)
+		if err != nil {
+			client.Close()
+			return err
+		}
+		local = NewRedisCache(client, codec, cfg.Redis.Prefix)
+	} else if client != nil {
+		// 仅供广播总线使用的连接，由 Close 释放
+		closers = append(closers, client)
+	}
+
+	var bus Bus
+	switch cfg.Bus.Driver {
+	case "memory":
+		bus = NewMemoryBus()
+	case "db":
+		bus = NewDBBus(xdb.GetDB(), time.Duration(cfg.Bus.PollInterval)*time.Millisecond)
+	case "redis":
+		bus = NewRedisBus(client, cfg.Bus.Channel)
+	default:
+		SetCache(NewStatsCache(local, defaultStats))
+		return nil
+	}
+	broadcast, err := NewBroadcastCache(local, bus, NodeID())
+	if err != nil {
+		SetCache(local)
+		Close()
+		SetCache(memoryCache())
+		return fmt.Errorf("failed to subscribe cache invalidation bus: %w", err)
+	}
+	SetCache(NewStatsCache(broadcast, defaultStats))
+	return nil
+}
+
+// closers Init 创建的、不归属于当前缓存实现的资源（如仅供广播总线使用的 Redis 连接）
+var closers []io.Closer
+
+// Close 释放缓存连接与广播总线，进程内缓存无需释放
+func Close() {
+	if h := current.Load(); h != nil {
+		if closer, ok := h.cache.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	for _, closer := range closers {
+		closer.Close()
+	}
+	closers = nil
+}
+
+// memoryCache 进程内缓存单例
+func memoryCache() *Cache {
+	once.Do(func() {
+		defaultCache = NewMemoryCache()
 	})
 	return defaultCache
 }
 
+// NewMemoryCache 创建独立的进程内缓存，业务代码应使用 GetCache 获取共享实例
+func NewMemoryCache() *Cache {
+	c := &Cache{
+		cache:       gocache.New(5*time.Minute, 10*time.Minute),
+		prefixIndex: make(map[string]map[string]struct{}),
+		tagIndex:    newTagIndex(),
+	}
+	// 过期清理与删除都会触发，同步维护前缀索引与标签索引
+	c.cache.OnEvicted(func(k string, v any) {
+		c.removeFromIndex(k)
+		c.setTags(k, nil)
+	})
+	return c
+}
+
+// GetValue 读取缓存并写入 dest（指针）：值类型一致时直接赋值，
+// 否则经 JSON 转换（如 Redis JSONCodec 解码得到的 map[string]any）
+func GetValue(key string, dest any) bool {
+	value, found := GetCache().Get(key)
+	if !found {
+		return false
+	}
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return false
+	}
+	if v := reflect.ValueOf(value); v.IsValid() && v.Type().AssignableTo(target.Elem().Type()) {
+		target.Elem().Set(v)
+		return true
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
 // 获取key的前缀，假设key格式为 "park:page@a3eracfdsfaer23423" 返回 "park:page"
-func (c *Cache) keyPrefix(key string) string {
+func keyPrefix(key string) string {
 	if i := strings.IndexByte(key, '@'); i > 0 {
 		return key[:i]
 	}
 	return ""
 }
 func (c *Cache) addToIndex(key string) {
-	prefix := c.keyPrefix(key)
+	prefix := keyPrefix(key)
 	if prefix == "" {
 		return
 	}
@@ -77,7 +231,7 @@ func (c *Cache) addToIndex(key string) {
 }
 
 func (c *Cache) removeFromIndex(key string) {
-	prefix := c.keyPrefix(key)
+	prefix := keyPrefix(key)
 	if prefix == "" {
 		return
 	}
@@ -99,13 +253,49 @@ func (c *Cache) Get(key string) (any, bool) {
 }
 
 func (c *Cache) Set(key string, value any, duration time.Duration) {
+	c.SetWithTags(key, value, duration)
+}
+
+func (c *Cache) SetWithTags(key string, value any, duration time.Duration, tags ...string) {
 	c.cache.Set(key, value, duration)
 	c.addToIndex(key)
+	c.setTags(key, tags)
+}
+
+func (c *Cache) setTags(key string, tags []string) {
+	c.mu.Lock()
+	c.tagIndex.set(key, tags)
+	c.mu.Unlock()
 }
 
 func (c *Cache) Delete(key string) {
 	c.cache.Delete(key)
 	c.removeFromIndex(key)
+	c.setTags(key, nil)
+}
+
+// Incr 计数器以 int64 保存，读改写在 counterMu 下执行
+func (c *Cache) Incr(key string, duration time.Duration) int64 {
+	c.counterMu.Lock()
+	defer c.counterMu.Unlock()
+	count := c.Counter(key) + 1
+	c.Set(key, count, duration)
+	return count
+}
+
+func (c *Cache) Counter(key string) int64 {
+	value, _ := c.cache.Get(key)
+	count, _ := value.(int64)
+	return count
+}
+
+func (c *Cache) InvalidateTags(tags ...string) {
+	c.mu.RLock()
+	keys := c.tagIndex.keysOf(tags)
+	c.mu.RUnlock()
+	for _, k := range keys {
+		c.Delete(k)
+	}
 }
 
 // 高性能的前缀删除实现
@@ -142,6 +332,20 @@ func (c *Cache) DeleteByPrefix(prefix string) {
 // Flush 清空所有缓存
 func (c *Cache) Flush() {
 	c.cache.Flush()
+	c.mu.Lock()
+	c.prefixIndex = make(map[string]map[string]struct{})
+	c.tagIndex = newTagIndex()
+	c.mu.Unlock()
+}
+
+// ScanKeys 遍历未过期的键
+func (c *Cache) ScanKeys(ctx context.Context, fn func(key string) bool) error {
+	for k := range c.cache.Items() {
+		if !fn(k) {
+			break
+		}
+	}
+	return nil
 }
 
 func GenerateKey(prefix string, query any) string {
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"webgos/common/json"
+)
+
+// Codec 分布式缓存的值序列化方式
+type Codec interface {
+	Name() string
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte) (any, error)
+}
+
+// JSONCodec JSON 序列化，可读性好、跨语言；读取结果为通用类型（map[string]any、float64 等），
+// 需要具体类型时使用 GetValue 转换
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string { return "json" }
+
+func (JSONCodec) Marshal(value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec) Unmarshal(data []byte) (any, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// GobCodec gob 序列化，读取结果保留写入时的具体类型，自定义类型需先调用 RegisterGobType 注册
+type GobCodec struct{}
+
+// gobEnvelope 以接口字段包装值，使 gob 记录具体类型
+type gobEnvelope struct {
+	Value any
+}
+
+func init() {
+	// 注册业务中常见的缓存值类型
+	for _, v := range []any{
+		map[string]bool{}, map[string]any{}, map[string]string{}, []any{}, []string{}, []int{},
+		negativeEntry{},
+	} {
+		gob.Register(v)
+	}
+}
+
+// RegisterGobType 注册使用 GobCodec 缓存的自定义类型，需在写入缓存前调用
+func RegisterGobType(value any) {
+	gob.Register(value)
+}
+
+func (GobCodec) Name() string { return "gob" }
+
+func (GobCodec) Marshal(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(gobEnvelope{Value: value}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte) (any, error) {
+	var envelope gobEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Value, nil
+}
+
+// NewCodec 按名称创建序列化方式：json（默认）、gob
+func NewCodec(name string) (Codec, error) {
+	switch name {
+	case "", "json":
+		return JSONCodec{}, nil
+	case "gob":
+		return GobCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported cache codec: %s", name)
+	}
+}
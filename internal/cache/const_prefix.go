@@ -6,4 +6,28 @@ const (
 	UserMenuPrefix = "user:menusByUserID"
 	// PermissionPrefix 用户权限缓存键前缀，格式：permissions:<userID>
 	PermissionPrefix = "permissions"
+	// TokenPrefix 登录令牌缓存键前缀，格式：token:<jwt>
+	TokenPrefix = "token:"
+	// AuthStatePrefix 用户状态与令牌版本缓存键前缀，格式：auth_state:<userID>
+	AuthStatePrefix = "auth_state:"
+	// SessionSeenPrefix 会话活跃时间节流键前缀，格式：session_seen:<会话ID>
+	SessionSeenPrefix = "session_seen:"
+	// LoginFailurePrefix 登录失败键前缀，格式：login_failure:user:<用户名> 与 login_failure:ip:<IP> 为失败计数，
+	// login_failure:last:<用户名> 为最近失败时间，login_failure:lock:<用户名> 为锁定记录
+	LoginFailurePrefix = "login_failure:"
+	// MFAChallengePrefix 两步验证挑战令牌键前缀，格式：mfa_challenge:<令牌摘要>，验证次数计数器为 mfa_challenge:<令牌摘要>:attempts
+	MFAChallengePrefix = "mfa_challenge:"
+	// MFAStepPrefix 用户最近一次通过校验的 TOTP 时间步，防止验证码重放，格式：mfa_step:<userID>，
+	// 已使用的时间步计数器为 mfa_step:<userID>:<时间步>
+	MFAStepPrefix = "mfa_step:"
+	// PasswordResetPrefix 重置密码邮件发送节流键前缀，格式：password_reset:<userID>
+	PasswordResetPrefix = "password_reset:"
+	// PasswordChangePrefix 密码过期时登录返回的修改密码令牌键前缀，格式：password_change:<令牌摘要>
+	PasswordChangePrefix = "password_change:"
+	// APIKeyPrefix API 密钥校验结果缓存键前缀，格式：api_key:<密钥摘要>
+	APIKeyPrefix = "api_key:"
+	// APIKeySeenPrefix API 密钥最近使用时间节流键前缀，格式：api_key_seen:<密钥ID>
+	APIKeySeenPrefix = "api_key_seen:"
+	// DebouncePrefix 防抖键前缀，格式：debounce:<用户ID或IP>@<路径>
+	DebouncePrefix = "debounce:"
 )
@@ -0,0 +1,269 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"webgos/internal/xlog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisDefaultTTL DefaultExpiration 对应的过期时间，与内存缓存的默认过期时间一致
+	redisDefaultTTL = 5 * time.Minute
+	// redisOpTimeout 单次缓存操作超时，Redis 不可用时快速失败并按未命中处理
+	redisOpTimeout = 500 * time.Millisecond
+	// redisScanCount SCAN 每批返回的键数量
+	redisScanCount = 500
+)
+
+// setScript 写入值并把键加入前缀索引集合与标签集合，集合的过期时间不短于其中最晚过期的键
+// KEYS[1] 缓存键，KEYS[2..n] 前缀索引集合与标签集合（可选）；ARGV[1] 值，ARGV[2] 过期毫秒数（0 表示永不过期）
+var setScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+for i = 2, #KEYS do
+	local current = redis.call('PTTL', KEYS[i])
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if ttl <= 0 then
+		redis.call('PERSIST', KEYS[i])
+	elseif current == -2 or (current >= 0 and current < ttl) then
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+return 1
+`)
+
+// RedisCache 基于 Redis 协议的分布式缓存，多实例部署时共享登出状态、权限缓存与防抖键
+// 所有键加上 prefix 命名空间；"前缀@哈希" 格式的键额外记录在前缀索引集合中，DeleteByPrefix 优先按索引删除，
+// 索引不存在时使用 SCAN 匹配删除
+// 带标签写入的键记录在 <prefix>tag:<标签> 集合中，InvalidateTags 按集合删除；键被覆盖或删除时不从原标签集合移除，
+// 失效原标签时会多删除该键，不影响正确性
+// 注意：写入时缓存键与索引集合在同一脚本中操作，Redis Cluster 下需保证二者在同一节点，建议使用单机或哨兵模式
+type RedisCache struct {
+	client redis.UniversalClient
+	codec  Codec
+	prefix string
+}
+
+// NewRedisCache 创建 Redis 缓存，codec 为空时使用 JSONCodec
+func NewRedisCache(client redis.UniversalClient, codec Codec, prefix string) *RedisCache {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &RedisCache{client: client, codec: codec, prefix: prefix}
+}
+
+func (c *RedisCache) key(key string) string {
+	return c.prefix + key
+}
+
+// indexKey 前缀索引集合的键
+func (c *RedisCache) indexKey(prefix string) string {
+	return c.prefix + "idx:" + prefix
+}
+
+// tagKey 标签集合的键
+func (c *RedisCache) tagKey(tag string) string {
+	return c.prefix + "tag:" + tag
+}
+
+func (c *RedisCache) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), redisOpTimeout)
+}
+
+// Get 读取缓存，Redis 不可用或值无法解码时按未命中处理
+func (c *RedisCache) Get(key string) (any, bool) {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	data, err := c.client.Get(ctx, c.key(key)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			xlog.Error("redis cache get %s failed: %v", key, err)
+		}
+		return nil, false
+	}
+	value, err := c.codec.Unmarshal(data)
+	if err != nil {
+		xlog.Error("redis cache decode %s failed: %v", key, err)
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *RedisCache) Set(key string, value any, duration time.Duration) {
+	c.SetWithTags(key, value, duration)
+}
+
+func (c *RedisCache) SetWithTags(key string, value any, duration time.Duration, tags ...string) {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		xlog.Error("redis cache encode %s failed: %v", key, err)
+		return
+	}
+	switch duration {
+	case DefaultExpiration:
+		duration = redisDefaultTTL
+	case NoExpiration:
+		duration = 0
+	}
+
+	keys := []string{c.key(key)}
+	if prefix := keyPrefix(key); prefix != "" {
+		keys = append(keys, c.indexKey(prefix))
+	}
+	for _, tag := range tags {
+		keys = append(keys, c.tagKey(tag))
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	if err := setScript.Run(ctx, c.client, keys, data, duration.Milliseconds()).Err(); err != nil {
+		xlog.Error("redis cache set %s failed: %v", key, err)
+	}
+}
+
+func (c *RedisCache) Delete(key string) {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	pipe := c.client.TxPipeline()
+	pipe.Del(ctx, c.key(key))
+	if prefix := keyPrefix(key); prefix != "" {
+		pipe.SRem(ctx, c.indexKey(prefix), c.key(key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		xlog.Error("redis cache delete %s failed: %v", key, err)
+	}
+}
+
+// Incr 使用 INCR 递增并重新设置过期时间；计数器保存为整数，不经过 codec，也不记录前缀索引与标签。
+// Redis 不可用时返回 0
+func (c *RedisCache) Incr(key string, duration time.Duration) int64 {
+	if duration == DefaultExpiration {
+		duration = redisDefaultTTL
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	pipe := c.client.TxPipeline()
+	incr := pipe.Incr(ctx, c.key(key))
+	if duration > 0 {
+		pipe.PExpire(ctx, c.key(key), duration)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		xlog.Error("redis cache incr %s failed: %v", key, err)
+		return 0
+	}
+	return incr.Val()
+}
+
+// Counter 读取 Incr 写入的整数，Redis 不可用或不是整数时返回 0
+func (c *RedisCache) Counter(key string) int64 {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	data, err := c.client.Get(ctx, c.key(key)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			xlog.Error("redis cache get %s failed: %v", key, err)
+		}
+		return 0
+	}
+	count, _ := strconv.ParseInt(data, 10, 64)
+	return count
+}
+
+// DeleteByPrefix 删除指定前缀的缓存：索引命中时按索引删除，否则 SCAN 匹配删除
+func (c *RedisCache) DeleteByPrefix(prefix string) {
+	ctx := context.Background()
+	indexKey := c.indexKey(prefix)
+	keys, err := c.client.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		xlog.Error("redis cache read index %s failed: %v", prefix, err)
+		return
+	}
+	if len(keys) > 0 {
+		c.del(ctx, append(keys, indexKey))
+		return
+	}
+	c.scanDelete(ctx, c.key(escapePattern(prefix))+"*")
+}
+
+// InvalidateTags 删除标签集合中的键及标签集合本身
+func (c *RedisCache) InvalidateTags(tags ...string) {
+	ctx := context.Background()
+	for _, tag := range tags {
+		tagKey := c.tagKey(tag)
+		keys, err := c.client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			xlog.Error("redis cache read tag %s failed: %v", tag, err)
+			continue
+		}
+		c.del(ctx, append(keys, tagKey))
+	}
+}
+
+// Flush 清空当前命名空间下的所有缓存，不影响同一 Redis 中的其他数据
+func (c *RedisCache) Flush() {
+	c.scanDelete(context.Background(), escapePattern(c.prefix)+"*")
+}
+
+// ScanKeys 使用 SCAN 遍历当前命名空间下的键（不含前缀索引与标签集合），返回的键已去掉命名空间
+func (c *RedisCache) ScanKeys(ctx context.Context, fn func(key string) bool) error {
+	indexPrefix, tagPrefix := c.indexKey(""), c.tagKey("")
+	iter := c.client.Scan(ctx, 0, escapePattern(c.prefix)+"*", redisScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasPrefix(key, indexPrefix) || strings.HasPrefix(key, tagPrefix) {
+			continue
+		}
+		if !fn(strings.TrimPrefix(key, c.prefix)) {
+			return nil
+		}
+	}
+	return iter.Err()
+}
+
+// Close 关闭 Redis 连接
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
+
+func (c *RedisCache) scanDelete(ctx context.Context, pattern string) {
+	iter := c.client.Scan(ctx, 0, pattern, redisScanCount).Iterator()
+	batch := make([]string, 0, redisScanCount)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == redisScanCount {
+			c.del(ctx, batch)
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		xlog.Error("redis cache scan %s failed: %v", pattern, err)
+	}
+	c.del(ctx, batch)
+}
+
+// del 逐键删除，避免多键 DEL 在 Redis Cluster 下跨槽报错
+func (c *RedisCache) del(ctx context.Context, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	pipe := c.client.Pipeline()
+	for _, k := range keys {
+		pipe.Del(ctx, k)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		xlog.Error("redis cache delete %d keys failed: %v", len(keys), err)
+	}
+}
+
+// escapePattern 转义 SCAN MATCH 中的通配符
+func escapePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,311 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// statsOther 未匹配任何已登记前缀的键的统计分组
+const statsOther = "other"
+
+var (
+	statsPrefixMu sync.RWMutex
+	// statsPrefixes 已登记的统计前缀，键按最长匹配归入分组，避免令牌、哈希等动态键产生无限多的分组
+	statsPrefixes = []string{UserPagePrefix, UserMenuPrefix, PermissionPrefix, TokenPrefix, AuthStatePrefix, SessionSeenPrefix, LoginFailurePrefix, MFAChallengePrefix, MFAStepPrefix, PasswordResetPrefix, PasswordChangePrefix, APIKeyPrefix, APIKeySeenPrefix, DebouncePrefix}
+)
+
+// RegisterStatsPrefix 登记业务缓存键前缀，统计按前缀分组；未登记前缀的键归入 other
+func RegisterStatsPrefix(prefixes ...string) {
+	statsPrefixMu.Lock()
+	defer statsPrefixMu.Unlock()
+	for _, prefix := range prefixes {
+		if prefix != "" && !contains(statsPrefixes, prefix) {
+			statsPrefixes = append(statsPrefixes, prefix)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// statsGroup 键所属的统计分组：匹配最长的已登记前缀，否则为 other
+func StatsGroup(key string) string {
+	statsPrefixMu.RLock()
+	defer statsPrefixMu.RUnlock()
+	group := ""
+	for _, prefix := range statsPrefixes {
+		if strings.HasPrefix(key, prefix) && len(prefix) > len(group) {
+			group = prefix
+		}
+	}
+	if group == "" {
+		return statsOther
+	}
+	return group
+}
+
+// KeyScanner 可遍历全部键的缓存实现，统计接口据此计算各前缀的条数；fn 返回 false 时停止遍历
+type KeyScanner interface {
+	ScanKeys(ctx context.Context, fn func(key string) bool) error
+}
+
+type counters struct {
+	hits, misses, sets, deletes, evictions atomic.Uint64
+}
+
+// Stats 按前缀分组的缓存操作计数，并发安全
+type Stats struct {
+	mu      sync.RWMutex
+	groups  map[string]*counters
+	flushes atomic.Uint64
+	// tagInvalidations 按标签失效的标签数，标签不归属于前缀分组，单独计数
+	tagInvalidations atomic.Uint64
+	since            atomic.Pointer[time.Time]
+}
+
+// NewStats 创建统计
+func NewStats() *Stats {
+	s := &Stats{groups: make(map[string]*counters)}
+	now := time.Now()
+	s.since.Store(&now)
+	return s
+}
+
+var defaultStats = NewStats()
+
+// GetStats 返回 Init 创建的缓存所使用的统计
+func GetStats() *Stats {
+	return defaultStats
+}
+
+func (s *Stats) group(key string) *counters {
+	name := StatsGroup(key)
+	s.mu.RLock()
+	c, ok := s.groups[name]
+	s.mu.RUnlock()
+	if ok {
+		return c
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok = s.groups[name]; !ok {
+		c = &counters{}
+		s.groups[name] = c
+	}
+	return c
+}
+
+// OnEvicted 记录淘汰，可直接作为 BoundedOptions.OnEvicted
+func (s *Stats) OnEvicted(key string, _ any, _ EvictReason) {
+	s.group(key).evictions.Add(1)
+}
+
+// Reset 清零所有计数
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	s.groups = make(map[string]*counters)
+	s.mu.Unlock()
+	s.flushes.Store(0)
+	s.tagInvalidations.Store(0)
+	now := time.Now()
+	s.since.Store(&now)
+}
+
+// PrefixStats 一个前缀分组的统计，Entries 为 -1 表示缓存实现不支持遍历键
+type PrefixStats struct {
+	Prefix    string  `json:"prefix"`
+	Entries   int64   `json:"entries"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRatio  float64 `json:"hit_ratio"`
+	Sets      uint64  `json:"sets"`
+	Deletes   uint64  `json:"deletes"`
+	Evictions uint64  `json:"evictions"`
+}
+
+// StatsSnapshot 统计快照，Total 为所有分组之和
+type StatsSnapshot struct {
+	Since            time.Time     `json:"since"`
+	Flushes          uint64        `json:"flushes"`
+	TagInvalidations uint64        `json:"tag_invalidations"`
+	Total            PrefixStats   `json:"total"`
+	Prefixes         []PrefixStats `json:"prefixes"`
+}
+
+// Snapshot 读取当前计数；c 实现 KeyScanner 时遍历键统计各分组的条数
+func (s *Stats) Snapshot(ctx context.Context, c ICache) (StatsSnapshot, error) {
+	entries := map[string]int64{}
+	scanner, scannable := c.(KeyScanner)
+	if scannable {
+		err := scanner.ScanKeys(ctx, func(key string) bool {
+			entries[StatsGroup(key)]++
+			return true
+		})
+		if errors.Is(err, ErrScanUnsupported) {
+			scannable = false
+		} else if err != nil {
+			return StatsSnapshot{}, err
+		}
+	}
+
+	snapshot := StatsSnapshot{Since: *s.since.Load(), Flushes: s.flushes.Load(), TagInvalidations: s.tagInvalidations.Load(), Total: PrefixStats{Prefix: "*"}}
+	s.mu.RLock()
+	names := make(map[string]bool, len(s.groups)+len(entries))
+	for name := range s.groups {
+		names[name] = true
+	}
+	for name := range entries {
+		names[name] = true
+	}
+	for name := range names {
+		p := PrefixStats{Prefix: name, Entries: -1}
+		if scannable {
+			p.Entries = entries[name]
+		}
+		if g, ok := s.groups[name]; ok {
+			p.Hits, p.Misses = g.hits.Load(), g.misses.Load()
+			p.Sets, p.Deletes, p.Evictions = g.sets.Load(), g.deletes.Load(), g.evictions.Load()
+		}
+		p.HitRatio = hitRatio(p.Hits, p.Misses)
+		snapshot.Prefixes = append(snapshot.Prefixes, p)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snapshot.Prefixes, func(i, j int) bool { return snapshot.Prefixes[i].Prefix < snapshot.Prefixes[j].Prefix })
+	total := &snapshot.Total
+	if !scannable {
+		total.Entries = -1
+	}
+	for _, p := range snapshot.Prefixes {
+		if scannable {
+			total.Entries += p.Entries
+		}
+		total.Hits += p.Hits
+		total.Misses += p.Misses
+		total.Sets += p.Sets
+		total.Deletes += p.Deletes
+		total.Evictions += p.Evictions
+	}
+	total.HitRatio = hitRatio(total.Hits, total.Misses)
+	return snapshot, nil
+}
+
+func hitRatio(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// StatsCache 为缓存增加按前缀的命中、未命中、写入与删除计数；淘汰由缓存实现通过 Stats.OnEvicted 上报
+type StatsCache struct {
+	ICache
+	stats *Stats
+}
+
+// NewStatsCache 包装缓存并记录到 stats
+func NewStatsCache(c ICache, stats *Stats) *StatsCache {
+	return &StatsCache{ICache: c, stats: stats}
+}
+
+// Unwrap 被包装的缓存
+func (c *StatsCache) Unwrap() ICache {
+	return c.ICache
+}
+
+// Stats 使用的统计
+func (c *StatsCache) Stats() *Stats {
+	return c.stats
+}
+
+func (c *StatsCache) Get(key string) (any, bool) {
+	value, ok := c.ICache.Get(key)
+	if ok {
+		c.stats.group(key).hits.Add(1)
+	} else {
+		c.stats.group(key).misses.Add(1)
+	}
+	return value, ok
+}
+
+func (c *StatsCache) Set(key string, value any, duration time.Duration) {
+	c.ICache.Set(key, value, duration)
+	c.stats.group(key).sets.Add(1)
+}
+
+func (c *StatsCache) SetWithTags(key string, value any, duration time.Duration, tags ...string) {
+	c.ICache.SetWithTags(key, value, duration, tags...)
+	c.stats.group(key).sets.Add(1)
+}
+
+func (c *StatsCache) Delete(key string) {
+	c.ICache.Delete(key)
+	c.stats.group(key).deletes.Add(1)
+}
+
+// Incr 计为一次写入
+func (c *StatsCache) Incr(key string, duration time.Duration) int64 {
+	count := c.ICache.Incr(key, duration)
+	c.stats.group(key).sets.Add(1)
+	return count
+}
+
+// Counter 计数器存在（大于 0）时计为命中
+func (c *StatsCache) Counter(key string) int64 {
+	count := c.ICache.Counter(key)
+	if count > 0 {
+		c.stats.group(key).hits.Add(1)
+	} else {
+		c.stats.group(key).misses.Add(1)
+	}
+	return count
+}
+
+func (c *StatsCache) DeleteByPrefix(prefix string) {
+	c.ICache.DeleteByPrefix(prefix)
+	c.stats.group(prefix).deletes.Add(1)
+}
+
+func (c *StatsCache) InvalidateTags(tags ...string) {
+	c.ICache.InvalidateTags(tags...)
+	c.stats.tagInvalidations.Add(uint64(len(tags)))
+}
+
+func (c *StatsCache) Flush() {
+	c.ICache.Flush()
+	c.stats.flushes.Add(1)
+}
+
+// ScanKeys 被包装的缓存支持遍历时遍历其键
+func (c *StatsCache) ScanKeys(ctx context.Context, fn func(key string) bool) error {
+	return scanKeys(ctx, c.ICache, fn)
+}
+
+// Close 关闭被包装的缓存
+func (c *StatsCache) Close() error {
+	if closer, ok := c.ICache.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ErrScanUnsupported 缓存实现不支持遍历键
+var ErrScanUnsupported = errors.New("cache: key scan not supported")
+
+func scanKeys(ctx context.Context, c ICache, fn func(key string) bool) error {
+	if scanner, ok := c.(KeyScanner); ok {
+		return scanner.ScanKeys(ctx, fn)
+	}
+	return ErrScanUnsupported
+}
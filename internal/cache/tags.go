@@ -0,0 +1,75 @@
+package cache
+
+import "strconv"
+
+// 缓存标签类型，标签格式为 <类型>:<ID>（如 user:42），由 Tag 生成
+// 写入时通过 SetWithTags 为缓存项打上其依赖的数据的标签，数据变更时 InvalidateTags 删除所有带该标签的缓存项，
+// 无需知道依赖该数据的缓存使用了哪些键前缀
+const (
+	TagUser       = "user"
+	TagRole       = "role"
+	TagMenu       = "menu"
+	TagDepartment = "department"
+	// TagUsers 用户集合标签，新增用户时失效所有用户分页
+	TagUsers = "users"
+	// TagSession 登录会话标签前缀，格式：session:<会话ID>，注销会话时使其访问令牌一并失效
+	TagSession = "session"
+)
+
+// Tag 生成标签，如 Tag(TagUser, 42) 返回 "user:42"
+func Tag(kind string, id int) string {
+	return kind + ":" + strconv.Itoa(id)
+}
+
+// tagIndex 进程内缓存的标签索引：标签到键、键到标签的双向映射，由调用方加锁
+type tagIndex struct {
+	keys map[string]map[string]struct{}
+	tags map[string][]string
+}
+
+func newTagIndex() tagIndex {
+	return tagIndex{keys: make(map[string]map[string]struct{}), tags: make(map[string][]string)}
+}
+
+// set 设置键的标签，替换键原有的标签
+func (t *tagIndex) set(key string, tags []string) {
+	t.remove(key)
+	if len(tags) == 0 {
+		return
+	}
+	t.tags[key] = tags
+	for _, tag := range tags {
+		if _, ok := t.keys[tag]; !ok {
+			t.keys[tag] = make(map[string]struct{})
+		}
+		t.keys[tag][key] = struct{}{}
+	}
+}
+
+// remove 移除键的所有标签
+func (t *tagIndex) remove(key string) {
+	for _, tag := range t.tags[key] {
+		if keys, ok := t.keys[tag]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(t.keys, tag)
+			}
+		}
+	}
+	delete(t.tags, key)
+}
+
+// keysOf 带有任一标签的键
+func (t *tagIndex) keysOf(tags []string) []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, tag := range tags {
+		for key := range t.keys[tag] {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
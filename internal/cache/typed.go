@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"webgos/common/json"
+	"webgos/common/syncx"
+	"webgos/internal/xdb"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 数据不存在。loader 返回该错误或 gorm.ErrRecordNotFound 时可被负缓存，
+// 负缓存命中时 GetOrLoad 返回该错误
+var ErrNotFound = errors.New("cache: not found")
+
+// negativeEntry 负缓存标记，JSON 序列化后为 {"__not_found__":true}
+type negativeEntry struct {
+	NotFound bool `json:"__not_found__"`
+}
+
+func isNegative(value any) bool {
+	switch v := value.(type) {
+	case negativeEntry:
+		return true
+	case map[string]any:
+		notFound, _ := v["__not_found__"].(bool)
+		return notFound && len(v) == 1
+	}
+	return false
+}
+
+// Page 分页缓存值
+type Page[T any] struct {
+	Items []T   `json:"items"`
+	Total int64 `json:"total"`
+}
+
+// TypedOption 类型化缓存选项
+type TypedOption func(*typedOptions)
+
+type typedOptions struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	cache       ICache
+}
+
+// WithTTL 缓存过期时间，默认 DefaultExpiration
+func WithTTL(ttl time.Duration) TypedOption {
+	return func(o *typedOptions) { o.ttl = ttl }
+}
+
+// WithNegativeTTL 开启负缓存：数据不存在的结果缓存 ttl，避免反复穿透查询不存在的数据
+func WithNegativeTTL(ttl time.Duration) TypedOption {
+	return func(o *typedOptions) { o.negativeTTL = ttl }
+}
+
+// WithCache 指定底层缓存，默认使用 GetCache() 的当前实现
+func WithCache(c ICache) TypedOption {
+	return func(o *typedOptions) { o.cache = c }
+}
+
+// Typed 基于 ICache 的类型化缓存句柄，进程内缓存直接存取 T，无需序列化；
+// 分布式缓存解码得到的通用类型按 JSON 转换为 T
+type Typed[T any] struct {
+	opts   typedOptions
+	flight syncx.SingleFlight
+}
+
+// NewTyped 创建类型化缓存句柄，通常作为包级变量使用
+func NewTyped[T any](opts ...TypedOption) *Typed[T] {
+	t := &Typed[T]{opts: typedOptions{ttl: DefaultExpiration}, flight: syncx.NewSingleFlight()}
+	for _, opt := range opts {
+		opt(&t.opts)
+	}
+	return t
+}
+
+func (t *Typed[T]) cache() ICache {
+	if t.opts.cache != nil {
+		return t.opts.cache
+	}
+	return GetCache()
+}
+
+// lookup 读取缓存，negative 表示命中负缓存
+func (t *Typed[T]) lookup(key string) (value T, found, negative bool) {
+	raw, ok := t.cache().Get(key)
+	if !ok {
+		return value, false, false
+	}
+	if isNegative(raw) {
+		return value, false, true
+	}
+	if v, ok := raw.(T); ok {
+		return v, true, false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil || json.Unmarshal(data, &value) != nil {
+		// 无法转换视为未命中，由调用方重新加载覆盖
+		return value, false, false
+	}
+	return value, true, false
+}
+
+// Get 读取缓存，负缓存视为未命中
+func (t *Typed[T]) Get(key string) (T, bool) {
+	value, found, _ := t.lookup(key)
+	return value, found
+}
+
+// Set 写入缓存，使用句柄的过期时间
+func (t *Typed[T]) Set(key string, value T) {
+	t.cache().Set(key, value, t.opts.ttl)
+}
+
+// SetWithTags 写入缓存并打上标签，使用句柄的过期时间
+func (t *Typed[T]) SetWithTags(key string, value T, tags ...string) {
+	t.cache().SetWithTags(key, value, t.opts.ttl, tags...)
+}
+
+// Delete 删除缓存（含负缓存）
+func (t *Typed[T]) Delete(key string) {
+	t.cache().Delete(key)
+}
+
+// GetOrLoad 读取缓存，未命中时调用 loader 加载并写入缓存
+// 同一 key 的并发未命中只执行一次 loader（共享第一个调用方的 ctx 与结果），防止缓存击穿；
+// loader 的错误不缓存，数据不存在且开启负缓存时缓存该结果，之后在负缓存有效期内直接返回 ErrNotFound
+func (t *Typed[T]) GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, error)) (T, error) {
+	return t.GetOrLoadTagged(ctx, key, func(ctx context.Context) (T, []string, error) {
+		value, err := loader(ctx)
+		return value, nil, err
+	})
+}
+
+// GetOrLoadTagged 与 GetOrLoad 相同，loader 同时返回缓存值依赖的数据标签（如 Tag(TagUser, id)），
+// 这些数据变更时调用 InvalidateTags 即可失效该缓存；负缓存不带标签
+// ctx 中有事务（xdb.TxFromContext）时，未命中加载的结果不写入缓存
+func (t *Typed[T]) GetOrLoadTagged(ctx context.Context, key string, loader func(ctx context.Context) (T, []string, error)) (T, error) {
+	if value, found, negative := t.lookup(key); found {
+		return value, nil
+	} else if negative {
+		return value, ErrNotFound
+	}
+	// 事务中读到的数据可能尚未提交或随后回滚，直接返回而不写入缓存，也不与事务外的调用方共享结果
+	if xdb.TxFromContext(ctx) != nil {
+		value, _, err := loader(ctx)
+		return value, notFoundError(err)
+	}
+
+	result, err := t.flight.Do(key, func() (any, error) {
+		// 等待期间可能已被其他调用写入
+		if value, found, negative := t.lookup(key); found {
+			return value, nil
+		} else if negative {
+			return value, ErrNotFound
+		}
+		value, tags, err := loader(ctx)
+		switch {
+		case err == nil:
+			t.SetWithTags(key, value, tags...)
+		case errors.Is(err, ErrNotFound) || errors.Is(err, gorm.ErrRecordNotFound):
+			if t.opts.negativeTTL > 0 {
+				t.cache().Set(key, negativeEntry{NotFound: true}, t.opts.negativeTTL)
+			}
+			err = notFoundError(err)
+		}
+		return value, err
+	})
+	value, _ := result.(T)
+	return value, err
+}
+
+// notFoundError 把 gorm.ErrRecordNotFound 包装为 ErrNotFound，调用方统一按 ErrNotFound 判断
+func notFoundError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
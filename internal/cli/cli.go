@@ -0,0 +1,407 @@
+// Package cli 实现 webgos 的命令行子命令
+// 除 serve 外的命令只初始化各自需要的组件（配置、日志、数据库、路由表），不监听 HTTP 端口
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"webgos/internal/bootstrap"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/models"
+	"webgos/internal/routes"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+	"webgos/internal/xdb/migrate"
+	"webgos/internal/xdb/seed"
+)
+
+// DefaultConfigPath 默认配置文件路径
+const DefaultConfigPath = "./config/config.yaml"
+
+const usage = `Usage: webgos [-c config] <command> [arguments]
+
+Commands:
+  serve                                   start the HTTP server (default)
+  migrate up [--dry-run]                  apply pending migrations
+  migrate down --to <version> [--dry-run] roll back migrations newer than version (0 = all)
+  migrate status                          show migration status
+  rbac sync                               sync route permissions to the database
+  rbac prune [--dry-run]                  delete permissions without a matching route
+  seed [file ...]                         load seed files (default: seed_files in config)
+  user create --username u [--super]      create a user (--super creates the configured super account)
+  user reset-password --username u        reset a user's password
+  config check                            load the config and run the security audit
+  routes list                             list registered routes (permission points)
+
+Passwords are read from --password, or from the first line of stdin when omitted.
+`
+
+// App 命令行应用，Serve 由 main 包提供（依赖 swagger 文档等仅在 main 中可用的包）
+type App struct {
+	Out   io.Writer
+	In    io.Reader
+	Serve func(configPath string) error
+}
+
+// Run 解析并执行命令，args 不含程序名；未指定命令时执行 serve
+func (a *App) Run(args []string) error {
+	global := flag.NewFlagSet("webgos", flag.ContinueOnError)
+	global.SetOutput(a.Out)
+	global.Usage = func() { fmt.Fprint(a.Out, usage) }
+	configPath := global.String("c", DefaultConfigPath, "Specify the config file path")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+
+	rest := global.Args()
+	if len(rest) == 0 {
+		return a.Serve(*configPath)
+	}
+
+	command, rest := rest[0], rest[1:]
+	switch command {
+	case "serve":
+		fs := a.flagSet("serve", configPath)
+		if err := fs.Parse(rest); err != nil {
+			return err
+		}
+		return a.Serve(*configPath)
+	case "migrate":
+		return a.migrate(configPath, rest)
+	case "rbac":
+		return a.rbac(configPath, rest)
+	case "seed":
+		return a.seed(configPath, rest)
+	case "user":
+		return a.user(configPath, rest)
+	case "config":
+		return a.config(configPath, rest)
+	case "routes":
+		return a.routes(configPath, rest)
+	case "help":
+		fmt.Fprint(a.Out, usage)
+		return nil
+	default:
+		fmt.Fprint(a.Out, usage)
+		return fmt.Errorf("unknown command: %s", command)
+	}
+}
+
+// flagSet 创建子命令参数集，子命令后同样支持 -c
+func (a *App) flagSet(name string, configPath *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.Out)
+	fs.Usage = func() { fmt.Fprint(a.Out, usage) }
+	fs.StringVar(configPath, "c", *configPath, "Specify the config file path")
+	return fs
+}
+
+// subcommand 拆分二级命令，如 migrate up
+func subcommand(command string, args []string, allowed ...string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("%s: missing subcommand (%s)", command, strings.Join(allowed, "|"))
+	}
+	for _, name := range allowed {
+		if args[0] == name {
+			return name, args[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("%s: unknown subcommand %s (%s)", command, args[0], strings.Join(allowed, "|"))
+}
+
+// openDatabase 加载配置、初始化日志、数据库连接与缓存，调用方需在退出前执行 cache.Close
+// 缓存按配置初始化（如 redis 与失效广播），重置密码等命令清除的令牌与 API 密钥缓存对运行中的实例立即生效
+func openDatabase(configPath string) (*config.Config, error) {
+	cfg, err := bootstrap.Setup(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := bootstrap.SetupDatabase(); err != nil {
+		return nil, err
+	}
+	if err := cache.Init(); err != nil {
+		return nil, fmt.Errorf("Cache initialization error: %v", err)
+	}
+	return cfg, nil
+}
+
+func (a *App) migrate(configPath *string, args []string) error {
+	sub, args, err := subcommand("migrate", args, "up", "down", "status")
+	if err != nil {
+		return err
+	}
+	fs := a.flagSet("migrate "+sub, configPath)
+	dryRun := fs.Bool("dry-run", false, "print the plan without changing the database")
+	to := fs.Int64("to", -1, "target version for down (0 rolls back everything)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if sub == "down" && *to < 0 {
+		return errors.New("migrate down: --to is required")
+	}
+	// 迁移不读写缓存；db 失效广播依赖迁移创建的事件表，因此只初始化数据库
+	if _, err := bootstrap.Setup(*configPath); err != nil {
+		return err
+	}
+	if err := bootstrap.SetupDatabase(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	opts := migrate.Options{DryRun: *dryRun}
+	prefix := ""
+	if *dryRun {
+		prefix = "[dry-run] "
+	}
+	switch sub {
+	case "up":
+		applied, err := migrate.Up(ctx, opts)
+		for _, m := range applied {
+			fmt.Fprintf(a.Out, "%sup   %d_%s\n", prefix, m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(a.Out, "no pending migrations")
+		}
+		return err
+	case "down":
+		rolled, err := migrate.Down(ctx, *to, opts)
+		for _, m := range rolled {
+			fmt.Fprintf(a.Out, "%sdown %d_%s\n", prefix, m.Version, m.Name)
+		}
+		if err == nil && len(rolled) == 0 {
+			fmt.Fprintln(a.Out, "nothing to roll back")
+		}
+		return err
+	default:
+		list, err := migrate.GetStatus(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(a.Out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range list {
+			status, appliedAt := "pending", ""
+			if s.Applied {
+				status = "applied"
+				appliedAt = s.AppliedAt.Format(time.DateTime)
+			}
+			if s.Dirty {
+				status = "checksum mismatch"
+			}
+			if s.Missing {
+				status = "missing in code"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		return w.Flush()
+	}
+}
+
+func (a *App) rbac(configPath *string, args []string) error {
+	sub, args, err := subcommand("rbac", args, "sync", "prune")
+	if err != nil {
+		return err
+	}
+	fs := a.flagSet("rbac "+sub, configPath)
+	dryRun := fs.Bool("dry-run", false, "print permissions to delete without deleting them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := openDatabase(*configPath)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+	// 只构建路由表以收集权限点，不监听端口
+	routes.New(cfg)
+
+	if sub == "sync" {
+		if err := routes.SyncPermissions(xdb.GetDB()); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.Out, "synced %d permissions\n", len(routes.Routes()))
+		return nil
+	}
+
+	stale, err := routes.PrunePermissions(xdb.GetDB(), *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, p := range stale {
+		if *dryRun {
+			fmt.Fprintf(a.Out, "[dry-run] ")
+		}
+		fmt.Fprintf(a.Out, "pruned %s\n", p.Name)
+	}
+	if len(stale) == 0 {
+		fmt.Fprintln(a.Out, "no stale permissions")
+	}
+	return nil
+}
+
+func (a *App) seed(configPath *string, args []string) error {
+	fs := a.flagSet("seed", configPath)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := openDatabase(*configPath)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+	files := fs.Args()
+	if len(files) == 0 {
+		files = cfg.SeedFiles
+	}
+	if len(files) == 0 {
+		return errors.New("seed: no seed files given and seed_files is empty")
+	}
+	result, err := seed.LoadFiles(context.Background(), files...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.Out, strings.ReplaceAll(result.String(), "; ", "\n"))
+	return nil
+}
+
+func (a *App) user(configPath *string, args []string) error {
+	sub, args, err := subcommand("user", args, "create", "reset-password")
+	if err != nil {
+		return err
+	}
+	fs := a.flagSet("user "+sub, configPath)
+	username := fs.String("username", "", "username")
+	password := fs.String("password", "", "password (read from stdin when omitted)")
+	nickname := fs.String("nickname", "", "nickname (create only)")
+	super := fs.Bool("super", false, "create the configured super account (create only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := openDatabase(*configPath)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+
+	if *super {
+		if *username != "" && *username != cfg.SuperAccount {
+			return fmt.Errorf("--super creates the configured super account %q, got --username %q", cfg.SuperAccount, *username)
+		}
+		*username = cfg.SuperAccount
+	}
+	if *username == "" {
+		return errors.New("--username is required")
+	}
+	if *password == "" {
+		if *password, err = a.readLine(); err != nil {
+			return err
+		}
+	}
+	if *password == "" {
+		return errors.New("password is required")
+	}
+
+	ctx := context.Background()
+	userService := services.NewUserService()
+	if sub == "reset-password" {
+		if err := userService.ResetPassword(ctx, *username, *password); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.Out, "password of %s has been reset\n", *username)
+		return nil
+	}
+
+	var count int64
+	if err := xdb.GetDB().Model(&models.User{}).Where("username = ?", *username).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("user %s already exists", *username)
+	}
+	if *nickname == "" {
+		*nickname = *username
+	}
+	user := &models.User{Username: *username, Nickname: *nickname, Password: *password}
+	if err := userService.CreateOrUpdateUser(ctx, user); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.Out, "user %s created (id=%d)\n", user.Username, user.ID)
+	return nil
+}
+
+func (a *App) config(configPath *string, args []string) error {
+	if _, args, err := subcommand("config", args, "check"); err != nil {
+		return err
+	} else if err := a.flagSet("config check", configPath).Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	for _, source := range config.Sources() {
+		fmt.Fprintf(a.Out, "%s <- %s\n", source.Path, source)
+	}
+	findings := config.Audit(cfg)
+	if len(findings) == 0 {
+		fmt.Fprintln(a.Out, "config OK, no security findings")
+		return nil
+	}
+	fmt.Fprintln(a.Out, config.FormatFindings(findings))
+	if cfg.Server.Mode == "release" && config.HasCritical(findings) {
+		return errors.New("config check failed: critical findings in release mode")
+	}
+	return nil
+}
+
+func (a *App) routes(configPath *string, args []string) error {
+	if _, args, err := subcommand("routes", args, "list"); err != nil {
+		return err
+	} else if err := a.flagSet("routes list", configPath).Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := bootstrap.Setup(*configPath)
+	if err != nil {
+		return err
+	}
+	routes.New(cfg)
+
+	list := routes.Routes()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Path == list[j].Path {
+			return list[i].Method < list[j].Method
+		}
+		return list[i].Path < list[j].Path
+	})
+	w := tabwriter.NewWriter(a.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tDESCRIPTION")
+	for _, r := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Method, r.Path, r.Description)
+	}
+	return w.Flush()
+}
+
+// readLine 从标准输入读取一行（如 echo "$PASS" | webgos user create ...），避免密码出现在进程参数中
+func (a *App) readLine() (string, error) {
+	in := a.In
+	if in == nil {
+		in = os.Stdin
+	}
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
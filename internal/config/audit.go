@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 审计问题级别
+const (
+	SeverityCritical = "CRITICAL" // release 模式下拒绝启动
+	SeverityWarning  = "WARNING"  // 仅输出警告
+)
+
+// minJWTSecretLen HS256 密钥最小长度（字节）
+const minJWTSecretLen = 32
+
+// 已知的默认或示例 JWT 密钥
+var weakJWTSecrets = map[string]bool{
+	DefaultJWTSecret: true,
+	"cyp_secret_key": true,
+	"secret":         true,
+	"changeme":       true,
+}
+
+// 常见弱口令，仅用于数据库密码检查
+var weakPasswords = map[string]bool{
+	"123456":   true,
+	"12345678": true,
+	"password": true,
+	"root":     true,
+	"postgres": true,
+	"admin":    true,
+}
+
+// Finding 单条安全审计结果
+type Finding struct {
+	Severity string // CRITICAL 或 WARNING
+	Field    string // 相关配置路径
+	Rule     string // 规则标识
+	Message  string // 问题描述
+}
+
+// Audit 对配置进行安全审计，返回发现的问题
+// release 模式下存在 CRITICAL 级别问题时应拒绝启动，debug 模式仅输出警告
+func Audit(cfg *Config) []Finding {
+	findings := make([]Finding, 0)
+	add := func(severity, field, rule, format string, args ...any) {
+		findings = append(findings, Finding{Severity: severity, Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// 配置非对称密钥后 secret 不再用于签发访问令牌
+	switch {
+	case len(cfg.JWT.Keys) > 0:
+	case weakJWTSecrets[cfg.JWT.Secret]:
+		add(SeverityCritical, "jwt.secret", "default-secret", "JWT secret is a known default value")
+	case len(cfg.JWT.Secret) < minJWTSecretLen:
+		add(SeverityCritical, "jwt.secret", "short-secret", "JWT secret is shorter than %d bytes", minJWTSecretLen)
+	}
+
+	// TOTP 密钥的加密密钥材料，未配置时使用 jwt.secret；HS256 模式下已由上面的检查覆盖
+	if cfg.MFA.SecretKey != "" || len(cfg.JWT.Keys) > 0 {
+		material := cfg.MFA.SecretKey
+		if material == "" {
+			material = cfg.JWT.Secret
+		}
+		if weakJWTSecrets[material] || len(material) < minJWTSecretLen {
+			add(SeverityCritical, "mfa.secret_key", "weak-mfa-key", "MFA secret key is a known default value or shorter than %d bytes", minJWTSecretLen)
+		}
+	}
+
+	if cfg.JWT.Expiry != 0 {
+		add(SeverityWarning, "jwt.expiry", "deprecated-expiry", "jwt.expiry is deprecated and ignored, use jwt.access_expiry (minutes) and jwt.refresh_expiry (hours)")
+	}
+
+	if cfg.Server.Swag && !isLoopbackAddr(cfg.Server.Host) {
+		add(SeverityCritical, "server.swag", "public-swagger", "Swagger UI is exposed on public interface %q", displayHost(cfg.Server.Host))
+	}
+	if cfg.Server.Pprof {
+		host, _, err := net.SplitHostPort(cfg.Server.PprofAddr)
+		if err != nil || !isLoopbackAddr(host) {
+			add(SeverityCritical, "server.pprof_addr", "public-pprof", "pprof is exposed on public address %q", cfg.Server.PprofAddr)
+		}
+	}
+
+	if cfg.CORS.AllowCredentials {
+		for _, origin := range cfg.CORS.AllowOrigins {
+			if origin == "*" {
+				add(SeverityCritical, "cors.allow_origins", "cors-wildcard-credentials", "CORS allows any origin together with credentials")
+				break
+			}
+		}
+	}
+
+	if strings.TrimSpace(cfg.SuperAccount) == "" {
+		add(SeverityCritical, "super_account", "empty-super-account", "super account is empty")
+	}
+
+	if weakPasswords[cfg.Database.Password] {
+		add(SeverityWarning, "database.password", "weak-db-password", "database password is a common weak password")
+	}
+	for i, slave := range cfg.Database.Slaves {
+		if weakPasswords[slave.Password] {
+			add(SeverityWarning, fmt.Sprintf("database.slaves.%d.password", i), "weak-db-password", "database password is a common weak password")
+		}
+	}
+
+	if cfg.Cache.Driver == "redis" && cfg.Cache.Redis.Password == "" {
+		host, _, err := net.SplitHostPort(cfg.Cache.Redis.Addr)
+		if err != nil || !isLoopbackAddr(host) {
+			add(SeverityWarning, "cache.redis.password", "redis-no-password", "Redis at %q is used without a password", cfg.Cache.Redis.Addr)
+		}
+	}
+
+	// log 与 file 方式把重置密码链接写入日志或文件，仅适合本地调试
+	if cfg.Mail.Driver == "log" || cfg.Mail.Driver == "file" {
+		add(SeverityCritical, "mail.driver", "mail-not-delivered", "mail driver %q does not deliver mail, password reset links are written to local logs or files", cfg.Mail.Driver)
+	}
+	if cfg.Mail.Driver == "smtp" && cfg.Mail.SMTP.TLS == "none" && !isLoopbackAddr(cfg.Mail.SMTP.Host) {
+		add(SeverityWarning, "mail.smtp.tls", "smtp-plaintext", "SMTP server %q is used without TLS", cfg.Mail.SMTP.Host)
+	}
+
+	return findings
+}
+
+// HasCritical 是否存在 CRITICAL 级别问题
+func HasCritical(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityCritical {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatFindings 将审计结果格式化为多行报告
+func FormatFindings(findings []Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "security audit found %d issue(s):", len(findings))
+	for _, f := range findings {
+		fmt.Fprintf(&b, "\n  [%s] %-22s %-26s %s", f.Severity, f.Field, f.Rule, f.Message)
+	}
+	return b.String()
+}
+
+// isLoopbackAddr 判断监听地址是否仅限本机，为空或 0.0.0.0 均视为公开
+func isLoopbackAddr(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func displayHost(host string) string {
+	if host == "" {
+		return "0.0.0.0"
+	}
+	return host
+}
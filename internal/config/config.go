@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,10 +15,22 @@ type DBConfig struct {
 	Username     string `yaml:"username"`
 	Password     string `yaml:"password"`
 	DBName       string `yaml:"dbname"`
-	Dialect      string `yaml:"dialect"`
+	Dialect      string `yaml:"dialect"` // mysql, postgres, sqlite
 	MaxOpenConns int    `yaml:"max_open_conns"`
 	MaxIdleConns int    `yaml:"max_idle_conns"`
 	MaxLifetime  int    `yaml:"max_lifetime"`
+	Weight       int    `yaml:"weight"` // 备库权重，仅 weighted 策略使用，默认 1
+}
+
+// DefaultJWTSecret 未配置 jwt.secret 时使用的默认密钥，不可用于生产环境
+const DefaultJWTSecret = "sean_secret_key"
+
+// JWTKey JWT 非对称签名密钥，算法由密钥类型决定（RSA 为 RS256，Ed25519 为 EdDSA）
+// 只配置公钥的密钥为已退役的密钥，仅用于校验轮换前签发的令牌
+type JWTKey struct {
+	Kid            string `yaml:"kid"`              // 密钥标识，写入令牌头部
+	PrivateKeyFile string `yaml:"private_key_file"` // PEM 私钥文件（PKCS#1 或 PKCS#8）
+	PublicKeyFile  string `yaml:"public_key_file"`  // PEM 公钥文件（PKIX），配置私钥时可省略
 }
 
 // Config 配置结构体
@@ -27,33 +40,116 @@ type Config struct {
 		DBConfig `yaml:",inline"`
 		// 读写分离策略（新增）
 		ReadWriteSeparation bool   `yaml:"read_write_separation"` // 是否启用读写分离
-		SlaveLoadBalance    string `yaml:"slave_load_balance"`    // 备库负载均衡策略：random, round_robin（轮询）
+		SlaveLoadBalance    string `yaml:"slave_load_balance"`    // 备库负载均衡策略：random, round_robin（轮询）, weighted（加权轮询）
+		HealthCheckInterval int    `yaml:"health_check_interval"` // 备库健康检查间隔（秒），默认 5，不能为负数
+		StickyMaster        int    `yaml:"sticky_master"`         // 写后读主库窗口（秒）：同一用户/请求写入后的读操作走主库，默认 3，负数关闭
 		// 备库配置（新增）
 		Slaves []DBConfig `yaml:"slaves"`
-	} `yaml:"database"`
+	} `yaml:"database" reload:"restart"`
 	Server struct {
-		Mode  string `yaml:"mode"`  // "debug" 或 "release"
-		Port  int    `yaml:"port"`  // 服务器端口
-		Swag  bool   `yaml:"swag"`  // 是否启用 Swagger 文档接口
-		Pprof bool   `yaml:"pprof"` // 是否启用 pprof 性能分析接口（独立 debug 端口）
+		Mode      string `yaml:"mode" reload:"restart"`       // "debug" 或 "release"
+		Host      string `yaml:"host" reload:"restart"`       // 监听地址，为空表示所有网卡
+		Port      int    `yaml:"port" reload:"restart"`       // 服务器端口
+		Swag      bool   `yaml:"swag" reload:"restart"`       // 是否启用 Swagger 文档接口
+		Pprof     bool   `yaml:"pprof" reload:"restart"`      // 是否启用 pprof 性能分析接口（独立 debug 端口）
+		PprofAddr string `yaml:"pprof_addr" reload:"restart"` // pprof 监听地址，默认 :6060
 	} `yaml:"server"`
 	Runtime struct {
 		Dir string `yaml:"dir"` // 运行时数据目录，日志、黑名单等文件均存放于此
-	} `yaml:"runtime"`
+	} `yaml:"runtime" reload:"restart"`
 	Log struct {
 		Level    string `yaml:"level"`     // 日志级别:  Error, Warn, Info
 		Access   bool   `yaml:"access"`    // 是否启用访问日志
 		LevelSQL string `yaml:"level_sql"` // SQL日志级别: Silent, Error, Warn, Info
 	} `yaml:"log"`
 	JWT struct {
-		Secret string `yaml:"secret"`
-		Expiry int    `yaml:"expiry"` // 小时
+		Secret        string `yaml:"secret"`
+		Expiry        int    `yaml:"expiry"`         // 已废弃：旧版单令牌有效期（小时），由 access_expiry 与 refresh_expiry 取代
+		AccessExpiry  int    `yaml:"access_expiry"`  // 访问令牌有效期（分钟），默认 15
+		RefreshExpiry int    `yaml:"refresh_expiry"` // 刷新令牌有效期（小时），默认 168（7 天）
+		MaxSessions   int    `yaml:"max_sessions"`   // 每个用户最多同时有效的会话数，超出时注销最久未活跃的会话，0 表示不限制
+		// 非对称签名：配置 keys 后使用 signing_key 对应的私钥签名，secret 不再用于签发与校验访问令牌
+		SigningKey string   `yaml:"signing_key"` // 签名密钥的 kid，默认 keys 中第一个配置了私钥的密钥
+		Keys       []JWTKey `yaml:"keys"`        // 签名与校验密钥集，轮换时新增密钥并切换 signing_key，旧密钥保留公钥直至其令牌过期
 	} `yaml:"jwt"`
 	Website struct {
 		Dir           string `yaml:"dir"`             // website 根目录
 		UploadUrl     string `yaml:"upload_url"`      // 临时文件目录
 		UploadTempUrl string `yaml:"upload_temp_url"` // 临时文件目录
 	} `yaml:"website"`
+	Limiter struct {
+		AuthRate     int `yaml:"auth_rate"`     // /auth 路由每个IP每秒填充的令牌数
+		AuthCapacity int `yaml:"auth_capacity"` // /auth 路由令牌桶容量（允许的瞬时突发量）
+	} `yaml:"limiter"`
+	Lockout struct {
+		MaxFailures   int `yaml:"max_failures"`    // 账号连续登录失败多少次后锁定，默认 5，负数关闭账号锁定
+		LockDuration  int `yaml:"lock_duration"`   // 锁定时长（分钟），默认 15
+		Window        int `yaml:"window"`          // 失败计数窗口（分钟），最近一次失败超过窗口后计数清零，默认 15
+		BaseDelay     int `yaml:"base_delay"`      // 渐进延迟基数（毫秒），第 n 次失败后需等待 base_delay*2^(n-1) 才能再次尝试，默认 1000，负数关闭
+		IPMaxFailures int `yaml:"ip_max_failures"` // 同一 IP 在窗口内登录失败（不分账号）达到次数时加入 IP 黑名单，默认 50，负数关闭
+	} `yaml:"lockout"`
+	MFA struct {
+		Issuer          string `yaml:"issuer"`                      // 验证器应用中显示的发行方名称，默认 webgos
+		ChallengeExpiry int    `yaml:"challenge_expiry"`            // 两步验证挑战令牌有效期（分钟），密码校验通过后需在此时间内提交验证码，默认 5
+		SecretKey       string `yaml:"secret_key" reload:"restart"` // 加密 TOTP 密钥的密钥材料，为空时使用 jwt.secret；修改后已保存的密钥无法解密
+	} `yaml:"mfa"`
+	Mail struct {
+		Driver string `yaml:"driver"` // 发送方式：none（默认，不发送，找回密码不可用）、smtp、log 与 file（写入日志或 .eml 文件，仅供本地调试）
+		From   string `yaml:"from"`   // 发件人地址，smtp 必填，如 "webgos <noreply@example.com>"
+		Dir    string `yaml:"dir"`    // file 方式的输出目录，默认 <runtime.dir>/mail
+		SMTP   struct {
+			Host     string `yaml:"host"`
+			Port     int    `yaml:"port"`     // 默认 587
+			Username string `yaml:"username"` // 为空时不认证
+			Password string `yaml:"password"` // 建议通过 WEBGOS_MAIL_SMTP_PASSWORD_FILE 注入
+			TLS      string `yaml:"tls"`      // starttls（默认）、ssl（隐式 TLS，通常为 465 端口）、none（仅限内网中继）
+		} `yaml:"smtp"`
+	} `yaml:"mail"`
+	PasswordReset struct {
+		URL         string `yaml:"url"`          // 前端重置密码页面地址，邮件中的链接为 <url>?token=<令牌>
+		TokenExpiry int    `yaml:"token_expiry"` // 重置令牌有效期（分钟），默认 30
+	} `yaml:"password_reset"`
+	PasswordPolicy struct {
+		MinLength        int      `yaml:"min_length"`        // 最小字符数，默认 6
+		MinClasses       int      `yaml:"min_classes"`       // 至少包含的字符类别数（大写字母、小写字母、数字、符号），0-4，默认 0 不限制
+		BanCommon        bool     `yaml:"ban_common"`        // 禁止使用内置列表中的常见弱密码
+		Banned           []string `yaml:"banned"`            // 额外禁止的密码（不区分大小写），如公司名、产品名
+		DisallowUsername bool     `yaml:"disallow_username"` // 密码不能包含用户名（不区分大小写）
+		History          int      `yaml:"history"`           // 不能与最近 N 次使用过的密码（含当前密码）相同，0 不限制
+		MaxAge           int      `yaml:"max_age"`           // 密码有效期（天），过期后登录时必须先修改密码，0 不过期
+	} `yaml:"password_policy"`
+	APIKey struct {
+		MaxPerUser int `yaml:"max_per_user"` // 每个用户最多拥有的 API 密钥数，默认 10
+		MaxExpiry  int `yaml:"max_expiry"`   // API 密钥最长有效期（天），0 允许永不过期；设置后创建时不指定有效期即使用该值
+	} `yaml:"api_key"`
+	Cache struct {
+		Driver string `yaml:"driver"` // 缓存驱动：memory（进程内，默认）、bounded（有界进程内）、redis（分布式，集群部署时使用）
+		Codec  string `yaml:"codec"`  // redis 值序列化方式：json（默认）、gob
+		Redis  struct {
+			Addr     string `yaml:"addr"`      // 地址，如 127.0.0.1:6379
+			Username string `yaml:"username"`  // ACL 用户名，可为空
+			Password string `yaml:"password"`  // 密码
+			DB       int    `yaml:"db"`        // 数据库编号
+			Prefix   string `yaml:"prefix"`    // 键前缀，隔离同一 Redis 中的多个应用，默认 webgos:
+			PoolSize int    `yaml:"pool_size"` // 连接池大小，0 使用客户端默认值
+		} `yaml:"redis"`
+		Bounded struct {
+			MaxEntries int            `yaml:"max_entries"` // 最大条数，默认 100000
+			MaxMemory  int            `yaml:"max_memory"`  // 近似内存预算（MB），默认 256
+			Policy     string         `yaml:"policy"`      // 淘汰策略：lru（默认）、lfu
+			Quotas     map[string]int `yaml:"quotas"`      // 前缀配额：键前缀 -> 最多保留的条数
+		} `yaml:"bounded"`
+		Bus struct {
+			Driver       string `yaml:"driver"`        // 失效广播：none（默认）、memory（单进程）、db（数据库轮询）、redis（发布订阅）
+			NodeID       string `yaml:"node_id"`       // 实例标识，默认 主机名-随机串
+			PollInterval int    `yaml:"poll_interval"` // db 轮询间隔（毫秒），默认 1000
+			Channel      string `yaml:"channel"`       // redis 频道，默认 <redis.prefix>cache:invalidate
+		} `yaml:"bus"`
+	} `yaml:"cache" reload:"restart"`
+	CORS struct {
+		AllowOrigins     []string `yaml:"allow_origins"`     // 允许的跨域来源，"*" 表示任意来源
+		AllowCredentials bool     `yaml:"allow_credentials"` // 是否允许携带凭证（Cookie 等）
+	} `yaml:"cors"`
 
 	// 自动迁移配置
 	AutoMigrate bool `yaml:"auto_migrate"`
@@ -61,43 +157,74 @@ type Config struct {
 	AutoRBACPoint bool `yaml:"auto_rbac_point"`
 	// 超级管理员账号
 	SuperAccount string `yaml:"super_account"`
+	// 种子数据文件（YAML/JSON），仅在启动时按自然键幂等加载
+	SeedFiles []string `yaml:"seed_files"`
 }
 
-var GlobalConfig *Config
-
-// LoadConfig 从文件加载配置
+// LoadConfig 从文件加载配置并作为首个快照生效
+// 加载顺序：YAML 文件 -> WEBGOS_* 环境变量 -> WEBGOS_*_FILE 密钥文件 -> 校验并填充默认值
 func LoadConfig(configPath string) (*Config, error) {
+	cfg, sources, err := load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	current.Store(&Snapshot{Version: 1, Config: cfg, Sources: sources, LoadedAt: time.Now()})
+	return cfg, nil
+}
+
+// Sources 返回当前快照中各配置项的生效来源，按配置路径排序
+func Sources() []ValueSource {
+	if snap := current.Load(); snap != nil {
+		return snap.Sources
+	}
+	return nil
+}
+
+// load 读取并解析配置文件，叠加环境变量后校验，不影响当前生效的快照
+func load(configPath string) (*Config, []ValueSource, error) {
 	yamlFile, err := os.ReadFile(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(yamlFile, cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
-	GlobalConfig = &Config{}
-	if err := yaml.Unmarshal(yamlFile, GlobalConfig); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	raw := make(map[string]any)
+	if err := yaml.Unmarshal(yamlFile, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	if err := validateConfig(GlobalConfig); err != nil {
-		return nil, err
+	sources, err := applyOverlay(cfg, raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateConfig(cfg); err != nil {
+		return nil, nil, err
 	}
 
-	return GlobalConfig, nil
+	return cfg, collectSources(cfg, sources), nil
 }
 
 // validateConfig 验证配置有效性
 func validateConfig(config *Config) error {
 	errs := make([]string, 0)
 
-	if config.Database.Host == "" {
-		errs = append(errs, "database host is required")
-	}
-	if config.Database.Port == 0 {
-		errs = append(errs, "database port is required")
-	}
-	if config.Database.Username == "" {
-		errs = append(errs, "database username is required")
-	}
-	if config.Database.Password == "" {
-		errs = append(errs, "database password is required")
+	// sqlite 为本地文件（或内存）数据库，无需主机与账号
+	if config.Database.Dialect != "sqlite" {
+		if config.Database.Host == "" {
+			errs = append(errs, "database host is required")
+		}
+		if config.Database.Port == 0 {
+			errs = append(errs, "database port is required")
+		}
+		if config.Database.Username == "" {
+			errs = append(errs, "database username is required")
+		}
+		if config.Database.Password == "" {
+			errs = append(errs, "database password is required")
+		}
 	}
 	if config.Database.DBName == "" {
 		errs = append(errs, "database dbname is required")
@@ -105,6 +232,102 @@ func validateConfig(config *Config) error {
 	if config.Server.Port == 0 {
 		errs = append(errs, "server port is required")
 	}
+	switch config.Database.SlaveLoadBalance {
+	case "", "random", "round_robin", "weighted":
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported slave_load_balance: %s", config.Database.SlaveLoadBalance))
+	}
+	if config.Database.HealthCheckInterval < 0 {
+		errs = append(errs, "database health_check_interval must not be negative")
+	}
+	for i, slave := range config.Database.Slaves {
+		if slave.Weight < 0 {
+			errs = append(errs, fmt.Sprintf("database slaves.%d weight must not be negative", i))
+		}
+	}
+	switch config.Cache.Driver {
+	case "", "memory", "bounded":
+	case "redis":
+		if config.Cache.Redis.Addr == "" {
+			errs = append(errs, "cache redis addr is required")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported cache driver: %s", config.Cache.Driver))
+	}
+	switch config.Cache.Bus.Driver {
+	case "", "none", "memory", "db":
+	case "redis":
+		if config.Cache.Redis.Addr == "" {
+			errs = append(errs, "cache redis addr is required by the redis invalidation bus")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported cache bus driver: %s", config.Cache.Bus.Driver))
+	}
+	if config.JWT.AccessExpiry < 0 || config.JWT.RefreshExpiry < 0 {
+		errs = append(errs, "jwt access_expiry and refresh_expiry must not be negative")
+	} else if config.JWT.AccessExpiry > 0 && config.JWT.RefreshExpiry > 0 && config.JWT.AccessExpiry >= config.JWT.RefreshExpiry*60 {
+		errs = append(errs, "jwt access_expiry must be shorter than refresh_expiry")
+	}
+	switch config.Mail.Driver {
+	case "", "none", "log", "file":
+	case "smtp":
+		if config.Mail.SMTP.Host == "" || config.Mail.From == "" {
+			errs = append(errs, "mail smtp driver requires mail.smtp.host and mail.from")
+		}
+		switch config.Mail.SMTP.TLS {
+		case "", "starttls", "ssl", "none":
+		default:
+			errs = append(errs, fmt.Sprintf("unsupported mail smtp tls mode: %s", config.Mail.SMTP.TLS))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported mail driver: %s", config.Mail.Driver))
+	}
+	policy := config.PasswordPolicy
+	if policy.MinLength < 0 || policy.History < 0 || policy.MaxAge < 0 {
+		errs = append(errs, "password_policy min_length, history and max_age must not be negative")
+	}
+	if policy.MinClasses < 0 || policy.MinClasses > 4 {
+		errs = append(errs, "password_policy min_classes must be between 0 and 4")
+	}
+	if config.APIKey.MaxPerUser < 0 || config.APIKey.MaxExpiry < 0 {
+		errs = append(errs, "api_key max_per_user and max_expiry must not be negative")
+	}
+	if config.JWT.MaxSessions < 0 {
+		errs = append(errs, "jwt max_sessions must not be negative")
+	}
+	kids := make(map[string]bool, len(config.JWT.Keys))
+	for i, key := range config.JWT.Keys {
+		switch {
+		case key.Kid == "":
+			errs = append(errs, fmt.Sprintf("jwt keys.%d kid is required", i))
+		case kids[key.Kid]:
+			errs = append(errs, fmt.Sprintf("duplicate jwt key kid: %s", key.Kid))
+		case key.PrivateKeyFile == "" && key.PublicKeyFile == "":
+			errs = append(errs, fmt.Sprintf("jwt key %s requires private_key_file or public_key_file", key.Kid))
+		}
+		if config.JWT.SigningKey == key.Kid && key.PrivateKeyFile == "" {
+			errs = append(errs, fmt.Sprintf("jwt signing_key %s has no private_key_file", key.Kid))
+		}
+		kids[key.Kid] = true
+	}
+	if config.JWT.SigningKey != "" && !kids[config.JWT.SigningKey] {
+		errs = append(errs, fmt.Sprintf("jwt signing_key %s not found in keys", config.JWT.SigningKey))
+	}
+	switch config.Cache.Bounded.Policy {
+	case "", "lru", "lfu":
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported cache bounded policy: %s", config.Cache.Bounded.Policy))
+	}
+	for prefix, quota := range config.Cache.Bounded.Quotas {
+		if prefix == "" || quota <= 0 {
+			errs = append(errs, fmt.Sprintf("cache bounded quota %q must have a non-empty prefix and a positive limit", prefix))
+		}
+	}
+	switch config.Cache.Codec {
+	case "", "json", "gob":
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported cache codec: %s", config.Cache.Codec))
+	}
 
 	if len(errs) > 0 {
 		return fmt.Errorf("invalid config: %s", strings.Join(errs, ", "))
@@ -124,6 +347,45 @@ func validateConfig(config *Config) error {
 	if config.Database.MaxLifetime == 0 {
 		config.Database.MaxLifetime = 60
 	}
+	if config.Database.StickyMaster == 0 {
+		config.Database.StickyMaster = 3
+	}
+	if config.Database.HealthCheckInterval == 0 {
+		config.Database.HealthCheckInterval = 5
+	}
+	for i := range config.Database.Slaves {
+		if config.Database.Slaves[i].Weight == 0 {
+			config.Database.Slaves[i].Weight = 1
+		}
+	}
+
+	if config.Cache.Driver == "" {
+		config.Cache.Driver = "memory"
+	}
+	if config.Cache.Codec == "" {
+		config.Cache.Codec = "json"
+	}
+	if config.Cache.Bounded.MaxEntries == 0 {
+		config.Cache.Bounded.MaxEntries = 100000
+	}
+	if config.Cache.Bounded.MaxMemory == 0 {
+		config.Cache.Bounded.MaxMemory = 256
+	}
+	if config.Cache.Bounded.Policy == "" {
+		config.Cache.Bounded.Policy = "lru"
+	}
+	if config.Cache.Redis.Prefix == "" {
+		config.Cache.Redis.Prefix = "webgos:"
+	}
+	if config.Cache.Bus.Driver == "" {
+		config.Cache.Bus.Driver = "none"
+	}
+	if config.Cache.Bus.PollInterval == 0 {
+		config.Cache.Bus.PollInterval = 1000
+	}
+	if config.Cache.Bus.Channel == "" {
+		config.Cache.Bus.Channel = config.Cache.Redis.Prefix + "cache:invalidate"
+	}
 
 	if config.Runtime.Dir == "" {
 		config.Runtime.Dir = "./runtime"
@@ -134,8 +396,70 @@ func validateConfig(config *Config) error {
 	if config.Log.LevelSQL == "" {
 		config.Log.LevelSQL = "Info"
 	}
+	if config.Server.PprofAddr == "" {
+		config.Server.PprofAddr = ":6060"
+	}
 	if config.JWT.Secret == "" {
-		config.JWT.Secret = "sean_secret_key"
+		// 仅方便本地调试，release 模式下安全审计会拒绝启动
+		config.JWT.Secret = DefaultJWTSecret
+	}
+	if config.JWT.AccessExpiry == 0 {
+		config.JWT.AccessExpiry = 15
+	}
+	if config.JWT.RefreshExpiry == 0 {
+		config.JWT.RefreshExpiry = 168
+	}
+
+	if config.Limiter.AuthRate == 0 {
+		config.Limiter.AuthRate = 1
+	}
+	if config.Limiter.AuthCapacity == 0 {
+		config.Limiter.AuthCapacity = 1
+	}
+	if config.Lockout.MaxFailures == 0 {
+		config.Lockout.MaxFailures = 5
+	}
+	if config.Lockout.LockDuration <= 0 {
+		config.Lockout.LockDuration = 15
+	}
+	if config.Lockout.Window <= 0 {
+		config.Lockout.Window = 15
+	}
+	if config.Lockout.BaseDelay == 0 {
+		config.Lockout.BaseDelay = 1000
+	}
+	if config.Lockout.IPMaxFailures == 0 {
+		config.Lockout.IPMaxFailures = 50
+	}
+	if config.MFA.Issuer == "" {
+		config.MFA.Issuer = "webgos"
+	}
+	if config.MFA.ChallengeExpiry <= 0 {
+		config.MFA.ChallengeExpiry = 5
+	}
+	if config.Mail.Driver == "" {
+		config.Mail.Driver = "none"
+	}
+	if config.Mail.Dir == "" {
+		config.Mail.Dir = config.Runtime.Dir + "/mail"
+	}
+	if config.Mail.SMTP.Port == 0 {
+		config.Mail.SMTP.Port = 587
+	}
+	if config.Mail.SMTP.TLS == "" {
+		config.Mail.SMTP.TLS = "starttls"
+	}
+	if config.PasswordReset.TokenExpiry <= 0 {
+		config.PasswordReset.TokenExpiry = 30
+	}
+	if config.PasswordPolicy.MinLength == 0 {
+		config.PasswordPolicy.MinLength = 6
+	}
+	if config.APIKey.MaxPerUser == 0 {
+		config.APIKey.MaxPerUser = 10
+	}
+	if len(config.CORS.AllowOrigins) == 0 {
+		config.CORS.AllowOrigins = []string{"*"}
 	}
 
 	// 设置上传目录默认值
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 环境变量前缀
+// 配置路径按 yaml 标签转大写后以下划线连接，例如：
+//
+//	jwt.secret              -> WEBGOS_JWT_SECRET
+//	database.password       -> WEBGOS_DATABASE_PASSWORD（主库配置为 inline，不额外增加层级）
+//	database.slaves[0].host -> WEBGOS_DATABASE_SLAVES_0_HOST
+//
+// 任意变量名追加 _FILE 后缀表示从挂载的密钥文件读取，例如 WEBGOS_JWT_SECRET_FILE=/run/secrets/jwt
+const EnvPrefix = "WEBGOS"
+
+// 配置值来源
+const (
+	SourceDefault = "default" // 代码默认值
+	SourceYAML    = "yaml"    // 配置文件
+	SourceEnv     = "env"     // 环境变量
+	SourceFile    = "file"    // 环境变量指向的密钥文件
+)
+
+// ValueSource 记录单个配置项最终生效的来源，仅用于启动时输出，不包含值本身，避免泄露密钥
+type ValueSource struct {
+	Path   string // 配置路径，如 database.slaves.0.host
+	Source string // 来源：default, yaml, env, file
+	Ref    string // 来源引用：环境变量名或密钥文件路径
+}
+
+func (s ValueSource) String() string {
+	if s.Ref == "" {
+		return s.Source
+	}
+	return s.Source + ":" + s.Ref
+}
+
+// 匹配环境变量中切片下标，如 WEBGOS_DATABASE_SLAVES_3_HOST 中的 3
+var envIndexRegex = regexp.MustCompile(`^(\d+)_`)
+
+// applyOverlay 在 YAML 解析结果之上叠加环境变量与密钥文件，返回每个配置项的来源
+// raw 为同一份 YAML 解析出的原始 map，用于判断某个配置项是否在文件中出现过
+func applyOverlay(cfg *Config, raw map[string]any) (map[string]ValueSource, error) {
+	present := make(map[string]bool)
+	flattenPaths(raw, "", present)
+
+	sources := make(map[string]ValueSource)
+	errs := make([]string, 0)
+	walkLeaves(reflect.ValueOf(cfg).Elem(), nil, true, func(path []string, field reflect.Value) {
+		key := strings.Join(path, ".")
+		envName := envNameOf(path)
+
+		value, envSet := os.LookupEnv(envName)
+		filePath, fileSet := os.LookupEnv(envName + "_FILE")
+		switch {
+		case envSet && fileSet:
+			errs = append(errs, fmt.Sprintf("%s and %s_FILE are both set", envName, envName))
+			return
+		case fileSet:
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s_FILE: %v", envName, err))
+				return
+			}
+			if err := setFieldValue(field, strings.TrimRight(string(content), "\r\n")); err != nil {
+				errs = append(errs, fmt.Sprintf("%s_FILE: %v", envName, err))
+				return
+			}
+			sources[key] = ValueSource{Path: key, Source: SourceFile, Ref: filePath}
+		case envSet:
+			if err := setFieldValue(field, value); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", envName, err))
+				return
+			}
+			sources[key] = ValueSource{Path: key, Source: SourceEnv, Ref: envName}
+		case present[key]:
+			sources[key] = ValueSource{Path: key, Source: SourceYAML}
+		}
+	})
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config overlay: %s", strings.Join(errs, ", "))
+	}
+	return sources, nil
+}
+
+// collectSources 在默认值填充后补全来源：未被文件与环境变量覆盖但非零值的配置项视为默认值
+func collectSources(cfg *Config, sources map[string]ValueSource) []ValueSource {
+	walkLeaves(reflect.ValueOf(cfg).Elem(), nil, false, func(path []string, field reflect.Value) {
+		key := strings.Join(path, ".")
+		if _, ok := sources[key]; !ok && !field.IsZero() {
+			sources[key] = ValueSource{Path: key, Source: SourceDefault}
+		}
+	})
+
+	list := make([]ValueSource, 0, len(sources))
+	for _, s := range sources {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
+
+// walkLeaves 按 yaml 标签遍历配置结构体的叶子字段
+// grow 为 true 时，结构体切片会按环境变量中出现的最大下标扩容，以支持仅通过环境变量新增备库
+func walkLeaves(v reflect.Value, path []string, grow bool, fn func(path []string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, inline := yamlName(sf)
+		if name == "-" {
+			continue
+		}
+		fieldPath := path
+		if !inline {
+			fieldPath = append(append([]string{}, path...), name)
+		}
+
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			walkLeaves(field, fieldPath, grow, fn)
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct:
+			if grow {
+				if n := maxEnvIndex(envNameOf(fieldPath)) + 1; n > field.Len() {
+					grown := reflect.MakeSlice(field.Type(), n, n)
+					reflect.Copy(grown, field)
+					field.Set(grown)
+				}
+			}
+			for j := 0; j < field.Len(); j++ {
+				walkLeaves(field.Index(j), append(append([]string{}, fieldPath...), strconv.Itoa(j)), grow, fn)
+			}
+		default:
+			fn(fieldPath, field)
+		}
+	}
+}
+
+// yamlName 解析字段的 yaml 名称，返回是否为 inline 嵌入
+func yamlName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("yaml")
+	name, opts, _ := strings.Cut(tag, ",")
+	if strings.Contains(opts, "inline") {
+		return "", true
+	}
+	if name == "" {
+		name = strings.ToLower(sf.Name)
+	}
+	return name, false
+}
+
+// envNameOf 将配置路径转换为环境变量名
+func envNameOf(path []string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.Join(path, "_"))
+}
+
+// maxEnvIndex 查找以 prefix_<n>_ 开头的环境变量中的最大下标，不存在时返回 -1
+func maxEnvIndex(prefix string) int {
+	maxIndex := -1
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(name, prefix+"_")
+		if !ok {
+			continue
+		}
+		if m := envIndexRegex.FindStringSubmatch(rest); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > maxIndex {
+				maxIndex = n
+			}
+		}
+	}
+	return maxIndex
+}
+
+// setFieldValue 将字符串形式的值写入字段，切片以逗号分隔
+func setFieldValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", value)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", field.Type())
+		}
+		items := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// flattenPaths 将 YAML 原始结构展开为点分路径集合
+func flattenPaths(node any, prefix string, out map[string]bool) {
+	switch n := node.(type) {
+	case map[string]any:
+		for k, v := range n {
+			flattenPaths(v, joinPath(prefix, k), out)
+		}
+	case []any:
+		out[prefix] = true
+		for i, v := range n {
+			flattenPaths(v, joinPath(prefix, strconv.Itoa(i)), out)
+		}
+	default:
+		if prefix != "" {
+			out[prefix] = true
+		}
+	}
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot 配置快照，热更新时整体原子替换，已取得的快照不会再被修改
+type Snapshot struct {
+	Version  uint64        // 版本号，首次加载为 1，每次热更新成功加 1
+	Config   *Config       // 配置内容
+	Sources  []ValueSource // 各配置项的生效来源
+	LoadedAt time.Time     // 加载时间
+}
+
+// Subscriber 配置变更订阅者，在新快照生效后按注册顺序同步回调
+type Subscriber func(old, new *Config)
+
+var (
+	current  atomic.Pointer[Snapshot]
+	reloadMu sync.Mutex // 串行化热更新，避免并发加载导致版本号错乱
+
+	subMu       sync.RWMutex
+	subscribers []namedSubscriber
+	subSeq      uint64
+)
+
+type namedSubscriber struct {
+	id   uint64
+	name string
+	fn   Subscriber
+}
+
+// Get 获取当前生效的配置，未加载时返回 nil
+// 读方应在每次使用时调用 Get，不要长期持有返回值，否则无法感知热更新
+func Get() *Config {
+	if snap := current.Load(); snap != nil {
+		return snap.Config
+	}
+	return nil
+}
+
+// Current 获取当前生效的配置快照，未加载时返回 nil
+func Current() *Snapshot {
+	return current.Load()
+}
+
+// SetCurrent 直接替换当前配置快照并返回原快照，不做校验；新旧快照都不为空时与热更新一样通知订阅者
+// 用于测试中临时替换配置，测试结束时以原快照再次调用即可恢复配置及订阅者应用的状态（如日志级别）
+func SetCurrent(snap *Snapshot) *Snapshot {
+	old := current.Swap(snap)
+	if old != nil && snap != nil {
+		notify(old.Config, snap.Config)
+	}
+	return old
+}
+
+// Subscribe 订阅配置热更新，name 仅用于日志与排查；返回的函数用于取消订阅
+func Subscribe(name string, fn Subscriber) (unsubscribe func()) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	subSeq++
+	id := subSeq
+	subscribers = append(subscribers, namedSubscriber{id: id, name: name, fn: fn})
+	return func() {
+		subMu.Lock()
+		defer subMu.Unlock()
+		subscribers = slices.DeleteFunc(subscribers, func(sub namedSubscriber) bool { return sub.id == id })
+	}
+}
+
+// notify 按注册顺序同步通知订阅者
+func notify(old, new *Config) {
+	subMu.RLock()
+	subs := append([]namedSubscriber(nil), subscribers...)
+	subMu.RUnlock()
+	for _, sub := range subs {
+		sub.fn(old, new)
+	}
+}
+
+// Reload 重新加载配置文件并原子替换当前快照
+// 新配置需通过校验，且不能修改标记为 reload:"restart" 的字段（如端口、数据库连接），否则拒绝并保留旧配置
+func Reload(configPath string) (*Snapshot, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	old := current.Load()
+	if old == nil {
+		return nil, fmt.Errorf("config not loaded")
+	}
+
+	cfg, sources, err := load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if changed := restartRequiredChanges(old.Config, cfg); len(changed) > 0 {
+		return nil, fmt.Errorf("config change requires restart: %s", strings.Join(changed, ", "))
+	}
+
+	// release 模式下热更新同样不允许引入不安全配置
+	if findings := Audit(cfg); cfg.Server.Mode == "release" && HasCritical(findings) {
+		return nil, errors.New(FormatFindings(findings))
+	}
+
+	snap := &Snapshot{Version: old.Version + 1, Config: cfg, Sources: sources, LoadedAt: time.Now()}
+	current.Store(snap)
+	notify(old.Config, cfg)
+	return snap, nil
+}
+
+// restartRequiredChanges 对比新旧配置中标记为 reload:"restart" 的字段，返回发生变化的配置路径
+func restartRequiredChanges(old, new *Config) []string {
+	changed := make([]string, 0)
+	compareRestartFields(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), "", &changed)
+	return changed
+}
+
+func compareRestartFields(old, new reflect.Value, prefix string, changed *[]string) {
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, inline := yamlName(sf)
+		path := prefix
+		if !inline {
+			path = joinPath(prefix, name)
+		}
+
+		if sf.Tag.Get("reload") == "restart" {
+			if !reflect.DeepEqual(old.Field(i).Interface(), new.Field(i).Interface()) {
+				*changed = append(*changed, path)
+			}
+			continue
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			compareRestartFields(old.Field(i), new.Field(i), path, changed)
+		}
+	}
+}
@@ -4,3 +4,87 @@ type Login struct {
 	Username string `json:"username" form:"username" validate:"required,min=2,max=20" label:"用户名"` // 用户名
 	Password string `json:"password" form:"password" validate:"required,min=6" label:"密码"`         // 密码
 }
+
+type RefreshToken struct {
+	RefreshToken string `json:"refreshToken" form:"refreshToken" validate:"required" label:"刷新令牌"` // 刷新令牌
+}
+
+type MFAVerify struct {
+	ChallengeToken string `json:"challengeToken" form:"challengeToken" validate:"required" label:"挑战令牌"` // 登录返回的挑战令牌
+	Code           string `json:"code" form:"code" validate:"required,max=32" label:"验证码"`               // 6 位验证码或恢复码
+}
+
+type MFAChallenge struct {
+	ChallengeToken string `json:"challengeToken" form:"challengeToken" validate:"required" label:"挑战令牌"` // 登录返回的挑战令牌
+}
+
+type ForgotPassword struct {
+	Account string `json:"account" form:"account" validate:"required,max=100" label:"用户名或邮箱"` // 用户名或邮箱
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" form:"token" validate:"required" label:"重置令牌"`               // 邮件中的重置令牌
+	Password string `json:"password" form:"password" validate:"required,password" label:"新密码"` // 新密码
+}
+
+type ChangeExpiredPassword struct {
+	ChangeToken string `json:"changeToken" form:"changeToken" validate:"required" label:"修改密码令牌"` // 登录返回的修改密码令牌
+	Password    string `json:"password" form:"password" validate:"required,password" label:"新密码"` // 新密码
+}
+
+type Logout struct {
+	RefreshToken string `json:"refreshToken" form:"refreshToken" label:"刷新令牌"` // 刷新令牌（可选）
+}
+
+// RevokeAllSessionsDTO 注销全部会话DTO
+type RevokeAllSessionsDTO struct {
+	KeepCurrent bool `json:"keepCurrent" label:"保留当前会话"` // 为 true 时保留当前会话，仅注销其他设备
+}
+
+// UserSessionsDTO 用户会话DTO
+type UserSessionsDTO struct {
+	UserID int `uri:"id" validate:"required" label:"用户ID"`
+}
+
+// ForceLogoutDTO 强制下线DTO
+type ForceLogoutDTO struct {
+	UserID int `json:"userId" validate:"required" label:"用户ID"`
+}
+
+// LockoutDTO 账号锁定查询与解锁DTO
+type LockoutDTO struct {
+	Username string `json:"username" validate:"required,max=20" label:"用户名"`
+}
+
+// MFACodeDTO 两步验证码DTO
+type MFACodeDTO struct {
+	Code string `json:"code" validate:"required,max=32" label:"验证码"` // 6 位验证码，关闭与重新生成恢复码时也可使用恢复码
+}
+
+// ResetMFADTO 重置用户两步验证DTO
+type ResetMFADTO struct {
+	UserID int `json:"userId" validate:"required" label:"用户ID"`
+}
+
+// CreateAPIKeyDTO 创建API密钥DTO
+type CreateAPIKeyDTO struct {
+	Name      string   `json:"name" validate:"required,max=100" label:"名称"`                                 // 用途说明，如“仓库扫码枪”
+	Scopes    []string `json:"scopes" validate:"required,min=1,max=100,dive,required,max=100" label:"权限范围"` // 权限点名称，如 /api/products#GET
+	ExpiresIn int      `json:"expiresIn" validate:"omitempty,gte=0" label:"有效天数"`                           // 0 表示不过期（配置了 api_key.max_expiry 时使用该值）
+}
+
+// CreateUserAPIKeyDTO 为指定用户（服务账号）创建API密钥DTO
+type CreateUserAPIKeyDTO struct {
+	UserID int `json:"userId" validate:"required" label:"用户ID"`
+	CreateAPIKeyDTO
+}
+
+// APIKeyIDDTO API密钥ID DTO
+type APIKeyIDDTO struct {
+	ID int `uri:"id" validate:"required" label:"密钥ID"`
+}
+
+// UserAPIKeysDTO 用户API密钥DTO
+type UserAPIKeysDTO struct {
+	UserID int `uri:"id" validate:"required" label:"用户ID"`
+}
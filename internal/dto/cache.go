@@ -0,0 +1,11 @@
+package dto
+
+// CacheKeyDTO 查看缓存键DTO
+type CacheKeyDTO struct {
+	Key string `json:"key" validate:"required,max=1024" label:"缓存键"`
+}
+
+// CachePrefixDTO 按前缀删除缓存DTO
+type CachePrefixDTO struct {
+	Prefix string `json:"prefix" validate:"required,max=256" label:"缓存键前缀"`
+}
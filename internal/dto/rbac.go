@@ -5,6 +5,7 @@ type AddRoleDTO struct {
 	Name   string `json:"name" validate:"required,min=1,max=50" label:"角色名称"`
 	Remark string `json:"remark" validate:"omitempty,max=200" label:"角色备注"`
 	Status int    `json:"status" validate:"oneof=0 1" label:"状态"` // 0-禁用 1-启用
+	RequireMFA bool `json:"require_mfa" label:"强制两步验证"` // 拥有该角色的用户登录必须通过两步验证
 	MenuIDs []int  `json:"menu_ids" validate:"omitempty" label:"菜单ID列表"`
 }
 
@@ -13,6 +14,7 @@ type EditRoleDTO struct {
 	Name   *string `json:"name" validate:"omitempty,min=1,max=50" label:"角色名称"`
 	Remark *string `json:"remark" validate:"omitempty,max=200" label:"角色备注"`
 	Status *int    `json:"status" validate:"omitempty,oneof=0 1" label:"状态"` // 0-禁用 1-启用
+	RequireMFA *bool `json:"require_mfa" label:"强制两步验证"` // 拥有该角色的用户登录必须通过两步验证
 	MenuIDs []int  `json:"menu_ids" validate:"omitempty" label:"菜单ID列表"`
 }
 
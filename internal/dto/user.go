@@ -8,13 +8,13 @@ type UserRegister struct {
 	ID       int    `json:"id" validate:"omitempty,gte=0" label:"id"`                              // id
 	Username string `json:"username" form:"username" validate:"required,min=2,max=20" label:"用户名"` // 用户名
 	Phone    string `json:"phone" form:"phone" validate:"omitempty,phone" label:"手机号码"`            // 手机号码
-	Password string `json:"password" validate:"omitempty,min=6" label:"密码"`                        // 明文，仅用于验证
+	Password string `json:"password" validate:"omitempty,password" label:"密码"`                     // 明文，仅用于验证
 	Nickname string `json:"nickname" validate:"omitempty,min=2,max=20" label:"昵称"`                 // 昵称
 	Email    string `json:"email" validate:"omitempty,email" label:"邮箱"`                           //	邮箱
 	Age      int    `json:"age" validate:"omitempty,gte=0,lte=150" label:"年龄"`                     // 年龄
 	Gender   string `json:"gender" validate:"omitempty,oneof=male female" label:"性别"`              // 性别
-	Status   int    `json:"status" validate:"oneof=0 1" label:"状态"`
-	RoleIds  []int  `json:"roleIds" validate:"omitempty,dive,gt=0" label:"角色ID列表"` // 角色ID列表
+	Status   *int   `json:"status" validate:"omitempty,oneof=0 1" label:"状态"`                      // 状态，不传时新建用户为启用、编辑时保持不变
+	RoleIds  []int  `json:"roleIds" validate:"omitempty,dive,gt=0" label:"角色ID列表"`                 // 角色ID列表
 }
 
 func (dto *UserRegister) ToModel() models.User {
@@ -26,7 +26,9 @@ func (dto *UserRegister) ToModel() models.User {
 		Age:      dto.Age,
 		Nickname: dto.Nickname,
 		Gender:   dto.Gender,
-		Status:   dto.Status,
+	}
+	if dto.Status != nil {
+		userModel.Status = *dto.Status
 	}
 	return userModel
 }
@@ -36,3 +38,9 @@ type UserQuery struct {
 	PageSize int    `form:"pageSize" validate:"omitempty,min=1,max=100" label:"每页数量"`
 	Username string `form:"username" validate:"omitempty,min=2,max=20" label:"用户名"`
 }
+
+// ChangePasswordDTO 修改密码DTO
+type ChangePasswordDTO struct {
+	OldPassword string `json:"oldPassword" validate:"required" label:"原密码"`          // 原密码
+	NewPassword string `json:"newPassword" validate:"required,password" label:"新密码"` // 新密码
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"webgos/internal/dto"
+	"webgos/internal/services"
+	"webgos/internal/utils/param"
+	"webgos/internal/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAPIKeys 我的API密钥
+// @Summary 我的API密钥
+// @Description 当前用户的 API 密钥，只返回密钥开头用于辨认，不包含完整密钥
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.APIKey}
+// @Failure 400 {object} response.Response
+// @Router /api/api_key [get]
+// @Security BearerAuth
+func ListAPIKeys(c *gin.Context) {
+	apiKeyService := services.NewAPIKeyService()
+	keys, err := apiKeyService.List(c, c.GetInt("user_id"))
+	if err != nil {
+		response.Error(c, "获取API密钥失败: "+err.Error())
+		return
+	}
+	response.Success(c, "获取API密钥成功", keys)
+}
+
+// CreateAPIKey 创建API密钥
+// @Summary 创建API密钥
+// @Description 为当前用户创建 API 密钥，权限范围只能是当前用户拥有的权限点；完整密钥仅此一次返回，请妥善保存
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateAPIKeyDTO true "名称、权限范围与有效期"
+// @Success 200 {object} response.Response{data=services.APIKeyCreated}
+// @Failure 400 {object} response.Response
+// @Router /api/api_key [post]
+// @Security BearerAuth
+func CreateAPIKey(c *gin.Context) {
+	var dtoModel dto.CreateAPIKeyDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	apiKeyService := services.NewAPIKeyService()
+	created, err := apiKeyService.Create(c, userID, userID, dtoModel)
+	if err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	response.Success(c, "创建API密钥成功", created)
+}
+
+// RevokeAPIKey 删除API密钥
+// @Summary 删除API密钥
+// @Description 删除当前用户的 API 密钥，使用该密钥的请求立即失败
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Param id path int true "密钥ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/api_key/{id} [delete]
+// @Security BearerAuth
+func RevokeAPIKey(c *gin.Context) {
+	var dtoModel dto.APIKeyIDDTO
+	if err := param.ValidateUri(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	apiKeyService := services.NewAPIKeyService()
+	if err := apiKeyService.Revoke(c, c.GetInt("user_id"), dtoModel.ID); err != nil {
+		response.Error(c, "删除API密钥失败: "+err.Error())
+		return
+	}
+	response.Success(c, "删除API密钥成功", nil)
+}
+
+// GetUserAPIKeys 用户API密钥
+// @Summary 用户API密钥
+// @Description 管理员查看指定用户（如服务账号）的 API 密钥
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} response.Response{data=[]models.APIKey}
+// @Failure 400 {object} response.Response
+// @Router /api/system/api_key/user/{id} [get]
+// @Security BearerAuth
+func GetUserAPIKeys(c *gin.Context) {
+	var dtoModel dto.UserAPIKeysDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	apiKeyService := services.NewAPIKeyService()
+	keys, err := apiKeyService.List(c, dtoModel.UserID)
+	if err != nil {
+		response.Error(c, "获取API密钥失败: "+err.Error())
+		return
+	}
+	response.Success(c, "获取API密钥成功", keys)
+}
+
+// CreateUserAPIKey 为用户创建API密钥
+// @Summary 为用户创建API密钥
+// @Description 管理员为指定用户（通常是专供集成使用的服务账号）创建 API 密钥，权限范围只能是该用户拥有的权限点；完整密钥仅此一次返回
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateUserAPIKeyDTO true "用户、名称、权限范围与有效期"
+// @Success 200 {object} response.Response{data=services.APIKeyCreated}
+// @Failure 400 {object} response.Response
+// @Router /api/system/api_key [post]
+// @Security BearerAuth
+func CreateUserAPIKey(c *gin.Context) {
+	var dtoModel dto.CreateUserAPIKeyDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	apiKeyService := services.NewAPIKeyService()
+	created, err := apiKeyService.Create(c, dtoModel.UserID, c.GetInt("user_id"), dtoModel.CreateAPIKeyDTO)
+	if err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	response.Success(c, "创建API密钥成功", created)
+}
+
+// DeleteAPIKey 删除任意API密钥
+// @Summary 删除任意API密钥
+// @Description 管理员删除任意用户的 API 密钥，如密钥泄露或集成下线
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param id path int true "密钥ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/system/api_key/{id} [delete]
+// @Security BearerAuth
+func DeleteAPIKey(c *gin.Context) {
+	var dtoModel dto.APIKeyIDDTO
+	if err := param.ValidateUri(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	apiKeyService := services.NewAPIKeyService()
+	if err := apiKeyService.Revoke(c, 0, dtoModel.ID); err != nil {
+		response.Error(c, "删除API密钥失败: "+err.Error())
+		return
+	}
+	response.Success(c, "删除API密钥成功", nil)
+}
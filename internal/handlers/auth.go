@@ -1,9 +1,16 @@
 package handlers
 
 import (
-	"webgos/internal/config"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
 	"webgos/internal/dto"
+	"webgos/internal/jwtkey"
+	"webgos/internal/middleware"
 	"webgos/internal/services"
+	"webgos/internal/utils/code"
 	"webgos/internal/utils/param"
 	"webgos/internal/utils/response"
 
@@ -16,7 +23,7 @@ import (
 // @Accept json
 // @Produce json
 // @Param data body dto.Login true "登录参数"
-// @Success 200 {object} response.Response "data={accessToken: string}"
+// @Success 200 {object} response.Response{data=services.TokenPair} "需要两步验证时业务码为 1002，data 为 services.MFAChallengeError；密码已过期时业务码为 1003，data 为 services.PasswordExpiredError"
 // @Failure 400 {object} response.Response
 // @Router /auth/login [post]
 // Login 用户登录
@@ -29,57 +36,252 @@ func Login(c *gin.Context) {
 	}
 
 	service := services.NewAuthService()
-	token, err := service.Login(c, userLoginDTO.Username, userLoginDTO.Password)
+	pair, err := service.Login(c, userLoginDTO.Username, userLoginDTO.Password, clientInfo(c))
+	var challenge *services.MFAChallengeError
+	if errors.As(err, &challenge) {
+		response.Resp(c, code.MFARequired, challenge.Error(), challenge)
+		return
+	}
+	if loginError(c, err) {
+		return
+	}
+
+	response.Success(c, "登录成功", pair)
+}
+
+// loginError 响应登录与两步验证的错误，返回是否已响应
+func loginError(c *gin.Context, err error) bool {
+	var blocked *services.LoginBlockedError
+	var expired *services.PasswordExpiredError
+	switch {
+	case errors.As(err, &expired):
+		response.Resp(c, code.PasswordExpired, expired.Error(), expired)
+	case errors.As(err, &blocked):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+		response.ErrorWithCode(c, err.Error(), code.LoginBlocked)
+	case errors.Is(err, services.ErrIPBanned):
+		// 与敏感路径检测共用 IP 黑名单
+		middleware.BanIP(c.ClientIP(), "login-failures")
+		response.Forbidden(c, err.Error())
+	case err != nil:
+		response.Error(c, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// @Summary 两步验证登录
+// @Description 登录返回业务码 1002 时，使用挑战令牌提交验证器中的 6 位验证码或恢复码完成登录；挑战要求首次绑定（setupRequired）时需先调用 /auth/mfa/setup，验证通过后启用两步验证并返回恢复码
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param data body dto.MFAVerify true "挑战令牌与验证码"
+// @Success 200 {object} response.Response{data=services.TokenPair}
+// @Failure 400 {object} response.Response
+// @Router /auth/mfa/verify [post]
+// VerifyMFA 两步验证登录
+func VerifyMFA(c *gin.Context) {
+	var verifyDTO dto.MFAVerify
+
+	if err := param.Validate(c, &verifyDTO); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	service := services.NewAuthService()
+	pair, err := service.VerifyMFA(c, verifyDTO.ChallengeToken, verifyDTO.Code, clientInfo(c))
+	if loginError(c, err) {
+		return
+	}
+
+	response.Success(c, "登录成功", pair)
+}
+
+// @Summary 登录时绑定验证器
+// @Description 角色要求两步验证而用户尚未启用时，使用挑战令牌生成 TOTP 密钥与 otpauth 地址（供生成二维码），绑定后调用 /auth/mfa/verify 确认
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param data body dto.MFAChallenge true "挑战令牌"
+// @Success 200 {object} response.Response{data=services.MFASetup}
+// @Failure 400 {object} response.Response
+// @Router /auth/mfa/setup [post]
+// SetupMFAChallenge 登录时绑定验证器
+func SetupMFAChallenge(c *gin.Context) {
+	var challengeDTO dto.MFAChallenge
+
+	if err := param.Validate(c, &challengeDTO); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	service := services.NewAuthService()
+	setup, err := service.SetupMFA(c, challengeDTO.ChallengeToken)
 	if err != nil {
 		response.Error(c, err.Error())
 		return
 	}
 
-	response.Success(c, "登录成功", gin.H{"accessToken": token})
+	response.Success(c, "生成密钥成功", setup)
+}
+
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的访问令牌与刷新令牌，旧刷新令牌随即失效；已使用过的刷新令牌再次提交时注销该登录的所有令牌
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param data body dto.RefreshToken true "刷新令牌"
+// @Success 200 {object} response.Response{data=services.TokenPair}
+// @Failure 400 {object} response.Response
+// @Router /auth/refresh [post]
+// RefreshToken 刷新令牌
+func RefreshToken(c *gin.Context) {
+	var refreshDTO dto.RefreshToken
+
+	if err := param.Validate(c, &refreshDTO); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	service := services.NewAuthService()
+	pair, err := service.Refresh(c, refreshDTO.RefreshToken, clientInfo(c))
+	if err != nil {
+		response.Unauthorized(c, err.Error())
+		return
+	}
+
+	response.Success(c, "刷新成功", pair)
 }
 
 // @Summary 用户登出
-// @Description 用户登出接口
+// @Description 用户登出接口，注销当前登录的访问令牌与刷新令牌
 // @Tags 登录
+// @Accept json
 // @Produce json
+// @Param data body dto.Logout false "刷新令牌"
 // @Success 200 {object} response.Response
 // @Router /auth/logout [post]
 // Logout 用户登出
 func Logout(c *gin.Context) {
+	var logoutDTO dto.Logout
+	// 请求体可选，仅携带访问令牌时也允许登出
+	_ = c.ShouldBindJSON(&logoutDTO)
+	accessToken, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
 	service := services.NewAuthService()
-	service.Logout(c.GetString("tokenString"))
+	if err := service.Logout(c, accessToken, logoutDTO.RefreshToken); err != nil {
+		response.Error(c, "登出失败: "+err.Error())
+		return
+	}
 	response.Success(c, "登出成功", nil)
 }
 
-// @Summary 重置密码 测试专用
-// @Description 重置密码接口
+// clientInfo 请求的客户端信息，记录在会话中
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// @Summary JWT 公钥集
+// @Description 公开访问令牌的校验公钥（JWKS，RFC 7517），供其他服务按令牌头部的 kid 校验 webgos 签发的令牌；HS256 模式下为空集合
+// @Tags 登录
+// @Produce json
+// @Success 200 {object} jwtkey.JWKS
+// @Router /.well-known/jwks.json [get]
+// JWKS JWT 公钥集
+func JWKS(c *gin.Context) {
+	keys, err := jwtkey.Get()
+	if err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	// 按标准格式直接输出，不使用统一响应包装；允许短时缓存，轮换时需保证新密钥提前发布
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keys.JWKS())
+}
+
+// @Summary 申请重置密码
+// @Description 按用户名或邮箱申请重置密码，向账号邮箱发送一次性重置链接；为避免泄露账号是否存在，账号不存在时同样返回成功
 // @Tags 登录
 // @Accept json
 // @Produce json
-// @Param data body dto.Login true "登录参数"
+// @Param data body dto.ForgotPassword true "用户名或邮箱"
 // @Success 200 {object} response.Response
 // @Failure 400 {object} response.Response
-// @Router /auth/reset-password [post]
+// @Router /auth/password/forgot [post]
+// ForgotPassword 申请重置密码
+func ForgotPassword(c *gin.Context) {
+	var forgotDTO dto.ForgotPassword
+
+	if err := param.Validate(c, &forgotDTO); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	service := services.NewPasswordResetService()
+	if err := service.Request(c, forgotDTO.Account, clientInfo(c)); err != nil {
+		if errors.Is(err, services.ErrPasswordResetUnavailable) {
+			response.Error(c, err.Error())
+			return
+		}
+		response.Error(c, "申请重置密码失败")
+		return
+	}
+	response.Success(c, "如果账号存在且已设置邮箱，重置链接已发送", nil)
+}
+
+// @Summary 重置密码
+// @Description 使用邮件中的重置令牌设置新密码，令牌只能使用一次；重置后该用户的所有会话被注销
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param data body dto.ResetPassword true "重置令牌与新密码"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /auth/password/reset [post]
 // ResetPassword 重置密码
 func ResetPassword(c *gin.Context) {
+	var resetDTO dto.ResetPassword
 
-	if config.GlobalConfig.Server.Mode != "debug" {
-		response.Error(c, "生产模式不允许重置密码")
+	if err := param.Validate(c, &resetDTO); err != nil {
+		response.Error(c, err.Error())
 		return
 	}
-	var userLoginDTO dto.Login
 
-	if err := param.Validate(c, &userLoginDTO); err != nil {
+	service := services.NewPasswordResetService()
+	if err := service.Reset(c, resetDTO.Token, resetDTO.Password); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	response.Success(c, "重置密码成功，请重新登录", nil)
+}
+
+// @Summary 修改已过期的密码
+// @Description 登录返回业务码 1003 时，使用修改密码令牌设置新密码并完成登录；新密码需满足密码策略，且不能与当前密码相同
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param data body dto.ChangeExpiredPassword true "修改密码令牌与新密码"
+// @Success 200 {object} response.Response{data=services.TokenPair}
+// @Failure 400 {object} response.Response
+// @Router /auth/password/change [post]
+// ChangeExpiredPassword 修改已过期的密码
+func ChangeExpiredPassword(c *gin.Context) {
+	var changeDTO dto.ChangeExpiredPassword
+
+	if err := param.Validate(c, &changeDTO); err != nil {
 		response.Error(c, err.Error())
+		return
 	}
 
-	userService := services.NewUserService()
-	err := userService.ResetPassword(c, userLoginDTO.Username, userLoginDTO.Password)
+	service := services.NewAuthService()
+	pair, err := service.ChangeExpiredPassword(c, changeDTO.ChangeToken, changeDTO.Password, clientInfo(c))
 	if err != nil {
 		response.Error(c, err.Error())
 		return
 	}
-	response.Success(c, "重置密码成功", nil)
+
+	response.Success(c, "修改密码成功", pair)
 }
 
 // @Summary 用户注册
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"webgos/internal/dto"
+	"webgos/internal/services"
+	"webgos/internal/utils/param"
+	"webgos/internal/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCacheStats 缓存统计
+// @Summary 缓存统计
+// @Description 按前缀统计缓存的条数、命中、未命中、写入、删除与淘汰次数
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/system/cache [get]
+// @Security BearerAuth
+func GetCacheStats(c *gin.Context) {
+	cacheService := services.NewCacheService()
+	stats, err := cacheService.Stats(c)
+	if err != nil {
+		response.Error(c, "获取缓存统计失败: "+err.Error())
+		return
+	}
+	response.Success(c, "获取缓存统计成功", stats)
+}
+
+// InspectCacheKey 查看缓存键
+// @Summary 查看缓存键
+// @Description 查看缓存键是否存在及其值
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param body body dto.CacheKeyDTO true "缓存键"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/system/cache/inspect [post]
+// @Security BearerAuth
+func InspectCacheKey(c *gin.Context) {
+	var dtoModel dto.CacheKeyDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	cacheService := services.NewCacheService()
+	response.Success(c, "查看缓存键成功", cacheService.Inspect(c, dtoModel.Key))
+}
+
+// DeleteCachePrefix 按前缀删除缓存
+// @Summary 按前缀删除缓存
+// @Description 删除指定前缀的缓存，配置了缓存失效广播时同步到其他实例
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param body body dto.CachePrefixDTO true "缓存键前缀"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/system/cache/delete_prefix [post]
+// @Security BearerAuth
+func DeleteCachePrefix(c *gin.Context) {
+	var dtoModel dto.CachePrefixDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	cacheService := services.NewCacheService()
+	cacheService.DeletePrefix(c, dtoModel.Prefix)
+	response.Success(c, "删除缓存成功", nil)
+}
+
+// FlushCache 清空缓存
+// @Summary 清空缓存
+// @Description 清空全部缓存（含登录令牌，所有用户需重新登录），配置了缓存失效广播时同步到其他实例
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/system/cache/flush [post]
+// @Security BearerAuth
+func FlushCache(c *gin.Context) {
+	cacheService := services.NewCacheService()
+	cacheService.Flush(c)
+	response.Success(c, "清空缓存成功", nil)
+}
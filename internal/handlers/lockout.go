@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"webgos/internal/dto"
+	"webgos/internal/services"
+	"webgos/internal/utils/param"
+	"webgos/internal/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLockoutStatus 账号锁定状态
+// @Summary 账号锁定状态
+// @Description 查看账号的登录失败次数、最近失败时间与锁定截止时间
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param body body dto.LockoutDTO true "用户名"
+// @Success 200 {object} response.Response{data=services.LoginFailures}
+// @Failure 400 {object} response.Response
+// @Router /api/system/lockout/status [post]
+// @Security BearerAuth
+func GetLockoutStatus(c *gin.Context) {
+	var dtoModel dto.LockoutDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	guard := services.NewLoginGuard()
+	response.Success(c, "获取锁定状态成功", guard.Status(c, dtoModel.Username))
+}
+
+// UnlockAccount 解除账号锁定
+// @Summary 解除账号锁定
+// @Description 解除账号的登录锁定并清除失败计数
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param body body dto.LockoutDTO true "用户名"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/system/lockout/unlock [post]
+// @Security BearerAuth
+func UnlockAccount(c *gin.Context) {
+	var dtoModel dto.LockoutDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	guard := services.NewLoginGuard()
+	guard.Unlock(c, dtoModel.Username)
+	response.Success(c, "解除锁定成功", nil)
+}
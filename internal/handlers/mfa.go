@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"webgos/internal/dto"
+	"webgos/internal/services"
+	"webgos/internal/utils/param"
+	"webgos/internal/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMFAStatus 两步验证状态
+// @Summary 两步验证状态
+// @Description 当前用户是否已启用两步验证、角色是否要求两步验证以及剩余恢复码数量
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response{data=services.MFAStatus}
+// @Failure 400 {object} response.Response
+// @Router /api/mfa [get]
+// @Security BearerAuth
+func GetMFAStatus(c *gin.Context) {
+	mfaService := services.NewMFAService()
+	status, err := mfaService.Status(c, c.GetInt("user_id"))
+	if err != nil {
+		response.Error(c, "获取两步验证状态失败: "+err.Error())
+		return
+	}
+	response.Success(c, "获取两步验证状态成功", status)
+}
+
+// SetupMFA 生成两步验证密钥
+// @Summary 生成两步验证密钥
+// @Description 生成待确认的 TOTP 密钥与 otpauth 地址（供生成二维码），使用验证器扫码后调用 /api/mfa/confirm 启用；已启用时需先关闭
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response{data=services.MFASetup}
+// @Failure 400 {object} response.Response
+// @Router /api/mfa/setup [post]
+// @Security BearerAuth
+func SetupMFA(c *gin.Context) {
+	mfaService := services.NewMFAService()
+	setup, err := mfaService.Setup(c, c.GetInt("user_id"))
+	if err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	response.Success(c, "生成密钥成功", setup)
+}
+
+// ConfirmMFA 启用两步验证
+// @Summary 启用两步验证
+// @Description 提交验证器生成的验证码确认密钥并启用两步验证，返回恢复码（仅此一次返回，请妥善保存）
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param body body dto.MFACodeDTO true "验证码"
+// @Success 200 {object} response.Response{data=[]string}
+// @Failure 400 {object} response.Response
+// @Router /api/mfa/confirm [post]
+// @Security BearerAuth
+func ConfirmMFA(c *gin.Context) {
+	var dtoModel dto.MFACodeDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	mfaService := services.NewMFAService()
+	codes, err := mfaService.Confirm(c, c.GetInt("user_id"), dtoModel.Code)
+	if err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	response.Success(c, "启用两步验证成功", codes)
+}
+
+// DisableMFA 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 提交验证码或恢复码关闭两步验证，删除密钥与恢复码；角色要求两步验证时不能关闭
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param body body dto.MFACodeDTO true "验证码"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/mfa/disable [post]
+// @Security BearerAuth
+func DisableMFA(c *gin.Context) {
+	var dtoModel dto.MFACodeDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	mfaService := services.NewMFAService()
+	if err := mfaService.Disable(c, c.GetInt("user_id"), dtoModel.Code); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	response.Success(c, "关闭两步验证成功", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 提交验证码或恢复码后重新生成一组恢复码，原有恢复码全部作废
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param body body dto.MFACodeDTO true "验证码"
+// @Success 200 {object} response.Response{data=[]string}
+// @Failure 400 {object} response.Response
+// @Router /api/mfa/recovery_codes [post]
+// @Security BearerAuth
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var dtoModel dto.MFACodeDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	mfaService := services.NewMFAService()
+	codes, err := mfaService.RegenerateRecoveryCodes(c, c.GetInt("user_id"), dtoModel.Code)
+	if err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	response.Success(c, "重新生成恢复码成功", codes)
+}
+
+// ResetUserMFA 重置用户两步验证
+// @Summary 重置用户两步验证
+// @Description 用户丢失验证器与恢复码时由管理员重置，删除其密钥与恢复码；角色要求两步验证时用户下次登录需重新绑定
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param body body dto.ResetMFADTO true "用户ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/system/mfa/reset [post]
+// @Security BearerAuth
+func ResetUserMFA(c *gin.Context) {
+	var dtoModel dto.ResetMFADTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	mfaService := services.NewMFAService()
+	if err := mfaService.Reset(c, dtoModel.UserID); err != nil {
+		response.Error(c, "重置两步验证失败: "+err.Error())
+		return
+	}
+	response.Success(c, "重置两步验证成功", nil)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"webgos/internal/dto"
+	"webgos/internal/services"
+	"webgos/internal/utils/param"
+	"webgos/internal/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions 我的会话
+// @Summary 我的会话
+// @Description 当前用户的有效会话（登录设备），按最近活跃时间倒序，current 标记发起请求的会话
+// @Tags 会话管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.Session}
+// @Failure 400 {object} response.Response
+// @Router /api/session [get]
+// @Security BearerAuth
+func ListSessions(c *gin.Context) {
+	sessionService := services.NewSessionService()
+	sessions, err := sessionService.List(c, c.GetInt("user_id"), c.GetString("session_id"))
+	if err != nil {
+		response.Error(c, "获取会话列表失败: "+err.Error())
+		return
+	}
+	response.Success(c, "获取会话列表成功", sessions)
+}
+
+// RevokeSession 注销会话
+// @Summary 注销会话
+// @Description 注销当前用户的指定会话，该会话的访问令牌与刷新令牌立即失效
+// @Tags 会话管理
+// @Accept json
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/session/{id} [delete]
+// @Security BearerAuth
+func RevokeSession(c *gin.Context) {
+	sessionService := services.NewSessionService()
+	if err := sessionService.Revoke(c, c.GetInt("user_id"), c.Param("id")); err != nil {
+		response.Error(c, "注销会话失败: "+err.Error())
+		return
+	}
+	response.Success(c, "注销会话成功", nil)
+}
+
+// RevokeAllSessions 注销全部会话
+// @Summary 注销全部会话
+// @Description 注销当前用户的全部会话，keepCurrent 为 true 时保留当前会话
+// @Tags 会话管理
+// @Accept json
+// @Produce json
+// @Param body body dto.RevokeAllSessionsDTO true "注销参数"
+// @Success 200 {object} response.Response "data={revoked: int}"
+// @Failure 400 {object} response.Response
+// @Router /api/session/revoke_all [post]
+// @Security BearerAuth
+func RevokeAllSessions(c *gin.Context) {
+	var dtoModel dto.RevokeAllSessionsDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	exceptID := ""
+	if dtoModel.KeepCurrent {
+		exceptID = c.GetString("session_id")
+	}
+	sessionService := services.NewSessionService()
+	revoked, err := sessionService.RevokeAll(c, c.GetInt("user_id"), exceptID)
+	if err != nil {
+		response.Error(c, "注销会话失败: "+err.Error())
+		return
+	}
+	response.Success(c, "注销会话成功", gin.H{"revoked": revoked})
+}
+
+// GetUserSessions 用户会话
+// @Summary 用户会话
+// @Description 管理员查看指定用户的有效会话
+// @Tags 会话管理
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} response.Response{data=[]models.Session}
+// @Failure 400 {object} response.Response
+// @Router /api/system/session/user/{id} [get]
+// @Security BearerAuth
+func GetUserSessions(c *gin.Context) {
+	var dtoModel dto.UserSessionsDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	sessionService := services.NewSessionService()
+	sessions, err := sessionService.List(c, dtoModel.UserID, c.GetString("session_id"))
+	if err != nil {
+		response.Error(c, "获取会话列表失败: "+err.Error())
+		return
+	}
+	response.Success(c, "获取会话列表成功", sessions)
+}
+
+// ForceLogout 强制下线
+// @Summary 强制下线
+// @Description 管理员注销指定用户的全部会话并删除其 API 密钥，用户需重新登录
+// @Tags 会话管理
+// @Accept json
+// @Produce json
+// @Param body body dto.ForceLogoutDTO true "用户"
+// @Success 200 {object} response.Response "data={revoked: int, revokedApiKeys: int}"
+// @Failure 400 {object} response.Response
+// @Router /api/system/session/force_logout [post]
+// @Security BearerAuth
+func ForceLogout(c *gin.Context) {
+	var dtoModel dto.ForceLogoutDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+
+	sessionService := services.NewSessionService()
+	revoked, err := sessionService.RevokeAll(c, dtoModel.UserID, "")
+	if err != nil {
+		response.Error(c, "强制下线失败: "+err.Error())
+		return
+	}
+	revokedKeys, err := services.NewAPIKeyService().RevokeAll(c, dtoModel.UserID)
+	if err != nil {
+		response.Error(c, "删除 API 密钥失败: "+err.Error())
+		return
+	}
+	response.Success(c, "强制下线成功", gin.H{"revoked": revoked, "revokedApiKeys": revokedKeys})
+}
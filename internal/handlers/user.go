@@ -29,6 +29,31 @@ func UserInfo(c *gin.Context) {
 	response.Success(c, "获取用户信息成功", user)
 }
 
+// ChangePassword 修改密码
+// @Summary 修改当前用户密码
+// @Description 校验原密码后设置新密码，新密码需满足密码策略；当前会话保留（需使用刷新令牌换取新的访问令牌），其他会话全部注销
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param body body dto.ChangePasswordDTO true "原密码与新密码"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/user/password [post]
+// @Security BearerAuth
+func ChangePassword(c *gin.Context) {
+	var dtoModel dto.ChangePasswordDTO
+	if err := param.Validate(c, &dtoModel); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	userService := services.NewUserService()
+	if err := userService.ChangePassword(c, c.GetInt("user_id"), c.GetString("session_id"), dtoModel.OldPassword, dtoModel.NewPassword); err != nil {
+		response.Error(c, err.Error())
+		return
+	}
+	response.Success(c, "修改密码成功", nil)
+}
+
 // UsersList 获取用户列表
 // @Summary 获取用户列表
 // @Description 分页获取用户列表
@@ -77,6 +102,13 @@ func UserEdit(c *gin.Context) {
 		response.Error(c, err.Error())
 		return
 	}
+	// 状态单独保存：禁用（0）不会被零值更新写入，且禁用需注销该用户的会话；未传状态时保持不变（新建用户为默认的启用）
+	if userRegisterDTO.Status != nil {
+		if err := userService.SetUserStatus(c, user.ID, *userRegisterDTO.Status); err != nil {
+			response.Error(c, "修改用户状态失败: "+err.Error())
+			return
+		}
+	}
 	roleIds := userRegisterDTO.RoleIds
 	if roleIds != nil {
 		rbacService := services.NewRBACService()
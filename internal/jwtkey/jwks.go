@@ -0,0 +1,45 @@
+package jwtkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK 公钥的 JSON Web Key 表示（RFC 7517）
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`   // RSA 模数
+	E   string `json:"e,omitempty"`   // RSA 公开指数
+	Crv string `json:"crv,omitempty"` // OKP 曲线
+	X   string `json:"x,omitempty"`   // Ed25519 公钥
+}
+
+// JWKS 公钥集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回所有可用于校验的公钥（含已退役的密钥），对称模式下为空集合
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.order))}
+	for _, key := range ks.order {
+		jwk := JWK{Use: "sig", Alg: key.Alg, Kid: key.Kid}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,234 @@
+// Package jwtkey 管理 JWT 签名密钥
+// 未配置 jwt.keys 时使用 jwt.secret 以 HS256 签名；配置后使用非对称密钥（RS256 或 EdDSA）签名，
+// 令牌头部携带 kid，校验时按 kid 选择公钥，公钥通过 JWKS 公开给其他服务校验令牌
+package jwtkey
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"webgos/internal/config"
+	"webgos/internal/xlog"
+)
+
+// minRSABits RSA 密钥最小长度
+const minRSABits = 2048
+
+var (
+	// ErrUnknownKey 令牌的 kid 不在密钥集中
+	ErrUnknownKey = errors.New("jwtkey: unknown key id")
+	// ErrAlgMismatch 令牌的签名算法与 kid 对应的密钥不一致
+	ErrAlgMismatch = errors.New("jwtkey: signing method does not match key")
+)
+
+// Key 非对称签名密钥，Private 为空表示已退役的密钥，只用于校验轮换前签发的令牌
+type Key struct {
+	Kid     string
+	Alg     string // RS256 或 EdDSA，由密钥类型决定
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet 签名与校验使用的密钥集，创建后不再修改
+type KeySet struct {
+	secret  []byte // 对称模式下的 HS256 密钥
+	signing *Key
+	keys    map[string]*Key
+	order   []*Key // 配置顺序，JWKS 按此顺序输出
+}
+
+// Load 按配置读取密钥文件并创建密钥集
+func Load(cfg *config.Config) (*KeySet, error) {
+	if len(cfg.JWT.Keys) == 0 {
+		return &KeySet{secret: []byte(cfg.JWT.Secret)}, nil
+	}
+
+	ks := &KeySet{keys: make(map[string]*Key, len(cfg.JWT.Keys))}
+	for _, kc := range cfg.JWT.Keys {
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", kc.Kid, err)
+		}
+		ks.keys[key.Kid] = key
+		ks.order = append(ks.order, key)
+		if ks.signing == nil && key.Private != nil && (cfg.JWT.SigningKey == "" || cfg.JWT.SigningKey == key.Kid) {
+			ks.signing = key
+		}
+	}
+	if ks.signing == nil {
+		return nil, errors.New("jwt signing key with a private key not found")
+	}
+	return ks, nil
+}
+
+func loadKey(kc config.JWTKey) (*Key, error) {
+	key := &Key{Kid: kc.Kid}
+	if kc.PrivateKeyFile != "" {
+		data, err := os.ReadFile(kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key.Private, err = parsePrivateKey(data); err != nil {
+			return nil, err
+		}
+		key.Public = key.Private.Public()
+	}
+	if kc.PublicKeyFile != "" {
+		data, err := os.ReadFile(kc.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		public, err := parsePublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+		if eq, ok := public.(interface{ Equal(crypto.PublicKey) bool }); ok && key.Public != nil && !eq.Equal(key.Public) {
+			return nil, errors.New("public key does not match private key")
+		}
+		key.Public = public
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+		}
+		key.Alg = jwt.SigningMethodRS256.Alg()
+	case ed25519.PublicKey:
+		key.Alg = jwt.SigningMethodEdDSA.Alg()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", key.Public)
+	}
+	return key, nil
+}
+
+// parsePrivateKey 解析 PEM 格式的 PKCS#1（RSA）或 PKCS#8 私钥
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// parsePublicKey 解析 PEM 格式的 PKCS#1（RSA）或 PKIX 公钥
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// Symmetric 是否为 HS256 对称签名模式
+func (ks *KeySet) Symmetric() bool {
+	return ks.signing == nil
+}
+
+// SigningKey 当前签名密钥，对称模式下为 nil
+func (ks *KeySet) SigningKey() *Key {
+	return ks.signing
+}
+
+// Sign 签发令牌，非对称模式下头部写入签名密钥的 kid
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.Symmetric() {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secret)
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.signing.Alg), claims)
+	token.Header["kid"] = ks.signing.Kid
+	return token.SignedString(ks.signing.Private)
+}
+
+// Parse 校验令牌签名并解析声明
+// 非对称模式下按 kid 选择密钥且算法必须与密钥一致，HS256 令牌不再被接受，避免持有旧密钥者伪造令牌
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	if ks.Symmetric() {
+		opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		return jwt.NewParser(opts...).ParseWithClaims(tokenString, claims, func(*jwt.Token) (any, error) {
+			return ks.secret, nil
+		})
+	}
+	opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+	return jwt.NewParser(opts...).ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != key.Alg {
+			return nil, ErrAlgMismatch
+		}
+		return key.Public, nil
+	})
+}
+
+var (
+	mu      sync.Mutex
+	current *KeySet
+	// loadedFor 生成 current 时的配置，配置快照变化（加载或热更新）后重新加载密钥
+	loadedFor *config.Config
+)
+
+// Init 按当前配置加载密钥集，启动时调用以便密钥文件错误时拒绝启动
+func Init() error {
+	mu.Lock()
+	defer mu.Unlock()
+	cfg := config.Get()
+	ks, err := Load(cfg)
+	if err != nil {
+		return err
+	}
+	current, loadedFor = ks, cfg
+	return nil
+}
+
+// Get 返回当前配置对应的密钥集
+// 热更新后 jwt 配置变化时重新加载，加载失败时记录错误并继续使用旧密钥集
+func Get() (*KeySet, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	cfg := config.Get()
+	if current != nil && loadedFor == cfg {
+		return current, nil
+	}
+	if current != nil && reflect.DeepEqual(loadedFor.JWT, cfg.JWT) {
+		loadedFor = cfg
+		return current, nil
+	}
+	ks, err := Load(cfg)
+	if err != nil {
+		if current == nil {
+			return nil, err
+		}
+		xlog.Error("reload jwt keys failed, keep previous keys: %v", err)
+		loadedFor = cfg
+		return current, nil
+	}
+	current, loadedFor = ks, cfg
+	return current, nil
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"webgos/internal/xlog"
+)
+
+// FileSender 把邮件写入目录中的 .eml 文件，不实际发送，可用邮件客户端直接打开
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func (s FileSender) Send(ctx context.Context, msg Message) error {
+	if err := checkHeaders(msg); err != nil {
+		return err
+	}
+	now := time.Now()
+	data, err := build(s.From, msg, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+	// 文件名包含收件人便于查找，替换路径中不允许的字符
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405.000000000"), strings.NewReplacer("/", "_", `\`, "_", ":", "_").Replace(msg.To[0]))
+	path := filepath.Join(s.Dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	xlog.Info("[MAIL] To=%s Subject=%s saved to %s", strings.Join(msg.To, ","), msg.Subject, path)
+	return nil
+}
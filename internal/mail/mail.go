@@ -0,0 +1,153 @@
+// Package mail 发送系统邮件（如重置密码链接）
+// 按 mail.driver 选择发送方式：smtp 投递到邮件服务器；log 与 file 不实际发送，分别写入日志与 .eml 文件，
+// 仅供本地调试且需显式配置；未配置（none）时没有发送方式，依赖邮件的功能拒绝服务
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"webgos/internal/config"
+	"webgos/internal/xlog"
+)
+
+// Message 纯文本邮件
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender 邮件发送方式
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ErrNotConfigured 未配置邮件发送方式（mail.driver 为 none）
+var ErrNotConfigured = errors.New("mail: no sender configured")
+
+// New 按配置创建发送方式，未配置时返回 ErrNotConfigured
+func New(cfg *config.Config) (Sender, error) {
+	switch cfg.Mail.Driver {
+	case "", "none":
+		return nil, ErrNotConfigured
+	case "log":
+		return LogSender{}, nil
+	case "file":
+		return FileSender{Dir: cfg.Mail.Dir, From: cfg.Mail.From}, nil
+	case "smtp":
+		smtp := cfg.Mail.SMTP
+		return &SMTPSender{
+			Host:     smtp.Host,
+			Port:     smtp.Port,
+			Username: smtp.Username,
+			Password: smtp.Password,
+			TLS:      smtp.TLS,
+			From:     cfg.Mail.From,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported mail driver: %s", cfg.Mail.Driver)
+}
+
+var (
+	mu        sync.Mutex
+	current   Sender
+	loadedFor *config.Config
+	override  Sender
+)
+
+// Get 返回当前配置对应的发送方式，热更新修改 mail 配置后重新创建；SetSender 设置的发送方式优先
+func Get() (Sender, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if override != nil {
+		return override, nil
+	}
+	cfg := config.Get()
+	if current != nil && (loadedFor == cfg || reflect.DeepEqual(loadedFor.Mail, cfg.Mail)) {
+		loadedFor = cfg
+		return current, nil
+	}
+	sender, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	current, loadedFor = sender, cfg
+	return current, nil
+}
+
+// SetSender 替换发送方式（测试或自定义投递渠道），传入 nil 恢复按配置创建，返回原先设置的发送方式
+func SetSender(s Sender) Sender {
+	mu.Lock()
+	defer mu.Unlock()
+	old := override
+	override = s
+	return old
+}
+
+// Send 使用当前发送方式发送邮件
+func Send(ctx context.Context, msg Message) error {
+	sender, err := Get()
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, msg)
+}
+
+// LogSender 把邮件内容（含重置链接等敏感信息）写入日志，不实际发送，仅供本地调试
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	xlog.Info("[MAIL] To=%s Subject=%s\n%s", strings.Join(msg.To, ","), msg.Subject, msg.Body)
+	return nil
+}
+
+// build 生成 RFC 5322 格式的邮件，主题按 RFC 2047 编码，正文使用 quoted-printable
+func build(from string, msg Message, now time.Time) ([]byte, error) {
+	var b bytes.Buffer
+	if from != "" {
+		fmt.Fprintf(&b, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&b, "Message-ID: <%d.%s@webgos>\r\n", now.Unix(), hex.EncodeToString(id))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// checkHeaders 拒绝包含换行的地址与主题，防止邮件头注入
+func checkHeaders(msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mail: no recipients")
+	}
+	for _, v := range append([]string{msg.Subject}, msg.To...) {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("mail: invalid header value %q", v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout 连接与发送的总超时
+const smtpTimeout = 30 * time.Second
+
+// SMTPSender 通过 SMTP 服务器投递邮件
+// TLS 为 starttls 时要求服务器支持 STARTTLS，ssl 为隐式 TLS（通常为 465 端口），none 不加密（仅限内网中继）
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
+	From     string
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if err := checkHeaders(msg); err != nil {
+		return err
+	}
+	from, err := netmail.ParseAddress(s.From)
+	if err != nil {
+		return err
+	}
+	to := make([]string, len(msg.To))
+	for i, addr := range msg.To {
+		parsed, err := netmail.ParseAddress(addr)
+		if err != nil {
+			return err
+		}
+		to[i] = parsed.Address
+	}
+	data, err := build(s.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("mail: smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		// PlainAuth 拒绝在未加密的连接上发送密码（本机除外）
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	if s.TLS == "ssl" {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.Host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
@@ -0,0 +1,100 @@
+// Package mfakey 加密保存在数据库中的 TOTP 密钥
+// 加密密钥由 mfa.secret_key 经 HKDF-SHA256 派生，未配置时使用 jwt.secret；使用 AES-256-GCM 加密，
+// 保存格式为 enc:v1:<base64(nonce|密文)>。更换密钥材料后已保存的密钥无法解密，需重置用户的两步验证
+package mfakey
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"webgos/internal/config"
+)
+
+// prefix 密文前缀，包含格式版本
+const prefix = "enc:v1:"
+
+// hkdfInfo 派生加密密钥时的用途标识，与其他由同一密钥材料派生的密钥区分
+const hkdfInfo = "webgos mfa secret"
+
+// ErrInvalidSecret 密文格式错误或无法用当前密钥解密
+var ErrInvalidSecret = errors.New("mfakey: invalid encrypted secret")
+
+// Material 派生加密密钥使用的配置项：mfa.secret_key，未配置时为 jwt.secret
+func Material(cfg *config.Config) string {
+	if cfg.MFA.SecretKey != "" {
+		return cfg.MFA.SecretKey
+	}
+	return cfg.JWT.Secret
+}
+
+// Sealed 是否为本包加密的密文
+func Sealed(stored string) bool {
+	return strings.HasPrefix(stored, prefix)
+}
+
+// Seal 使用当前配置加密 TOTP 密钥，空字符串原样返回
+func Seal(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	aead, err := newAEAD(config.Get())
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open 使用当前配置解密 Seal 保存的密钥，空字符串原样返回
+func Open(stored string) (string, error) {
+	if stored == "" {
+		return "", nil
+	}
+	encoded, ok := strings.CutPrefix(stored, prefix)
+	if !ok {
+		return "", ErrInvalidSecret
+	}
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidSecret
+	}
+	aead, err := newAEAD(config.Get())
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", ErrInvalidSecret
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrInvalidSecret
+	}
+	return string(secret), nil
+}
+
+func newAEAD(cfg *config.Config) (cipher.AEAD, error) {
+	material := Material(cfg)
+	if material == "" {
+		return nil, errors.New("mfakey: mfa.secret_key and jwt.secret are both empty")
+	}
+	key, err := hkdf.Key(sha256.New, []byte(material), nil, hkdfInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
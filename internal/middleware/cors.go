@@ -1,17 +1,67 @@
 package middleware
 
 import (
+	"sync"
+	"sync/atomic"
+	"webgos/internal/config"
 	"webgos/internal/utils/response"
 
 	"github.com/gin-gonic/gin"
 )
 
+// corsPolicy 由配置生成的跨域策略，配置热更新时整体替换
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	credentials bool
+}
+
+var (
+	corsState atomic.Pointer[corsPolicy]
+	corsOnce  sync.Once
+)
+
+func newCORSPolicy(cfg *config.Config) *corsPolicy {
+	p := &corsPolicy{
+		origins:     make(map[string]bool),
+		credentials: cfg.CORS.AllowCredentials,
+	}
+	for _, origin := range cfg.CORS.AllowOrigins {
+		if origin == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		p.origins[origin] = true
+	}
+	return p
+}
+
 // 跨域中间件
+// 允许的来源由 cors.allow_origins 配置，支持热更新
 func CORS() gin.HandlerFunc {
+	corsOnce.Do(func() {
+		corsState.Store(newCORSPolicy(config.Get()))
+		config.Subscribe("cors", func(_, cfg *config.Config) {
+			corsState.Store(newCORSPolicy(cfg))
+		})
+	})
+
 	return func(c *gin.Context) {
+		policy := corsState.Load()
+		origin := c.GetHeader("Origin")
+
 		// 设置CORS头部
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		switch {
+		case policy.anyOrigin && !policy.credentials:
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && (policy.anyOrigin || policy.origins[origin]):
+			// 携带凭证时浏览器不接受通配符，需回显具体来源
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		if policy.credentials {
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
@@ -31,7 +31,7 @@ func Debounce(timeout ...time.Duration) gin.HandlerFunc {
 		if userID == 0 {
 			user_id = c.ClientIP() // 匿名用户用IP
 		}
-		key := user_id + "@" + path
+		key := cache.DebouncePrefix + user_id + "@" + path
 
 		// 检查是否已存在相同请求
 		if _, found := cache.GetCache().Get(key); found {
@@ -8,10 +8,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// JWT中间件
+// JWT中间件，同时接受机器客户端的 API 密钥（Authorization: ApiKey <密钥> 或 X-API-Key 请求头）
 func JWT() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if key := c.GetHeader("X-API-Key"); key != "" && authHeader == "" {
+			apiKeyAuth(c, key)
+			return
+		}
 		if authHeader == "" {
 			response.Unauthorized(c, "缺少认证令牌")
 			return
@@ -19,6 +23,10 @@ func JWT() gin.HandlerFunc {
 
 		// 检查Bearer token格式
 		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) == 2 && parts[0] == "ApiKey" {
+			apiKeyAuth(c, parts[1])
+			return
+		}
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
 			response.Unauthorized(c, "令牌格式错误")
 			return
@@ -30,7 +38,7 @@ func JWT() gin.HandlerFunc {
 
 		// token验证
 		service := services.NewAuthService()
-		claims, err := service.ValidateToken(tokenString)
+		claims, err := service.ValidateToken(c, tokenString)
 		if err != nil {
 			response.Unauthorized(c, err.Error())
 			return
@@ -39,6 +47,39 @@ func JWT() gin.HandlerFunc {
 		// 将用户信息存入上下文
 		c.Set("user_id", int((*claims)["user_id"].(float64)))
 		c.Set("username", (*claims)["username"].(string))
+		if sessionID, _ := (*claims)["sid"].(string); sessionID != "" {
+			c.Set("session_id", sessionID)
+			services.NewSessionService().Touch(c, sessionID, c.ClientIP())
+		}
+		c.Next()
+	}
+}
+
+// apiKeyAuth 使用 API 密钥认证，只能访问密钥权限范围内的接口；之后的 RBAC 中间件再按所属用户的权限校验，两者取交集
+func apiKeyAuth(c *gin.Context, key string) {
+	principal, err := services.NewAPIKeyService().Authenticate(c, key, c.ClientIP())
+	if err != nil {
+		response.Unauthorized(c, err.Error())
+		return
+	}
+	if !principal.Allows(requiredPermission(c)) {
+		response.Forbidden(c, "API 密钥无权访问该接口")
+		return
+	}
+
+	c.Set("user_id", principal.UserID)
+	c.Set("username", principal.Username)
+	c.Set("api_key_id", principal.KeyID)
+	c.Next()
+}
+
+// NoAPIKey 拒绝 API 密钥认证的请求，用于管理密钥、会话等只允许用户本人登录后操作的接口，需放在 JWT 之后
+func NoAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetInt("api_key_id") != 0 {
+			response.Forbidden(c, "该接口不允许使用 API 密钥访问")
+			return
+		}
 		c.Next()
 	}
 }
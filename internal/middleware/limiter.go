@@ -4,37 +4,76 @@ import (
 	"net/http"
 	"sync"
 	"webgos/common/bucketx"
+	"webgos/internal/config"
 	"webgos/internal/xlog"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ipLimiter 按IP维护令牌桶，限流参数变化时清空已有桶
+type ipLimiter struct {
+	mu       sync.Mutex
+	rate     int
+	capacity int
+	buckets  map[string]bucketx.TokenBucket
+}
+
+func newIPLimiter(rate, capacity int) *ipLimiter {
+	return &ipLimiter{
+		rate:     rate,
+		capacity: capacity,
+		buckets:  make(map[string]bucketx.TokenBucket),
+	}
+}
+
+// reset 更新限流参数，参数未变化时保留已有令牌桶
+func (l *ipLimiter) reset(rate, capacity int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate == rate && l.capacity == capacity {
+		return
+	}
+	l.rate = rate
+	l.capacity = capacity
+	l.buckets = make(map[string]bucketx.TokenBucket)
+}
+
+func (l *ipLimiter) handle(c *gin.Context) {
+	ip := c.ClientIP()
+	l.mu.Lock()
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = bucketx.NewTokenBucket(l.rate, l.capacity)
+		l.buckets[ip] = b
+	}
+	l.mu.Unlock()
+
+	if b.TryTake(1) {
+		c.Next()
+	} else {
+		xlog.Warn("[SECURITY] IP %s 超过请求频率限制", ip)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"message": "服务器内部错误",
+		})
+	}
+}
+
 // IPLimiter 基于令牌桶的IP限流中间件
 // rate: 每秒填充的令牌数（即每秒允许的请求数）
 // capacity: 桶容量，决定允许的瞬时突发量（一般和 rate 一致表示不允许突发）
 // 每个IP独立维护一个令牌桶，请求消耗1个令牌，令牌不足时返回 500
 func IPLimiter(rate, capacity int) gin.HandlerFunc {
-	var mu sync.Mutex
-	buckets := make(map[string]bucketx.TokenBucket)
+	return newIPLimiter(rate, capacity).handle
+}
 
+// ConfigIPLimiter 限流参数取自配置的IP限流中间件，配置热更新后自动生效
+// params 从配置中取出 rate 与 capacity，例如 /auth 路由使用 limiter.auth_rate 与 limiter.auth_capacity
+// 每个请求按当前配置核对参数而不订阅配置变更，多次创建路由（如测试）不会累积订阅者
+func ConfigIPLimiter(params func(cfg *config.Config) (rate, capacity int)) gin.HandlerFunc {
+	l := newIPLimiter(params(config.Get()))
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		mu.Lock()
-		b, ok := buckets[ip]
-		if !ok {
-			b = bucketx.NewTokenBucket(rate, capacity)
-			buckets[ip] = b
-		}
-		mu.Unlock()
-
-		if b.TryTake(1) {
-			c.Next()
-		} else {
-			xlog.Warn("[SECURITY] IP %s 超过请求频率限制", ip)
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"code":    http.StatusInternalServerError,
-				"message": "服务器内部错误",
-			})
-		}
+		l.reset(params(config.Get()))
+		l.handle(c)
 	}
 }
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"time"
@@ -30,51 +31,23 @@ func RBAC() gin.HandlerFunc {
 			return
 		}
 
-		// 从缓存获取用户权限
+		// 从缓存获取用户权限，并发未命中只查询一次数据库，不存在的用户短时间负缓存
 		cacheKey := cache.PermissionPrefix + ":" + strconv.Itoa(userID)
-		userPermissions, found := cache.GetCache().Get(cacheKey)
-		var permissions map[string]bool
-		if !found {
-			// 缓存未命中，查询数据库
-			var user models.User
-			if err := xdb.GetDB().Preload("Roles.Menus.Permissions").Where("id = ?", userID).First(&user).Error; err != nil {
-				response.Unauthorized(c, "用户不存在")
-				return
-			}
-			// 超管跳过权限检查
-			if user.Username == config.GlobalConfig.SuperAccount {
-				c.Next()
-				return
-			}
-			// 收集用户所有权限：user → roles → menus → permissions
-			permissions = make(map[string]bool)
-			for _, role := range user.Roles {
-				for _, menu := range role.Menus {
-					for _, perm := range menu.Permissions {
-						key := perm.Name
-						permissions[key] = true
-					}
-				}
-			}
-			// 将权限存入缓存
-			cache.GetCache().Set(cacheKey, permissions, 5*time.Minute)
-		} else {
-			// 缓存命中，直接使用缓存的权限
-			permissionsMap, ok := userPermissions.(map[string]bool)
-			if !ok {
-				// 缓存类型错误，重新查询或拒绝请求
-				cache.GetCache().Delete(cacheKey)
-				response.Unauthorized(c, "权限验证失败")
-				return
-			}
-			permissions = permissionsMap
+		perms, err := permissionCache.GetOrLoadTagged(c, cacheKey, func(ctx context.Context) (userPermissions, []string, error) {
+			return loadUserPermissions(userID)
+		})
+		if err != nil {
+			response.Unauthorized(c, "用户不存在")
+			return
 		}
+		// 超管跳过权限检查
+		if perms.Username == config.Get().SuperAccount {
+			c.Next()
+			return
+		}
+		permissions := perms.Permissions
 
-		// 检查当前请求是否有权限（统一转小写，与权限点同步时存储的 path 保持一致）
-		currentPath := strings.ToLower(c.FullPath())
-		currentMethod := strings.ToUpper(c.Request.Method)
-		requiredPermission := currentPath + "#" + currentMethod
-		if permissions[requiredPermission] {
+		if permissions[requiredPermission(c)] {
 			c.Next()
 		} else {
 			response.Forbidden(c, "没有访问权限")
@@ -82,3 +55,37 @@ func RBAC() gin.HandlerFunc {
 		}
 	}
 }
+
+// requiredPermission 当前请求对应的权限点名称（统一转小写，与权限点同步时存储的 path 保持一致）
+func requiredPermission(c *gin.Context) string {
+	return strings.ToLower(c.FullPath()) + "#" + strings.ToUpper(c.Request.Method)
+}
+
+// permissionCache 用户权限缓存，带有用户及其角色、菜单的标签，角色/菜单权限变更时由 rbacService 按标签失效
+var permissionCache = cache.NewTyped[userPermissions](cache.WithTTL(5*time.Minute), cache.WithNegativeTTL(30*time.Second))
+
+// userPermissions 用户权限缓存值，超管判断在每次请求时按当前配置进行
+type userPermissions struct {
+	Username    string          `json:"username"`
+	Permissions map[string]bool `json:"permissions"`
+}
+
+// loadUserPermissions 收集用户所有权限：user → roles → menus → permissions，并返回所依赖的用户、角色与菜单标签
+func loadUserPermissions(userID int) (userPermissions, []string, error) {
+	var user models.User
+	if err := xdb.GetDB().Preload("Roles.Menus.Permissions").Where("id = ?", userID).First(&user).Error; err != nil {
+		return userPermissions{}, nil, err
+	}
+	perms := userPermissions{Username: user.Username, Permissions: make(map[string]bool)}
+	tags := []string{cache.Tag(cache.TagUser, user.ID)}
+	for _, role := range user.Roles {
+		tags = append(tags, cache.Tag(cache.TagRole, role.ID))
+		for _, menu := range role.Menus {
+			tags = append(tags, cache.Tag(cache.TagMenu, menu.ID))
+			for _, perm := range menu.Permissions {
+				perms.Permissions[perm.Name] = true
+			}
+		}
+	}
+	return perms, tags, nil
+}
@@ -93,6 +93,12 @@ func CheckSensitivePath(c *gin.Context) {
 	}
 }
 
+// BanIP 将 IP 加入黑名单，供其他安全检测（如登录失败次数过多）调用
+func BanIP(ip, reason string) {
+	globalIPBlacklist.add(ip)
+	xlog.Warn("[SECURITY] 恶意IP自动封禁 IP=%s Reason=%s", ip, reason)
+}
+
 // IPBlacklistMiddleware IP黑名单中间件，在全局路由注册拦截黑名单IP的所有请求
 func IPBlacklistMiddleware(dir string) gin.HandlerFunc {
 	blacklistOnce.Do(func() {
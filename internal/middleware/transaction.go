@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"webgos/internal/utils/response"
+	"webgos/internal/xdb"
+	"webgos/internal/xlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Transaction 在单个数据库事务中执行整个请求
+// 事务通过 c.Set(xdb.TxContextKey) 传递，handler 把 c 作为 ctx 传给服务层即可，ctxDB/ctxSDB 自动使用该事务
+// HTTP 状态码 < 400、业务码为 0 且没有 c.Error 时提交，否则回滚（panic 同样回滚后继续交给 Recovery 处理）
+// 响应在事务结束前先缓存，提交失败时改为返回错误，避免客户端收到成功但数据未落库
+// 服务层通过 xdb.AfterCommit 登记的回调（如清除缓存）在提交成功后执行
+func Transaction() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 使用请求的 context 开启事务：gin.Context 会在请求结束后被复用，不能交给 database/sql 在后台监听
+		// 复制用户与请求标识，事务内的写操作仍标记写后读主库的粘滞窗口
+		tx := xdb.GetDB().WithContext(xdb.WithStickyIdentity(c.Request.Context(), c)).Begin()
+		if tx.Error != nil {
+			xlog.Error("begin transaction failed: %v", tx.Error)
+			response.Error(c, "开启事务失败")
+			return
+		}
+
+		origin := c.Writer
+		writer := &bufferedWriter{ResponseWriter: origin}
+		c.Writer = writer
+		hooks := &xdb.TxHooks{}
+		c.Set(xdb.TxContextKey, tx)
+		c.Set(xdb.TxHooksContextKey, hooks)
+
+		done := false
+		defer func() {
+			if !done {
+				tx.Rollback()
+				c.Writer = origin
+			}
+		}()
+
+		c.Next()
+
+		c.Writer = origin
+		done = true
+		if writer.Status() >= http.StatusBadRequest || c.GetInt(response.CodeContextKey) != 0 || len(c.Errors) > 0 {
+			tx.Rollback()
+			writer.flush()
+			return
+		}
+		if err := tx.Commit().Error; err != nil {
+			xlog.Error("commit transaction failed: %v", err)
+			response.Error(c, "提交事务失败")
+			return
+		}
+		hooks.Run()
+		writer.flush()
+	}
+}
+
+// bufferedWriter 缓存响应状态码与内容，由事务中间件在提交或回滚后统一写出
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.status != 0 || w.body.Len() > 0
+}
+
+func (w *bufferedWriter) Flush() {}
+
+// flush 将缓存的响应写入原始 ResponseWriter
+func (w *bufferedWriter) flush() {
+	if !w.Written() {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.Status())
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// APIKey 供脚本、扫码枪、报表任务等机器客户端使用的 API 密钥，以所属用户的身份访问接口
+// 只存储密钥的 SHA-256 摘要，Prefix 为密钥开头的若干字符，用于在列表中辨认密钥
+// 可访问的接口为 Scopes 与所属用户当前 RBAC 权限的交集
+type APIKey struct {
+	ID         int           `gorm:"primaryKey" json:"id"`
+	UserID     int           `gorm:"index;not null" json:"user_id"`
+	Name       string        `gorm:"size:100;not null" json:"name"`
+	Prefix     string        `gorm:"size:16;not null" json:"prefix"`
+	KeyHash    string        `gorm:"column:key_hash;size:64;uniqueIndex;not null" json:"-"`
+	Scopes     []APIKeyScope `gorm:"foreignKey:APIKeyID" json:"-"`
+	ScopeNames []string      `gorm:"-" json:"scopes"`                                   // 权限点名称，仅用于展示
+	CreatedBy  int           `gorm:"column:created_by" json:"created_by"`               // 创建人，管理员为服务账号创建时与 UserID 不同
+	ExpiresAt  *time.Time    `gorm:"index" json:"expires_at,omitempty"`                 // 为空表示永不过期
+	LastUsedAt *time.Time    `gorm:"column:last_used_at" json:"last_used_at,omitempty"` // 最近使用时间，按分钟更新
+	LastUsedIP string        `gorm:"column:last_used_ip;size:64" json:"last_used_ip"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// APIKeyScope API 密钥可访问的权限点，按权限点名称（如 /api/products#GET）保存
+type APIKeyScope struct {
+	APIKeyID   int    `gorm:"column:api_key_id;primaryKey" json:"api_key_id"`
+	Permission string `gorm:"size:100;primaryKey" json:"permission"`
+}
+
+func (APIKeyScope) TableName() string {
+	return "api_key_scopes"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CacheInvalidation 缓存失效事件，数据库轮询广播总线（cache.DBBus）使用，定期清理
+type CacheInvalidation struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	Op        string    `gorm:"size:20;not null" json:"op"`
+	CacheKey  string    `gorm:"column:cache_key;size:1024" json:"cache_key"`
+	NodeID    string    `gorm:"column:node_id;size:100;not null" json:"node_id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (CacheInvalidation) TableName() string {
+	return "cache_invalidations"
+}
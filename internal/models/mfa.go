@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// MFARecoveryCode 两步验证恢复码，丢失验证器时代替验证码使用，每个只能使用一次；只存储 SHA-256 摘要
+// 重新生成恢复码或关闭两步验证时删除该用户的全部恢复码
+type MFARecoveryCode struct {
+	ID        int        `gorm:"primaryKey" json:"id"`
+	UserID    int        `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"column:code_hash;size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
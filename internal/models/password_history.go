@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PasswordHistory 用户使用过的密码（bcrypt 哈希），配置 password_policy.history 时禁止重复使用最近的密码
+// 每次设置密码时写入，只保留最近 history 条
+type PasswordHistory struct {
+	ID           int       `gorm:"primaryKey" json:"id"`
+	UserID       int       `gorm:"index;not null" json:"user_id"`
+	PasswordHash string    `gorm:"column:password_hash;size:100;not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PasswordResetToken 重置密码令牌，通过邮件发送给用户，只存储令牌的 SHA-256 摘要
+// 令牌只能使用一次；同一用户申请新令牌或重置成功后，其余未使用的令牌一并作废
+type PasswordResetToken struct {
+	ID        int        `gorm:"primaryKey" json:"id"`
+	UserID    int        `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"column:token_hash;size:64;uniqueIndex;not null" json:"-"`
+	IP        string     `gorm:"size:64" json:"ip"` // 申请重置的客户端 IP
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"` // 已使用或已作废的时间
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
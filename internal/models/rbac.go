@@ -5,6 +5,7 @@ type RBACRole struct {
 	Name        string    `gorm:"size:50;unique" json:"name"`
 	Remark      string    `gorm:"size:200" json:"remark"`
 	Status      int       `gorm:"default:1;comment:状态 0-禁用 1-启用" json:"status"`
+	RequireMFA  bool      `gorm:"column:require_mfa;default:false;not null" json:"require_mfa"` // 拥有该角色的用户登录必须通过两步验证
 	Users       []User    `gorm:"many2many:rbac_user_roles;" json:"-"`
 	Menus       []Menu    `gorm:"many2many:rbac_role_menus" json:"-"`
 	MenuIDs     []int     `gorm:"-" json:"menu_ids"`
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RefreshToken 服务端保存的刷新令牌，只存储令牌的 SHA-256 摘要
+// 同一次登录及其后续轮换产生的令牌属于同一家族（FamilyID，即会话 Session.ID），已使用的令牌再次出现时注销整个家族
+type RefreshToken struct {
+	ID        int        `gorm:"primaryKey" json:"id"`
+	UserID    int        `gorm:"index;not null" json:"user_id"`
+	FamilyID  string     `gorm:"column:family_id;size:36;index;not null" json:"family_id"`
+	TokenHash string     `gorm:"column:token_hash;size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`    // 已轮换为新令牌的时间
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // 注销时间（登出或检测到重用）
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
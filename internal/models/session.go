@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Session 登录会话，一次登录及其后续刷新属于同一会话，ID 即访问令牌中的 sid 与刷新令牌的 FamilyID
+// 注销会话时同时注销其刷新令牌并使访问令牌失效
+type Session struct {
+	ID         string     `gorm:"primaryKey;size:36" json:"id"`
+	UserID     int        `gorm:"index;not null" json:"user_id"`
+	IP         string     `gorm:"size:64" json:"ip"`
+	UserAgent  string     `gorm:"column:user_agent;size:512" json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at" json:"last_seen_at"` // 最近活跃时间，按分钟更新
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expires_at"`        // 随刷新令牌轮换延长
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `gorm:"-" json:"current"` // 是否为发起请求的会话，仅用于列表展示
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
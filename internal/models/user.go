@@ -1,29 +1,40 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
 	BaseFields
-	Username     string     `gorm:"unique" json:"username"`
-	Nickname     string     `json:"nickname"`
-	Email        string     `json:"email"`
-	Phone        string     `json:"phone" `
-	Password     string     `json:"-"`
-	Gender       string     `json:"gender"`
-	Age          int        `json:"age"`
-	Status       int        `gorm:"default:1" json:"status"`
-	DepartmentID int        `gorm:"column:department_id;default:0" json:"department_id"`
-	Roles        []RBACRole `gorm:"many2many:rbac_user_roles;" json:"roles"`
+	Username          string     `gorm:"unique" json:"username"`
+	Nickname          string     `json:"nickname"`
+	Email             string     `json:"email"`
+	Phone             string     `json:"phone" `
+	Password          string     `json:"-"`
+	PasswordChangedAt *time.Time `gorm:"column:password_changed_at" json:"password_changed_at,omitempty"` // 最近一次设置密码的时间，配置 password_policy.max_age 时据此判断密码是否过期
+	Gender            string     `json:"gender"`
+	Age               int        `json:"age"`
+	Status            int        `gorm:"default:1" json:"status"`
+	DepartmentID      int        `gorm:"column:department_id;default:0" json:"department_id"`
+	TokenVersion      int        `gorm:"column:token_version;default:0;not null" json:"-"`             // 令牌版本，修改密码、状态或角色时递增，旧版本的访问令牌失效
+	MFAEnabled        bool       `gorm:"column:mfa_enabled;default:false;not null" json:"mfa_enabled"` // 是否已启用两步验证
+	MFASecret         string     `gorm:"column:mfa_secret;size:255" json:"-"`                          // TOTP 密钥，经 mfakey 加密保存；未确认启用前为待确认的密钥
+	Roles             []RBACRole `gorm:"many2many:rbac_user_roles;" json:"roles"`
 }
 
+// UserStatusEnabled 用户状态：启用；其他值为禁用
+const UserStatusEnabled = 1
+
 func (u *User) SetPassword(password string) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 	u.Password = string(hashedPassword)
+	now := time.Now()
+	u.PasswordChangedAt = &now
 	return nil
 }
 
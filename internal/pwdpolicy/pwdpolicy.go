@@ -0,0 +1,123 @@
+// Package pwdpolicy 密码策略：长度、字符类别、常见弱密码与用户名检查
+// 策略由 password_policy 配置，DTO 中可使用 password 验证标签，服务层设置密码前使用 Current().Check 校验
+package pwdpolicy
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"webgos/internal/config"
+)
+
+// maxBytes bcrypt 只使用前 72 字节，更长的密码无法正确校验
+const maxBytes = 72
+
+// 违反的规则
+const (
+	RuleLength   = "length"
+	RuleClasses  = "classes"
+	RuleCommon   = "common"
+	RuleUsername = "username"
+)
+
+// PolicyError 密码不满足策略
+type PolicyError struct {
+	Rule    string
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+// Policy 密码策略
+type Policy struct {
+	MinLength        int      // 最小字符数
+	MinClasses       int      // 至少包含的字符类别数：大写字母、小写字母、数字、符号
+	BanCommon        bool     // 禁止常见弱密码
+	Banned           []string // 额外禁止的密码
+	DisallowUsername bool     // 不能包含用户名
+}
+
+// FromConfig 按配置创建策略
+func FromConfig(cfg *config.Config) Policy {
+	p := cfg.PasswordPolicy
+	return Policy{
+		MinLength:        p.MinLength,
+		MinClasses:       p.MinClasses,
+		BanCommon:        p.BanCommon,
+		Banned:           p.Banned,
+		DisallowUsername: p.DisallowUsername,
+	}
+}
+
+// Current 当前配置的策略，配置热更新后立即生效
+func Current() Policy {
+	return FromConfig(config.Get())
+}
+
+// Check 校验密码，返回第一个不满足的规则；username 为空时不检查用户名
+func (p Policy) Check(password, username string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return &PolicyError{Rule: RuleLength, Message: fmt.Sprintf("长度不能少于%d个字符", p.MinLength)}
+	}
+	if len(password) > maxBytes {
+		return &PolicyError{Rule: RuleLength, Message: fmt.Sprintf("长度不能超过%d字节", maxBytes)}
+	}
+	if classes := countClasses(password); classes < p.MinClasses {
+		return &PolicyError{Rule: RuleClasses, Message: fmt.Sprintf("需至少包含大写字母、小写字母、数字、符号中的%d类", p.MinClasses)}
+	}
+	lower := strings.ToLower(password)
+	if p.BanCommon && commonPasswords[lower] {
+		return &PolicyError{Rule: RuleCommon, Message: "过于常见，请使用更复杂的密码"}
+	}
+	for _, banned := range p.Banned {
+		if strings.EqualFold(password, banned) {
+			return &PolicyError{Rule: RuleCommon, Message: "属于禁止使用的密码"}
+		}
+	}
+	if p.DisallowUsername && username != "" && strings.Contains(lower, strings.ToLower(username)) {
+		return &PolicyError{Rule: RuleUsername, Message: "不能包含用户名"}
+	}
+	return nil
+}
+
+func countClasses(password string) int {
+	var upper, lower, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return upper + lower + digit + symbol
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = parseList(commonPasswordsFile)
+
+// parseList 解析每行一个密码的列表，忽略空行与 # 开头的注释
+func parseList(data string) map[string]bool {
+	list := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list[strings.ToLower(line)] = true
+	}
+	return list
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"webgos/internal/handlers"
+	"webgos/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(router *gin.Engine) {
+		api := router.Group("/api")
+
+		// 我的 API 密钥，只能由用户登录后管理，不允许使用 API 密钥创建新密钥；不注册为权限点
+		apiKey := api.Group("/api_key")
+		apiKey.Use(middleware.JWT())
+		apiKey.Use(middleware.NoAPIKey())
+		{
+			apiKey.GET("", handlers.ListAPIKeys)
+			apiKey.POST("", handlers.CreateAPIKey)
+			apiKey.DELETE("/:id", handlers.RevokeAPIKey)
+		}
+
+		// API 密钥管理，可为服务账号创建密钥，需授权后访问
+		systemAPIKey := WrapRouter(api.Group("/system/api_key"))
+		systemAPIKey.Use(middleware.JWT())
+		systemAPIKey.Use(middleware.NoAPIKey())
+		systemAPIKey.Use(middleware.RBAC())
+		{
+			systemAPIKey.GET("/user/:id", "用户API密钥", handlers.GetUserAPIKeys)
+			systemAPIKey.POST("", "为用户创建API密钥", handlers.CreateUserAPIKey)
+			systemAPIKey.DELETE("/:id", "删除任意API密钥", handlers.DeleteAPIKey)
+		}
+	})
+}
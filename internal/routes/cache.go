@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"webgos/internal/handlers"
+	"webgos/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(router *gin.Engine) {
+		api := router.Group("/api")
+
+		// 缓存管理路由，清空与删除会影响所有用户，需授权后访问
+		systemCache := WrapRouter(api.Group("/system/cache"))
+		systemCache.Use(middleware.JWT())
+		systemCache.Use(middleware.RBAC())
+		{
+			systemCache.GET("", "缓存统计", handlers.GetCacheStats)
+			systemCache.POST("/inspect", "查看缓存键", handlers.InspectCacheKey)
+			systemCache.POST("/delete_prefix", "按前缀删除缓存", handlers.DeleteCachePrefix)
+			systemCache.POST("/flush", "清空缓存", handlers.FlushCache)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"webgos/internal/handlers"
+	"webgos/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(router *gin.Engine) {
+		api := router.Group("/api")
+
+		// 账号锁定管理，需授权后访问
+		lockout := WrapRouter(api.Group("/system/lockout"))
+		lockout.Use(middleware.JWT())
+		lockout.Use(middleware.RBAC())
+		{
+			lockout.POST("/status", "账号锁定状态", handlers.GetLockoutStatus)
+			lockout.POST("/unlock", "解除账号锁定", handlers.UnlockAccount)
+		}
+	})
+}
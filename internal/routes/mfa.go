@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"webgos/internal/handlers"
+	"webgos/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(router *gin.Engine) {
+		api := router.Group("/api")
+
+		// 我的两步验证，登录即可访问，不注册为权限点
+		mfa := api.Group("/mfa")
+		mfa.Use(middleware.JWT())
+		mfa.Use(middleware.NoAPIKey())
+		{
+			mfa.GET("", handlers.GetMFAStatus)
+			mfa.POST("/setup", handlers.SetupMFA)
+			mfa.POST("/confirm", handlers.ConfirmMFA)
+			mfa.POST("/disable", handlers.DisableMFA)
+			mfa.POST("/recovery_codes", handlers.RegenerateRecoveryCodes)
+		}
+
+		// 两步验证管理，需授权后访问
+		systemMFA := WrapRouter(api.Group("/system/mfa"))
+		systemMFA.Use(middleware.JWT())
+		systemMFA.Use(middleware.RBAC())
+		{
+			systemMFA.POST("/reset", "重置用户两步验证", handlers.ResetUserMFA)
+		}
+	})
+}
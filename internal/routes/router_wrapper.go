@@ -7,7 +7,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
-	"webgos/internal/config"
 	"webgos/internal/models"
 )
 
@@ -70,16 +69,14 @@ func (w *RouterWrapper) addRouteInfoWithHandlers(relativePath, method, descripti
 		w.RouterGroup.DELETE(relativePath, handlers...)
 	}
 	// 添加路由信息到routeInfos（只记录路径和方法，不记录中间件）
-	// 仅在配置中启用自动同步RBAC权限点时才收集路由信息
-	if config.GlobalConfig.AutoRBACPoint {
-		fullPath := strings.ToLower(w.calculateFullPath(relativePath))
-		routeInfos = append(routeInfos, RouteInfo{
-			Path:        fullPath,
-			Method:      method,
-			Description: description,
-			Name:        fullPath + "#" + method,
-		})
-	}
+	// 始终收集，是否同步到数据库由启动流程根据 auto_rbac_point 决定，命令行 rbac sync/prune 与 routes list 也依赖该列表
+	fullPath := strings.ToLower(w.calculateFullPath(relativePath))
+	routeInfos = append(routeInfos, RouteInfo{
+		Path:        fullPath,
+		Method:      method,
+		Description: description,
+		Name:        fullPath + "#" + method,
+	})
 
 }
 func lastChar(str string) uint8 {
@@ -103,6 +100,11 @@ func (w *RouterWrapper) calculateFullPath(relativePath string) string {
 	return finalPath
 }
 
+// Routes 返回通过 RouterWrapper 注册的路由（即权限点），需在 New 之后调用
+func Routes() []RouteInfo {
+	return append([]RouteInfo(nil), routeInfos...)
+}
+
 // SyncPermissions 将收集的路由信息同步到数据库作为权限点。
 // 注意：仅负责权限点本身的同步（创建/更新描述），不做菜单归属。
 // 菜单与权限点的绑定由 AssignPermissionsToMenu 显式维护，
@@ -135,3 +137,39 @@ func SyncPermissions(db *gorm.DB) error {
 	}
 	return nil
 }
+
+// PrunePermissions 删除数据库中已没有对应路由的权限点（同时解除菜单绑定），返回被删除（dryRun 时为将被删除）的权限点。
+// 路由改名或删除后旧权限点不会被 SyncPermissions 清理，需显式执行。
+func PrunePermissions(db *gorm.DB, dryRun bool) ([]models.RBACPermission, error) {
+	active := make(map[string]bool, len(routeInfos))
+	for _, route := range routeInfos {
+		active[route.Name] = true
+	}
+
+	var permissions []models.RBACPermission
+	if err := db.Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	stale := make([]models.RBACPermission, 0)
+	for _, permission := range permissions {
+		if !active[permission.Name] {
+			stale = append(stale, permission)
+		}
+	}
+	if dryRun || len(stale) == 0 {
+		return stale, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range stale {
+			if err := tx.Model(&stale[i]).Association("Menus").Clear(); err != nil {
+				return err
+			}
+			if err := tx.Delete(&stale[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return stale, err
+}
@@ -30,6 +30,7 @@ func New(config *config.Config) *gin.Engine {
 	gin.SetMode(config.Server.Mode)
 	// 创建不带默认中间件的路由引擎
 	REngine = gin.New()
+	routeInfos = nil
 
 	// 应用通用中间件
 	middleware.ApplyMiddlewares(REngine, config)
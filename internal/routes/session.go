@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"webgos/internal/handlers"
+	"webgos/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(router *gin.Engine) {
+		api := router.Group("/api")
+
+		// 我的会话，只能查看与注销自己的会话；登录即可访问，不注册为权限点
+		session := api.Group("/session")
+		session.Use(middleware.JWT())
+		session.Use(middleware.NoAPIKey())
+		{
+			session.GET("", handlers.ListSessions)
+			session.DELETE("/:id", handlers.RevokeSession)
+			session.POST("/revoke_all", handlers.RevokeAllSessions)
+		}
+
+		// 会话管理，可让任意用户下线，需授权后访问
+		systemSession := WrapRouter(api.Group("/system/session"))
+		systemSession.Use(middleware.JWT())
+		systemSession.Use(middleware.RBAC())
+		{
+			systemSession.GET("/user/:id", "用户会话", handlers.GetUserSessions)
+			systemSession.POST("/force_logout", "强制下线", handlers.ForceLogout)
+		}
+	})
+}
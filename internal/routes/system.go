@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"webgos/internal/config"
 	"webgos/internal/handlers"
 	"webgos/internal/middleware"
 
@@ -13,14 +14,24 @@ func init() {
 
 		// 登录相关路由（公开）
 		loginGroup := router.Group("/auth")
-		loginGroup.Use(middleware.IPLimiter(1, 1))
+		loginGroup.Use(middleware.ConfigIPLimiter(func(cfg *config.Config) (int, int) {
+			return cfg.Limiter.AuthRate, cfg.Limiter.AuthCapacity
+		}))
 		{
 			loginGroup.POST("/register", handlers.RegisterUser)
 			loginGroup.POST("/login", handlers.Login)
+			loginGroup.POST("/mfa/verify", handlers.VerifyMFA)
+			loginGroup.POST("/mfa/setup", handlers.SetupMFAChallenge)
+			loginGroup.POST("/refresh", handlers.RefreshToken)
 			loginGroup.POST("/logout", handlers.Logout)
-			loginGroup.POST("/reset-password", handlers.ResetPassword)
+			loginGroup.POST("/password/forgot", handlers.ForgotPassword)
+			loginGroup.POST("/password/reset", handlers.ResetPassword)
+			loginGroup.POST("/password/change", handlers.ChangeExpiredPassword)
 		}
 
+		// JWT 公钥集（公开），供其他服务校验令牌
+		router.GET("/.well-known/jwks.json", handlers.JWKS)
+
 		// 需要认证的路由组
 		api := router.Group("/api")
 		api.Use(middleware.JWT())
@@ -61,7 +72,15 @@ func init() {
 		{
 			user.GET("/info", "当前用户", handlers.UserInfo)
 			user.POST("/list", "获取用户列表", handlers.UsersList)
-			user.POST("/edit", "修改用户", handlers.UserEdit)
+			// 保存用户与分配角色在同一事务中完成
+			user.POST("/edit", "修改用户", middleware.Transaction(), handlers.UserEdit)
+		}
+
+		// 修改自己的密码，登录即可访问，不注册为权限点
+		password := api.Group("/user")
+		password.Use(middleware.NoAPIKey())
+		{
+			password.POST("/password", handlers.ChangePassword)
 		}
 
 	})
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/dto"
+	"webgos/internal/models"
+	"webgos/internal/xdb"
+	"webgos/internal/xlog"
+)
+
+const (
+	// apiKeyPrefix API 密钥的固定开头，便于在日志与代码仓库中识别泄露的密钥
+	apiKeyPrefix = "wgk_"
+	// apiKeyDisplayLen 列表中展示的密钥开头长度
+	apiKeyDisplayLen = 12
+	// apiKeySeenInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+	apiKeySeenInterval = time.Minute
+)
+
+var (
+	// ErrInvalidAPIKey API 密钥不存在、已删除或已过期
+	ErrInvalidAPIKey = errors.New("API 密钥无效或已过期")
+	// ErrAPIKeyNotFound 要删除的密钥不存在或不属于该用户
+	ErrAPIKeyNotFound = errors.New("API 密钥不存在")
+	// ErrAPIKeyForbidden 只有超管本人可以为超管创建 API 密钥
+	ErrAPIKeyForbidden = errors.New("不能为超管创建 API 密钥")
+)
+
+// APIKeyCreated 创建 API 密钥的结果，Key 为完整密钥，仅此一次返回
+type APIKeyCreated struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyPrincipal API 密钥认证通过后的身份
+type APIKeyPrincipal struct {
+	KeyID    int      `json:"key_id"`
+	UserID   int      `json:"user_id"`
+	Username string   `json:"username"`
+	Scopes   []string `json:"scopes"`
+}
+
+// Allows 密钥的权限范围是否包含权限点，是否最终放行还需经过 RBAC 校验所属用户的权限
+func (p *APIKeyPrincipal) Allows(permission string) bool {
+	return slices.Contains(p.Scopes, permission)
+}
+
+// APIKeyService 机器客户端使用的 API 密钥
+type APIKeyService interface {
+	// List 用户的全部 API 密钥，不包含密钥本身
+	List(ctx context.Context, userID int) ([]models.APIKey, error)
+	// Create 为用户创建 API 密钥，权限范围必须是该用户当前拥有的权限点；createdBy 为操作人，为其他用户创建时操作人也必须拥有这些权限点
+	Create(ctx context.Context, userID, createdBy int, dtoModel dto.CreateAPIKeyDTO) (*APIKeyCreated, error)
+	// Revoke 删除 API 密钥，立即失效；ownerID 为 0 时不限制所属用户（管理员操作）
+	Revoke(ctx context.Context, ownerID, id int) error
+	// RevokeAll 删除用户的全部 API 密钥，返回删除的数量；重置或修改密码、强制下线时调用
+	RevokeAll(ctx context.Context, userID int) (int, error)
+	// Authenticate 校验密钥并记录最近使用时间，所属用户被禁用时同样失败
+	Authenticate(ctx context.Context, key, ip string) (*APIKeyPrincipal, error)
+}
+
+type apiKeyService struct{}
+
+func NewAPIKeyService() APIKeyService {
+	return &apiKeyService{}
+}
+
+// IsAPIKey 字符串是否具有 API 密钥的格式
+func IsAPIKey(s string) bool {
+	return strings.HasPrefix(s, apiKeyPrefix)
+}
+
+func (s *apiKeyService) List(ctx context.Context, userID int) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := ctxSDB(ctx).Preload("Scopes").Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		keys[i].ScopeNames = scopeNames(keys[i].Scopes)
+	}
+	return keys, nil
+}
+
+func scopeNames(scopes []models.APIKeyScope) []string {
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		names = append(names, scope.Permission)
+	}
+	return names
+}
+
+func (s *apiKeyService) Create(ctx context.Context, userID, createdBy int, dtoModel dto.CreateAPIKeyDTO) (*APIKeyCreated, error) {
+	cfg := config.Get().APIKey
+	expiresIn := dtoModel.ExpiresIn
+	if cfg.MaxExpiry > 0 {
+		if expiresIn == 0 {
+			expiresIn = cfg.MaxExpiry
+		}
+		if expiresIn > cfg.MaxExpiry {
+			return nil, fmt.Errorf("有效期不能超过%d天", cfg.MaxExpiry)
+		}
+	}
+
+	var user models.User
+	if err := ctxDB(ctx).Take(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	scopes := slices.Compact(slices.Sorted(slices.Values(dtoModel.Scopes)))
+	if err := checkScopes(ctx, &user, scopes); err != nil {
+		return nil, err
+	}
+	// 为其他用户创建时，操作人自己也必须拥有这些权限点，防止借服务账号或超管的身份扩大权限
+	if createdBy != userID {
+		var creator models.User
+		if err := ctxDB(ctx).Take(&creator, createdBy).Error; err != nil {
+			return nil, errors.New("操作人不存在")
+		}
+		superAccount := config.Get().SuperAccount
+		if user.Username == superAccount && creator.Username != superAccount {
+			return nil, ErrAPIKeyForbidden
+		}
+		if err := checkScopes(ctx, &creator, scopes); err != nil {
+			return nil, fmt.Errorf("操作人%w", err)
+		}
+	}
+
+	key, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	key = apiKeyPrefix + key
+	record := models.APIKey{
+		UserID:    userID,
+		Name:      dtoModel.Name,
+		Prefix:    key[:apiKeyDisplayLen],
+		KeyHash:   hashToken(key),
+		CreatedBy: createdBy,
+	}
+	for _, scope := range scopes {
+		record.Scopes = append(record.Scopes, models.APIKeyScope{Permission: scope})
+	}
+	if expiresIn > 0 {
+		expiresAt := time.Now().AddDate(0, 0, expiresIn)
+		record.ExpiresAt = &expiresAt
+	}
+
+	err = ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(cfg.MaxPerUser) {
+			return fmt.Errorf("每个用户最多创建%d个 API 密钥", cfg.MaxPerUser)
+		}
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	xlog.Warn("[SECURITY] 创建 API 密钥 ID=%d UserID=%d CreatedBy=%d Prefix=%s", record.ID, userID, createdBy, record.Prefix)
+	record.ScopeNames = scopes
+	return &APIKeyCreated{APIKey: record, Key: key}, nil
+}
+
+// checkScopes 权限范围必须是已存在的权限点，且所属用户当前拥有（超管可使用任意权限点）
+func checkScopes(ctx context.Context, user *models.User, scopes []string) error {
+	var existing []string
+	if err := ctxDB(ctx).Model(&models.RBACPermission{}).Where("name IN ?", scopes).Pluck("name", &existing).Error; err != nil {
+		return err
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(existing, scope) {
+			return fmt.Errorf("权限点不存在: %s", scope)
+		}
+	}
+	if user.Username == config.Get().SuperAccount {
+		return nil
+	}
+
+	var granted []string
+	if err := ctxDB(ctx).Model(&models.RBACPermission{}).
+		Joins("JOIN rbac_menu_permissions ON rbac_menu_permissions.rbac_permission_id = rbac_permissions.id").
+		Joins("JOIN rbac_role_menus ON rbac_role_menus.menu_id = rbac_menu_permissions.menu_id").
+		Joins("JOIN rbac_user_roles ON rbac_user_roles.rbac_role_id = rbac_role_menus.rbac_role_id").
+		Where("rbac_user_roles.user_id = ? AND rbac_permissions.name IN ?", user.ID, scopes).
+		Distinct().Pluck("rbac_permissions.name", &granted).Error; err != nil {
+		return err
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return fmt.Errorf("没有该权限，不能授予密钥: %s", scope)
+		}
+	}
+	return nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, ownerID, id int) error {
+	var record models.APIKey
+	query := ctxDB(ctx).Where("id = ?", id)
+	if ownerID > 0 {
+		query = query.Where("user_id = ?", ownerID)
+	}
+	if err := query.Take(&record).Error; err != nil {
+		return ErrAPIKeyNotFound
+	}
+	err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("api_key_id = ?", record.ID).Delete(&models.APIKeyScope{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&record).Error
+	})
+	if err != nil {
+		return err
+	}
+	apiKeyCache.Delete(cache.APIKeyPrefix + record.KeyHash)
+	xlog.Warn("[SECURITY] 删除 API 密钥 ID=%d UserID=%d Prefix=%s", record.ID, record.UserID, record.Prefix)
+	return nil
+}
+
+func (s *apiKeyService) RevokeAll(ctx context.Context, userID int) (int, error) {
+	var records []models.APIKey
+	if err := ctxDB(ctx).Select("id", "key_hash").Where("user_id = ?", userID).Find(&records).Error; err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	ids := make([]int, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("api_key_id IN ?", ids).Delete(&models.APIKeyScope{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.APIKey{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	xdb.AfterCommit(ctx, func() {
+		for _, record := range records {
+			apiKeyCache.Delete(cache.APIKeyPrefix + record.KeyHash)
+		}
+	})
+	xlog.Warn("[SECURITY] 删除用户的全部 API 密钥 UserID=%d Count=%d", userID, len(records))
+	return len(records), nil
+}
+
+// apiKeyAuth 密钥校验所需的信息，带有所属用户标签，用户变更时失效；删除密钥时按摘要删除
+type apiKeyAuth struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Username  string     `json:"username"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+var apiKeyCache = cache.NewTyped[apiKeyAuth](cache.WithTTL(5*time.Minute), cache.WithNegativeTTL(30*time.Second))
+
+func (s *apiKeyService) Authenticate(ctx context.Context, key, ip string) (*APIKeyPrincipal, error) {
+	if !IsAPIKey(key) {
+		return nil, ErrInvalidAPIKey
+	}
+	hash := hashToken(key)
+	auth, err := apiKeyCache.GetOrLoadTagged(ctx, cache.APIKeyPrefix+hash, func(ctx context.Context) (apiKeyAuth, []string, error) {
+		var record models.APIKey
+		if err := ctxDB(ctx).Preload("Scopes").Where("key_hash = ?", hash).Take(&record).Error; err != nil {
+			return apiKeyAuth{}, nil, err
+		}
+		var user models.User
+		if err := ctxDB(ctx).Select("id", "username").Take(&user, record.UserID).Error; err != nil {
+			return apiKeyAuth{}, nil, err
+		}
+		return apiKeyAuth{
+			ID:        record.ID,
+			UserID:    record.UserID,
+			Username:  user.Username,
+			Scopes:    scopeNames(record.Scopes),
+			ExpiresAt: record.ExpiresAt,
+		}, []string{cache.Tag(cache.TagUser, record.UserID)}, nil
+	})
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if auth.ExpiresAt != nil && time.Now().After(*auth.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+	state, err := loadAuthState(ctx, auth.UserID)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if state.Status != models.UserStatusEnabled {
+		return nil, ErrAccountDisabled
+	}
+
+	s.touch(ctx, auth.ID, ip)
+	return &APIKeyPrincipal{KeyID: auth.ID, UserID: auth.UserID, Username: auth.Username, Scopes: auth.Scopes}, nil
+}
+
+// touch 更新密钥最近使用时间与 IP，同一密钥每分钟最多写一次数据库
+func (s *apiKeyService) touch(ctx context.Context, id int, ip string) {
+	key := cache.APIKeySeenPrefix + strconv.Itoa(id)
+	if _, found := cache.GetCache().Get(key); found {
+		return
+	}
+	cache.GetCache().Set(key, true, apiKeySeenInterval)
+	if err := ctxDB(ctx).Model(&models.APIKey{}).Where("id = ?", id).
+		Updates(map[string]any{"last_used_at": time.Now(), "last_used_ip": ip}).Error; err != nil {
+		xlog.Error("更新 API 密钥 %d 使用时间失败: %v", id, err)
+	}
+}
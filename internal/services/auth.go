@@ -2,20 +2,58 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"webgos/internal/cache"
 	"webgos/internal/config"
+	"webgos/internal/jwtkey"
 	"webgos/internal/models"
+	"webgos/internal/xlog"
 )
 
+var (
+	// ErrInvalidRefreshToken 刷新令牌不存在、已过期或已注销
+	ErrInvalidRefreshToken = errors.New("刷新令牌无效或已过期")
+	// ErrRefreshTokenReused 已轮换的刷新令牌被再次使用，可能已泄露，该会话的所有令牌已注销
+	ErrRefreshTokenReused = errors.New("刷新令牌已被使用，请重新登录")
+	// ErrAccountDisabled 账号已禁用
+	ErrAccountDisabled = errors.New("账号已禁用")
+	// ErrTokenStale 访问令牌签发后用户的密码、状态或角色已变更，需使用刷新令牌换取新令牌
+	ErrTokenStale = errors.New("令牌已过期，请刷新令牌")
+)
+
+// TokenPair 登录与刷新返回的令牌对
+type TokenPair struct {
+	AccessToken      string `json:"accessToken"`
+	RefreshToken     string `json:"refreshToken"`
+	ExpiresIn        int64  `json:"expiresIn"`        // 访问令牌有效秒数
+	RefreshExpiresIn int64  `json:"refreshExpiresIn"` // 刷新令牌有效秒数
+	// RecoveryCodes 登录时按角色要求首次启用两步验证生成的恢复码，仅此一次返回
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
 type AuthService interface {
-	Login(ctx context.Context, username, password string) (string, error)
-	ValidateToken(tokenString string) (*jwt.MapClaims, error)
-	Logout(tokenString string)
+	Login(ctx context.Context, username, password string, client ClientInfo) (*TokenPair, error)
+	// VerifyMFA 使用挑战令牌与验证码（或恢复码）完成两步验证登录
+	VerifyMFA(ctx context.Context, challengeToken, code string, client ClientInfo) (*TokenPair, error)
+	// SetupMFA 角色要求两步验证而用户尚未启用时，登录过程中使用挑战令牌生成待确认的密钥
+	SetupMFA(ctx context.Context, challengeToken string) (*MFASetup, error)
+	// ChangeExpiredPassword 密码过期时使用登录返回的令牌设置新密码，完成登录
+	ChangeExpiredPassword(ctx context.Context, changeToken, password string, client ClientInfo) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+	ValidateToken(ctx context.Context, tokenString string) (*jwt.MapClaims, error)
+	Logout(ctx context.Context, accessToken, refreshToken string) error
 }
 
 type authService struct{}
@@ -24,44 +62,310 @@ func NewAuthService() AuthService {
 	return &authService{}
 }
 
-func (s *authService) Login(ctx context.Context, username, password string) (string, error) {
-	jwtConfig := config.GlobalConfig.JWT
+// Login 校验密码并创建新会话；配置了 jwt.max_sessions 时，超出上限注销最久未活跃的会话
+// 账号被锁定或处于渐进延迟中时不校验密码，直接返回 *LoginBlockedError
+// 用户已启用两步验证或角色要求两步验证时不签发令牌，返回 *MFAChallengeError，由 VerifyMFA 完成登录
+// 密码已过期时返回 *PasswordExpiredError，由 ChangeExpiredPassword 设置新密码后完成登录
+func (s *authService) Login(ctx context.Context, username, password string, client ClientInfo) (*TokenPair, error) {
+	guard := NewLoginGuard()
+	if err := guard.Check(ctx, username); err != nil {
+		return nil, err
+	}
 
 	var user models.User
 	if err := ctxDB(ctx).Where("username = ?", username).Take(&user).Error; err != nil {
-		return "", errors.New("用户不存在")
+		return nil, s.loginFailed(ctx, guard, username, client, errors.New("用户不存在"))
 	}
 
 	if !user.CheckPassword(password) {
-		return "", errors.New("密码错误")
+		return nil, s.loginFailed(ctx, guard, username, client, errors.New("密码错误"))
+	}
+
+	if user.Status != models.UserStatusEnabled {
+		guard.Succeed(ctx, username)
+		return nil, ErrAccountDisabled
+	}
+
+	required, err := NewMFAService().Required(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled || required {
+		// 两步验证通过后才清除失败计数，验证码错误同样计入账号锁定
+		challenge, err := newMFAChallenge(&user, !user.MFAEnabled)
+		if err != nil {
+			return nil, err
+		}
+		return nil, challenge
+	}
+	guard.Succeed(ctx, username)
+	return s.completeLogin(ctx, &user, client, nil)
+}
+
+// VerifyMFA 校验挑战令牌与验证码，通过后创建会话；验证码错误计入账号登录失败，同一挑战错误次数过多时作废
+// 挑战要求首次绑定时，验证码用于确认 SetupMFA 生成的密钥，启用两步验证并在令牌对中返回恢复码
+func (s *authService) VerifyMFA(ctx context.Context, challengeToken, code string, client ClientInfo) (*TokenPair, error) {
+	challenge, err := loadMFAChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	guard := NewLoginGuard()
+	if err := guard.Check(ctx, challenge.Username); err != nil {
+		return nil, err
+	}
+	// 校验前计入次数，并发提交的验证码同样受次数限制
+	if cache.GetCache().Incr(mfaAttemptsKey(challengeToken), mfaChallengeTTL) > mfaChallengeMaxAttempts {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	mfa := NewMFAService()
+	var recoveryCodes []string
+	if challenge.SetupRequired {
+		recoveryCodes, err = mfa.Confirm(ctx, challenge.UserID, code)
+	} else {
+		err = mfa.Verify(ctx, challenge.UserID, code)
+	}
+	if errors.Is(err, ErrInvalidMFACode) {
+		return nil, s.loginFailed(ctx, guard, challenge.Username, client, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	mfaChallengeCache.Delete(mfaChallengeKey(challengeToken))
+	cache.GetCache().Delete(mfaAttemptsKey(challengeToken))
+	guard.Succeed(ctx, challenge.Username)
+
+	var user models.User
+	if err := ctxDB(ctx).Take(&user, challenge.UserID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.Status != models.UserStatusEnabled {
+		return nil, ErrAccountDisabled
+	}
+	return s.completeLogin(ctx, &user, client, recoveryCodes)
+}
+
+func (s *authService) SetupMFA(ctx context.Context, challengeToken string) (*MFASetup, error) {
+	challenge, err := loadMFAChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.SetupRequired {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	return NewMFAService().Setup(ctx, challenge.UserID)
+}
+
+// completeLogin 身份校验全部通过后创建会话；密码已过期时不签发令牌，返回 *PasswordExpiredError
+func (s *authService) completeLogin(ctx context.Context, user *models.User, client ClientInfo, recoveryCodes []string) (*TokenPair, error) {
+	if passwordExpired(user) {
+		change, err := newPasswordChange(user, recoveryCodes)
+		if err != nil {
+			return nil, err
+		}
+		return nil, change
+	}
+	pair, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	pair.RecoveryCodes = recoveryCodes
+	return pair, nil
+}
+
+// startSession 创建新会话并签发令牌对
+func (s *authService) startSession(ctx context.Context, user *models.User, client ClientInfo) (*TokenPair, error) {
+	// 顺带清理该用户已过期的会话与刷新令牌
+	now := time.Now()
+	if err := ctxDB(ctx).Where("user_id = ? AND expires_at < ?", user.ID, now).Delete(&models.RefreshToken{}).Error; err != nil {
+		xlog.Error("清理过期刷新令牌失败: %v", err)
+	}
+	if err := ctxDB(ctx).Where("user_id = ? AND expires_at < ?", user.ID, now).Delete(&models.Session{}).Error; err != nil {
+		xlog.Error("清理过期会话失败: %v", err)
+	}
+	if err := enforceMaxSessions(ctx, user.ID, config.Get().JWT.MaxSessions); err != nil {
+		return nil, err
+	}
+
+	// 每次登录开启新的会话，会话 ID 即刷新令牌的家族 ID
+	session := models.Session{
+		ID:         uuid.NewString(),
+		UserID:     user.ID,
+		IP:         client.IP,
+		UserAgent:  truncate(client.UserAgent, 512),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Duration(config.Get().JWT.RefreshExpiry) * time.Hour),
+	}
+	var pair *TokenPair
+	err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		pair, err = s.issue(tx, user, session.ID)
+		return err
+	})
+	return pair, err
+}
+
+// loginFailed 记录登录失败，同一 IP 失败次数过多时返回 ErrIPBanned，否则返回 err
+func (s *authService) loginFailed(ctx context.Context, guard LoginGuard, username string, client ClientInfo, err error) error {
+	if banErr := guard.Fail(ctx, username, client.IP); banErr != nil {
+		return banErr
+	}
+	return err
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效（轮换），会话有效期随之延长
+// 已轮换的令牌再次出现说明令牌可能被窃取，注销整个会话（包括其访问令牌），双方都需重新登录
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	var pair *TokenPair
+	var reusedFamily string
+	var userID int
+	err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(refreshToken)).Take(&token).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+		userID = token.UserID
+		now := time.Now()
+		if token.RevokedAt != nil || now.After(token.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+		if token.UsedAt != nil {
+			reusedFamily = token.FamilyID
+			return ErrRefreshTokenReused
+		}
+		// 条件更新防止并发请求重复轮换同一令牌
+		result := tx.Model(&models.RefreshToken{}).Where("id = ? AND used_at IS NULL", token.ID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reusedFamily = token.FamilyID
+			return ErrRefreshTokenReused
+		}
+
+		var user models.User
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+		if user.Status != models.UserStatusEnabled {
+			return ErrAccountDisabled
+		}
+		var err error
+		if pair, err = s.issue(tx, &user, token.FamilyID); err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).Where("id = ?", token.FamilyID).Updates(map[string]any{
+			"ip":           client.IP,
+			"user_agent":   truncate(client.UserAgent, 512),
+			"last_seen_at": now,
+			"expires_at":   now.Add(time.Duration(pair.RefreshExpiresIn) * time.Second),
+		}).Error
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		xlog.Error("检测到刷新令牌重用，注销会话 %s", reusedFamily)
+		if err := revokeSessions(ctx, reusedFamily); err != nil {
+			xlog.Error("注销会话 %s 失败: %v", reusedFamily, err)
+		}
+	}
+	if errors.Is(err, ErrAccountDisabled) {
+		if _, err := NewSessionService().RevokeAll(ctx, userID, ""); err != nil {
+			xlog.Error("注销用户 %d 的会话失败: %v", userID, err)
+		}
+	}
+	if err != nil {
+		return nil, err
 	}
+	return pair, nil
+}
+
+// issue 签发访问令牌并保存新的刷新令牌
+func (s *authService) issue(db *gorm.DB, user *models.User, sessionID string) (*TokenPair, error) {
+	jwtConfig := config.Get().JWT
+	accessTTL := time.Duration(jwtConfig.AccessExpiry) * time.Minute
+	refreshTTL := time.Duration(jwtConfig.RefreshExpiry) * time.Hour
 
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"username": user.Username,
-		"exp":      time.Now().Add(time.Hour * time.Duration(jwtConfig.Expiry)).Unix(),
+		"sid":      sessionID,
+		"ver":      user.TokenVersion,
+		"exp":      time.Now().Add(accessTTL).Unix(),
+	}
+	keys, err := jwtkey.Get()
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := keys.Sign(claims)
+	if err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtConfig.Secret))
+	refreshToken, err := randomToken()
 	if err != nil {
-		return "", err
-	} // 将令牌存入缓存，设置与令牌相同的过期时间
-	// todo也可以使用黑名单方式实现登出功能，不用缓存储大量令牌
-	cache.GetCache().Set(tokenString, true, time.Duration(jwtConfig.Expiry)*time.Hour)
-	return tokenString, nil
+		return nil, err
+	}
+	if err := db.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  sessionID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTTL),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	// 访问令牌存入缓存，设置与令牌相同的过期时间；带有会话标签，注销会话时一并失效
+	cache.GetCache().SetWithTags(cache.TokenPrefix+accessToken, true, accessTTL, sessionTag(sessionID))
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int64(accessTTL / time.Second),
+		RefreshExpiresIn: int64(refreshTTL / time.Second),
+	}, nil
 }
 
-func (s *authService) ValidateToken(tokenString string) (*jwt.MapClaims, error) {
-	if _, found := cache.GetCache().Get(tokenString); !found {
-		return nil, errors.New("令牌已失效")
+// ValidateToken 校验访问令牌签名与有效期，并确认令牌未注销、令牌版本与用户当前版本一致
+// 缓存中没有该令牌（重启、淘汰）时按会话表确认会话仍有效，并重新写入缓存
+func (s *authService) ValidateToken(ctx context.Context, tokenString string) (*jwt.MapClaims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
 	}
 
-	jwtConfig := config.GlobalConfig.JWT
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.MapClaims{}, func(token *jwt.Token) (any, error) {
-		return []byte(jwtConfig.Secret), nil
-	})
+	userID, _ := (*claims)["user_id"].(float64)
+	version, _ := (*claims)["ver"].(float64)
+	state, err := loadAuthState(ctx, int(userID))
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if state.Status != models.UserStatusEnabled {
+		return nil, ErrAccountDisabled
+	}
+	if int(version) != state.TokenVersion {
+		return nil, ErrTokenStale
+	}
+	if _, found := cache.GetCache().Get(cache.TokenPrefix + tokenString); found {
+		return claims, nil
+	}
 
+	sessionID, _ := (*claims)["sid"].(string)
+	if sessionID == "" || !sessionActive(ctx, sessionID) {
+		return nil, errors.New("令牌已失效")
+	}
+	if exp, ok := (*claims)["exp"].(float64); ok {
+		cache.GetCache().SetWithTags(cache.TokenPrefix+tokenString, true, time.Until(time.Unix(int64(exp), 0)), sessionTag(sessionID))
+	}
+	return claims, nil
+}
+
+func parseToken(tokenString string, opts ...jwt.ParserOption) (*jwt.MapClaims, error) {
+	keys, err := jwtkey.Get()
+	if err != nil {
+		return nil, err
+	}
+	token, err := keys.Parse(tokenString, &jwt.MapClaims{}, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,6 +376,79 @@ func (s *authService) ValidateToken(tokenString string) (*jwt.MapClaims, error)
 	return nil, errors.New("无效的令牌")
 }
 
-func (s *authService) Logout(tokenString string) {
-	cache.GetCache().Delete(tokenString)
+// Logout 注销访问令牌所属的会话；只提供刷新令牌时按刷新令牌查找会话
+func (s *authService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	if accessToken != "" {
+		cache.GetCache().Delete(cache.TokenPrefix + accessToken)
+		// 访问令牌过期后仍可用于登出，只校验签名
+		if claims, err := parseToken(accessToken, jwt.WithoutClaimsValidation()); err == nil {
+			if sessionID, _ := (*claims)["sid"].(string); sessionID != "" {
+				if err := revokeSessions(ctx, sessionID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if refreshToken != "" {
+		var token models.RefreshToken
+		if err := ctxDB(ctx).Where("token_hash = ?", hashToken(refreshToken)).Take(&token).Error; err == nil {
+			return revokeSessions(ctx, token.FamilyID)
+		}
+	}
+	return nil
+}
+
+// authState 用户状态与令牌版本，每个请求校验访问令牌时使用，带有用户标签，用户变更时失效
+type authState struct {
+	Status       int `json:"status"`
+	TokenVersion int `json:"token_version"`
+}
+
+var authStateCache = cache.NewTyped[authState](cache.WithTTL(5*time.Minute), cache.WithNegativeTTL(30*time.Second))
+
+func loadAuthState(ctx context.Context, userID int) (authState, error) {
+	key := cache.AuthStatePrefix + strconv.Itoa(userID)
+	return authStateCache.GetOrLoadTagged(ctx, key, func(ctx context.Context) (authState, []string, error) {
+		var user models.User
+		err := ctxDB(ctx).Select("id", "status", "token_version").Take(&user, userID).Error
+		return authState{Status: user.Status, TokenVersion: user.TokenVersion}, []string{cache.Tag(cache.TagUser, userID)}, err
+	})
+}
+
+// bumpTokenVersion 递增用户的令牌版本，已签发的访问令牌随即失效（刷新令牌仍可换取新版本的令牌）
+func bumpTokenVersion(ctx context.Context, userIDs ...int) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	if err := ctxDB(ctx).Model(&models.User{}).Where("id IN ?", userIDs).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return err
+	}
+	invalidateUserCache(ctx, userIDs...)
+	return nil
+}
+
+// randomToken 生成 256 位随机的不透明令牌
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncate 按字节截断字符串，不截断多字节字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
 }
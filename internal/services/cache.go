@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"webgos/common/json"
+	"webgos/internal/cache"
+)
+
+// CacheEntry 缓存键的查看结果
+type CacheEntry struct {
+	Key   string `json:"key"`
+	Found bool   `json:"found"`
+	Group string `json:"group"`          // 统计分组
+	Type  string `json:"type,omitempty"` // 值的 Go 类型，分布式缓存为解码后的类型
+	Value any    `json:"value,omitempty"`
+}
+
+type CacheService interface {
+	Stats(ctx context.Context) (cache.StatsSnapshot, error)
+	Inspect(ctx context.Context, key string) CacheEntry
+	DeletePrefix(ctx context.Context, prefix string)
+	Flush(ctx context.Context)
+}
+
+type cacheService struct{}
+
+func NewCacheService() CacheService {
+	return &cacheService{}
+}
+
+func (s *cacheService) Stats(ctx context.Context) (cache.StatsSnapshot, error) {
+	return cache.GetStats().Snapshot(ctx, cache.GetCache())
+}
+
+// Inspect 读取缓存键，查看本身也计入该键的命中/未命中统计
+func (s *cacheService) Inspect(ctx context.Context, key string) CacheEntry {
+	entry := CacheEntry{Key: key, Group: cache.StatsGroup(key)}
+	value, found := cache.GetCache().Get(key)
+	if !found {
+		return entry
+	}
+	entry.Found = true
+	entry.Type = fmt.Sprintf("%T", value)
+	// 无法 JSON 序列化的值（如函数、通道）以文本形式返回
+	if _, err := json.Marshal(value); err != nil {
+		entry.Value = fmt.Sprintf("%v", value)
+	} else {
+		entry.Value = value
+	}
+	return entry
+}
+
+func (s *cacheService) DeletePrefix(ctx context.Context, prefix string) {
+	cache.GetCache().DeleteByPrefix(prefix)
+}
+
+func (s *cacheService) Flush(ctx context.Context) {
+	cache.GetCache().Flush()
+}
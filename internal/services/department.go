@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"webgos/internal/cache"
 	"webgos/internal/dto"
 	"webgos/internal/models"
 	"webgos/internal/xlog"
@@ -101,7 +102,7 @@ func (s *departmentService) Delete(ctx context.Context, id int) error {
 		return errors.New("部门不存在")
 	}
 
-	return ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+	if err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("parent_id = ?", id).Delete(&models.Department{}).Error; err != nil {
 			return err
 		}
@@ -111,7 +112,11 @@ func (s *departmentService) Delete(ctx context.Context, id int) error {
 		}
 
 		return tx.Delete(&department, id).Error
-	})
+	}); err != nil {
+		return err
+	}
+	invalidateTags(ctx, cache.Tag(cache.TagDepartment, id))
+	return nil
 }
 
 func (s *departmentService) GetTree(ctx context.Context) ([]models.Department, error) {
@@ -148,7 +153,11 @@ func (s *departmentService) AddUsers(ctx context.Context, departmentID int, user
 		return nil
 	}
 
-	return ctxDB(ctx).Model(&models.User{}).Where("id IN ?", userIDs).Update("department_id", departmentID).Error
+	if err := ctxDB(ctx).Model(&models.User{}).Where("id IN ?", userIDs).Update("department_id", departmentID).Error; err != nil {
+		return err
+	}
+	invalidateUserCache(ctx, userIDs...)
+	return nil
 }
 
 func (s *departmentService) RemoveUser(ctx context.Context, userID int) error {
@@ -169,5 +178,9 @@ func (s *departmentService) RemoveUser(ctx context.Context, userID int) error {
 	}
 
 	// 再将用户移出部门
-	return db.Model(&models.User{}).Where("id = ?", userID).Update("department_id", 0).Error
+	if err := db.Model(&models.User{}).Where("id = ?", userID).Update("department_id", 0).Error; err != nil {
+		return err
+	}
+	invalidateUserCache(ctx, userID)
+	return nil
 }
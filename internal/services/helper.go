@@ -7,16 +7,13 @@ import (
 	"gorm.io/gorm"
 )
 
-// ctxDB 获取主库连接（写操作）
+// ctxDB 获取主库连接（写操作），ctx 中有事务（xdb.Transaction 或 middleware.Transaction）时返回事务
 func ctxDB(ctx context.Context) *gorm.DB {
-	return xdb.GetDB().WithContext(ctx)
+	return xdb.WriteDB(ctx)
 }
 
-// ctxSDB 获取从库连接（读操作）
-// 注意：从库存在复制延迟，写后紧跟的读、强一致性读请使用 ctxDB 走主库
+// ctxSDB 获取从库连接（读操作），没有可用备库时自动回退主库，ctx 中有事务时返回事务
+// 同一用户/请求写入后的短时间内自动改走主库；需要强一致读时可传入 xdb.ForceMaster(ctx)
 func ctxSDB(ctx context.Context) *gorm.DB {
-	if xdb.GetSlaveDB() == nil {
-		return xdb.GetDB().WithContext(ctx)
-	}
-	return xdb.GetSlaveDB().WithContext(ctx)
+	return xdb.ReadDB(ctx)
 }
@@ -5,6 +5,9 @@ import (
 	"errors"
 
 	"webgos/internal/models"
+	"webgos/internal/xdb"
+
+	"gorm.io/gorm"
 )
 
 type InventoryService interface {
@@ -18,48 +21,61 @@ func NewInventoryService() InventoryService {
 	return &inventoryService{}
 }
 
+// ProductIn 入库：记录入库单并增加库存，两步在同一事务中完成
 func (s *inventoryService) ProductIn(ctx context.Context, record *models.InventoryRecord) error {
 	if record.ProductID == 0 || record.Quantity <= 0 {
 		return errors.New("产品ID和数量必须大于0")
 	}
 	record.Type = "in"
 
-	db := ctxDB(ctx)
+	return xdb.Transaction(ctx, func(ctx context.Context) error {
+		db := ctxDB(ctx)
 
-	if err := db.Create(record).Error; err != nil {
-		return err
-	}
+		var product models.Product
+		if err := db.First(&product, record.ProductID).Error; err != nil {
+			return err
+		}
 
-	var product models.Product
-	if err := db.First(&product, record.ProductID).Error; err != nil {
-		return err
-	}
+		if err := db.Create(record).Error; err != nil {
+			return err
+		}
 
-	product.Stock += record.Quantity
-	return db.Updates(&product).Error
+		return db.Model(&product).Update("stock", gorm.Expr("stock + ?", record.Quantity)).Error
+	})
 }
 
+// ProductOut 出库：校验库存后记录出库单并扣减库存，两步在同一事务中完成
+// 扣减时带上库存条件，并发出库时不会扣成负数
 func (s *inventoryService) ProductOut(ctx context.Context, record *models.InventoryRecord) error {
 	if record.ProductID == 0 || record.Quantity <= 0 {
 		return errors.New("产品ID和数量必须大于0")
 	}
 	record.Type = "out"
 
-	db := ctxDB(ctx)
-
-	var product models.Product
-	if err := db.First(&product, record.ProductID).Error; err != nil {
-		return err
-	}
-
-	if product.Stock < record.Quantity {
-		return errors.New("库存不足")
-	}
-
-	if err := db.Create(record).Error; err != nil {
-		return err
-	}
-
-	product.Stock -= record.Quantity
-	return db.Updates(&product).Error
+	return xdb.Transaction(ctx, func(ctx context.Context) error {
+		db := ctxDB(ctx)
+
+		var product models.Product
+		if err := db.First(&product, record.ProductID).Error; err != nil {
+			return err
+		}
+
+		if product.Stock < record.Quantity {
+			return errors.New("库存不足")
+		}
+
+		if err := db.Create(record).Error; err != nil {
+			return err
+		}
+
+		result := db.Model(&product).Where("stock >= ?", record.Quantity).
+			Update("stock", gorm.Expr("stock - ?", record.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("库存不足")
+		}
+		return nil
+	})
 }
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/xlog"
+)
+
+// loginFailureTTL 最近失败时间与锁定记录在缓存中的保留时间，仅用于清理；失败计数按 lockout.window 过期
+const loginFailureTTL = 24 * time.Hour
+
+// ErrIPBanned 同一 IP 登录失败次数过多，调用方应将该 IP 加入黑名单
+var ErrIPBanned = errors.New("登录失败次数过多，请求已被拒绝")
+
+// LoginBlockedError 账号被锁定或处于渐进延迟中，RetryAfter 后才能再次尝试
+type LoginBlockedError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("账号已锁定，请 %d 分钟后重试", int(math.Ceil(e.RetryAfter.Minutes())))
+	}
+	return fmt.Sprintf("登录过于频繁，请 %d 秒后重试", int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// LoginFailures 账号或 IP 的登录失败记录
+type LoginFailures struct {
+	Count       int       `json:"count"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until,omitempty"`
+}
+
+// LoginGuard 按账号记录登录失败，失败后渐进延迟，连续失败达到上限时临时锁定；同时按 IP 统计失败次数
+// 失败计数保存在缓存中，使用 redis 缓存时多实例共享；与 /auth 的 IP 限流、IP 黑名单叠加使用
+type LoginGuard interface {
+	// Check 登录前检查账号是否被锁定或处于延迟中，返回 *LoginBlockedError
+	Check(ctx context.Context, username string) error
+	// Fail 记录一次登录失败；该 IP 的失败次数达到上限时返回 ErrIPBanned
+	Fail(ctx context.Context, username, ip string) error
+	// Succeed 登录成功，清除账号的失败计数
+	Succeed(ctx context.Context, username string)
+	// Status 账号当前的失败记录
+	Status(ctx context.Context, username string) LoginFailures
+	// Unlock 管理员解除账号锁定并清除失败计数
+	Unlock(ctx context.Context, username string)
+}
+
+type loginGuard struct{}
+
+func NewLoginGuard() LoginGuard {
+	return &loginGuard{}
+}
+
+// 失败次数保存在计数器中，由 cache.Incr 原子递增，并发失败不会丢失计数；计数器每次失败后重新计时 lockout.window，
+// 窗口内没有新的失败即清零。最近失败时间只用于计算渐进延迟；锁定记录只由达到上限的那次失败写入
+var (
+	lastFailureCache  = cache.NewTyped[time.Time](cache.WithTTL(loginFailureTTL))
+	loginLockoutCache = cache.NewTyped[LoginFailures](cache.WithTTL(loginFailureTTL))
+)
+
+func userFailureKey(username string) string {
+	return cache.LoginFailurePrefix + "user:" + strings.ToLower(username)
+}
+
+func lastFailureKey(username string) string {
+	return cache.LoginFailurePrefix + "last:" + strings.ToLower(username)
+}
+
+func lockoutKey(username string) string {
+	return cache.LoginFailurePrefix + "lock:" + strings.ToLower(username)
+}
+
+func ipFailureKey(ip string) string {
+	return cache.LoginFailurePrefix + "ip:" + ip
+}
+
+// current 读取账号的失败记录，锁定期间返回锁定时的记录
+func (g *loginGuard) current(username string, now time.Time) LoginFailures {
+	if locked, found := loginLockoutCache.Get(lockoutKey(username)); found && now.Before(locked.LockedUntil) {
+		return locked
+	}
+	count := cache.GetCache().Counter(userFailureKey(username))
+	if count == 0 {
+		return LoginFailures{}
+	}
+	last, _ := lastFailureCache.Get(lastFailureKey(username))
+	return LoginFailures{Count: int(count), LastFailure: last}
+}
+
+func (g *loginGuard) Check(ctx context.Context, username string) error {
+	now := time.Now()
+	failures := g.current(username, now)
+	if now.Before(failures.LockedUntil) {
+		return &LoginBlockedError{Locked: true, RetryAfter: failures.LockedUntil.Sub(now)}
+	}
+	if next := failures.LastFailure.Add(loginDelay(failures.Count)); failures.Count > 0 && now.Before(next) {
+		return &LoginBlockedError{RetryAfter: next.Sub(now)}
+	}
+	return nil
+}
+
+// loginDelay 第 count 次失败后的等待时间：base_delay*2^(count-1)，不超过锁定时长
+func loginDelay(count int) time.Duration {
+	lockout := config.Get().Lockout
+	if count <= 0 || lockout.BaseDelay < 0 {
+		return 0
+	}
+	limit := time.Duration(lockout.LockDuration) * time.Minute
+	delay := time.Duration(lockout.BaseDelay) * time.Millisecond
+	for i := 1; i < count && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+func (g *loginGuard) Fail(ctx context.Context, username, ip string) error {
+	lockout := config.Get().Lockout
+	now := time.Now()
+	window := time.Duration(lockout.Window) * time.Minute
+
+	// 先记录失败时间再递增计数，Check 读到新的计数时延迟按本次失败计算
+	lastFailureCache.Set(lastFailureKey(username), now)
+	count := int(cache.GetCache().Incr(userFailureKey(username), window))
+	xlog.Warn("[SECURITY] 登录失败 Username=%s IP=%s Failures=%d", username, ip, count)
+	if lockout.MaxFailures > 0 && count >= lockout.MaxFailures {
+		// 锁定期满后重新计数
+		cache.GetCache().Delete(userFailureKey(username))
+		lockedUntil := now.Add(time.Duration(lockout.LockDuration) * time.Minute)
+		loginLockoutCache.Set(lockoutKey(username), LoginFailures{Count: count, LastFailure: now, LockedUntil: lockedUntil})
+		xlog.Warn("[SECURITY] 账号已锁定 Username=%s IP=%s Until=%s", username, ip, lockedUntil.Format(time.DateTime))
+	}
+
+	if ip == "" || lockout.IPMaxFailures < 0 {
+		return nil
+	}
+	ipKey := ipFailureKey(ip)
+	if ipCount := cache.GetCache().Incr(ipKey, window); ipCount >= int64(lockout.IPMaxFailures) {
+		xlog.Warn("[SECURITY] IP 登录失败次数过多 IP=%s Failures=%d", ip, ipCount)
+		cache.GetCache().Delete(ipKey)
+		return ErrIPBanned
+	}
+	return nil
+}
+
+func (g *loginGuard) Succeed(ctx context.Context, username string) {
+	g.clear(username)
+}
+
+func (g *loginGuard) Status(ctx context.Context, username string) LoginFailures {
+	return g.current(username, time.Now())
+}
+
+func (g *loginGuard) Unlock(ctx context.Context, username string) {
+	g.clear(username)
+	xlog.Warn("[SECURITY] 管理员解除账号锁定 Username=%s", username)
+}
+
+// clear 清除账号的失败计数与锁定
+func (g *loginGuard) clear(username string) {
+	cache.GetCache().Delete(userFailureKey(username))
+	lastFailureCache.Delete(lastFailureKey(username))
+	loginLockoutCache.Delete(lockoutKey(username))
+}
@@ -87,14 +87,18 @@ func (s *menuService) DeleteMenu(ctx context.Context, id int) error {
 	}
 
 	var childCount int64
-	if err := ctxDB(ctx).Model(&models.Menu{}).Where("parent_id = ?", id).Count(&childCount).Error; err != nil {
+	if err := ctxDB(ctx).Model(&models.Menu{}).Where("pid = ?", id).Count(&childCount).Error; err != nil {
 		return err
 	}
 	if childCount > 0 {
 		return errors.New("存在子菜单，无法删除")
 	}
 
-	return ctxDB(ctx).Delete(&models.Menu{}, id).Error
+	if err := ctxDB(ctx).Delete(&models.Menu{}, id).Error; err != nil {
+		return err
+	}
+	InvalidateMenuPermissionCache(ctx, id)
+	return nil
 }
 
 func (s *menuService) GetMenuByID(ctx context.Context, id int) (*models.Menu, error) {
@@ -154,7 +158,7 @@ func (s *menuService) GetUserMenus(ctx context.Context, userID int) ([]models.Me
 	}
 
 	isSuper := false
-	if user.Username == config.GlobalConfig.SuperAccount {
+	if user.Username == config.Get().SuperAccount {
 		isSuper = true
 	}
 
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"webgos/common/totp"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/mfakey"
+	"webgos/internal/models"
+	"webgos/internal/xlog"
+)
+
+const (
+	// mfaSkew 允许的时钟偏差（时间步），即接受前后各 30 秒的验证码
+	mfaSkew = 1
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// mfaChallengeMaxAttempts 同一挑战令牌最多提交错误验证码的次数，超过后需重新登录
+	mfaChallengeMaxAttempts = 5
+	// mfaChallengeTTL 挑战令牌在缓存中的保留时间，仅用于清理，是否过期按 ExpiresAt 判断
+	mfaChallengeTTL = time.Hour
+)
+
+var (
+	// ErrMFAAlreadyEnabled 已启用两步验证，需先关闭才能重新绑定
+	ErrMFAAlreadyEnabled = errors.New("已启用两步验证")
+	// ErrMFANotEnabled 未启用两步验证
+	ErrMFANotEnabled = errors.New("未启用两步验证")
+	// ErrMFANotSetup 尚未生成 TOTP 密钥
+	ErrMFANotSetup = errors.New("请先生成两步验证密钥")
+	// ErrInvalidMFACode 验证码或恢复码错误，或验证码已使用过
+	ErrInvalidMFACode = errors.New("验证码错误")
+	// ErrMFARequiredByRole 用户的角色要求两步验证，不能关闭
+	ErrMFARequiredByRole = errors.New("当前角色要求启用两步验证，不能关闭")
+	// ErrInvalidMFAChallenge 挑战令牌不存在、已过期或错误次数过多
+	ErrInvalidMFAChallenge = errors.New("两步验证已过期，请重新登录")
+)
+
+// MFAChallengeError 密码校验通过但还需要两步验证，客户端使用挑战令牌提交验证码完成登录
+type MFAChallengeError struct {
+	ChallengeToken string `json:"challengeToken"`
+	ExpiresIn      int64  `json:"expiresIn"`     // 挑战令牌有效秒数
+	SetupRequired  bool   `json:"setupRequired"` // 角色要求两步验证但用户尚未启用，需先通过 /auth/mfa/setup 绑定验证器
+}
+
+func (e *MFAChallengeError) Error() string {
+	if e.SetupRequired {
+		return "当前角色要求启用两步验证，请绑定验证器"
+	}
+	return "请输入两步验证码"
+}
+
+// MFASetup 待确认的 TOTP 密钥，secret 供手动输入，uri 供生成二维码
+type MFASetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFAStatus 用户的两步验证状态
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`               // 角色是否要求两步验证
+	RecoveryCodesRemaining int64 `json:"recoveryCodesRemaining"` // 未使用的恢复码数量
+}
+
+// MFAService 基于 TOTP（RFC 6238）的两步验证：生成密钥、确认启用、恢复码与关闭
+type MFAService interface {
+	Status(ctx context.Context, userID int) (*MFAStatus, error)
+	// Setup 生成新的待确认密钥，已启用时返回 ErrMFAAlreadyEnabled
+	Setup(ctx context.Context, userID int) (*MFASetup, error)
+	// Confirm 使用验证器生成的验证码确认密钥并启用两步验证，返回恢复码（仅此一次明文返回）
+	Confirm(ctx context.Context, userID int, code string) ([]string, error)
+	// Verify 校验验证码或恢复码，恢复码使用后作废
+	Verify(ctx context.Context, userID int, code string) error
+	// Disable 校验验证码后关闭两步验证，角色要求两步验证时返回 ErrMFARequiredByRole
+	Disable(ctx context.Context, userID int, code string) error
+	// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，原有恢复码全部作废
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+	// Reset 管理员重置用户的两步验证（丢失验证器时），用户下次登录按角色策略重新绑定
+	Reset(ctx context.Context, userID int) error
+	// Required 用户是否拥有要求两步验证的角色
+	Required(ctx context.Context, userID int) (bool, error)
+}
+
+type mfaService struct{}
+
+func NewMFAService() MFAService {
+	return &mfaService{}
+}
+
+func (s *mfaService) Status(ctx context.Context, userID int) (*MFAStatus, error) {
+	var user models.User
+	if err := ctxDB(ctx).Select("id", "mfa_enabled").Take(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	required, err := s.Required(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{Enabled: user.MFAEnabled, Required: required}
+	if user.MFAEnabled {
+		if err := ctxDB(ctx).Model(&models.MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).
+			Count(&status.RecoveryCodesRemaining).Error; err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+func (s *mfaService) Setup(ctx context.Context, userID int) (*MFASetup, error) {
+	var user models.User
+	if err := ctxDB(ctx).Take(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := mfakey.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	// 未启用前可反复生成，以最后一次为准
+	if err := ctxDB(ctx).Model(&models.User{}).Where("id = ? AND mfa_enabled = ?", userID, false).
+		Update("mfa_secret", sealed).Error; err != nil {
+		return nil, err
+	}
+	return &MFASetup{Secret: secret, URI: totp.URI(config.Get().MFA.Issuer, user.Username, secret)}, nil
+}
+
+func (s *mfaService) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	var user models.User
+	if err := ctxDB(ctx).Take(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrMFANotSetup
+	}
+	secret, err := mfakey.Open(user.MFASecret)
+	if err != nil {
+		return nil, err
+	}
+	if !verifyTOTP(user.ID, secret, code) {
+		return nil, ErrInvalidMFACode
+	}
+
+	var codes []string
+	err = ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("mfa_enabled", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateUserCache(ctx, userID)
+	xlog.Warn("[SECURITY] 启用两步验证 UserID=%d", userID)
+	return codes, nil
+}
+
+func (s *mfaService) Verify(ctx context.Context, userID int, code string) error {
+	var user models.User
+	if err := ctxDB(ctx).Select("id", "mfa_enabled", "mfa_secret").Take(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	// 密钥无法解密（如更换了 mfa.secret_key）时仍可使用恢复码
+	secret, err := mfakey.Open(user.MFASecret)
+	if err != nil {
+		xlog.Error("解密用户 %d 的两步验证密钥失败: %v", userID, err)
+	}
+	if verifyTOTP(user.ID, secret, code) {
+		return nil
+	}
+
+	// 非 6 位数字按恢复码处理，条件更新保证每个恢复码只能使用一次
+	result := ctxDB(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+	xlog.Warn("[SECURITY] 使用恢复码通过两步验证 UserID=%d", userID)
+	return nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID int, code string) error {
+	required, err := s.Required(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByRole
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := clearMFA(ctx, userID); err != nil {
+		return err
+	}
+	xlog.Warn("[SECURITY] 关闭两步验证 UserID=%d", userID)
+	return nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+func (s *mfaService) Reset(ctx context.Context, userID int) error {
+	if err := ctxDB(ctx).Take(&models.User{}, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if err := clearMFA(ctx, userID); err != nil {
+		return err
+	}
+	xlog.Warn("[SECURITY] 管理员重置两步验证 UserID=%d", userID)
+	return nil
+}
+
+func (s *mfaService) Required(ctx context.Context, userID int) (bool, error) {
+	var count int64
+	err := ctxDB(ctx).Model(&models.RBACRole{}).
+		Joins("JOIN rbac_user_roles ON rbac_user_roles.rbac_role_id = rbac_roles.id").
+		Where("rbac_user_roles.user_id = ? AND rbac_roles.require_mfa = ?", userID, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// clearMFA 清除用户的密钥与恢复码
+func clearMFA(ctx context.Context, userID int) error {
+	err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]any{"mfa_enabled": false, "mfa_secret": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	invalidateUserCache(ctx, userID)
+	return nil
+}
+
+// mfaStepTTL 已使用的时间步在缓存中的保留时间，覆盖验证码的全部有效期
+const mfaStepTTL = time.Duration(2*mfaSkew+1) * totp.Period * time.Second
+
+var mfaStepCache = cache.NewTyped[int64](cache.WithTTL(mfaStepTTL))
+
+// verifyTOTP 校验 TOTP 验证码；同一用户已通过的时间步及更早的验证码不能再次使用
+func verifyTOTP(userID int, secret, code string) bool {
+	if secret == "" {
+		return false
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), mfaSkew)
+	if !ok {
+		return false
+	}
+	key := cache.MFAStepPrefix + strconv.Itoa(userID)
+	if last, found := mfaStepCache.Get(key); found && step <= last {
+		return false
+	}
+	// 并发提交同一验证码时只有第一个请求递增到 1，其余按重放拒绝
+	if cache.GetCache().Incr(key+":"+strconv.FormatInt(step, 10), mfaStepTTL) != 1 {
+		return false
+	}
+	mfaStepCache.Set(key, step)
+	return true
+}
+
+// replaceRecoveryCodes 删除用户原有的恢复码并生成新的一组，返回明文
+func replaceRecoveryCodes(tx *gorm.DB, userID int) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.MFARecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = models.MFARecoveryCode{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// randomRecoveryCode 生成 80 位随机的恢复码，格式 xxxx-xxxx-xxxx-xxxx
+func randomRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(recoveryEncoding.EncodeToString(b))
+	return s[0:4] + "-" + s[4:8] + "-" + s[8:12] + "-" + s[12:16], nil
+}
+
+// normalizeRecoveryCode 忽略恢复码的大小写、空格与连字符
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// mfaChallenge 密码校验通过后等待两步验证的登录
+type mfaChallenge struct {
+	UserID        int       `json:"user_id"`
+	Username      string    `json:"username"`
+	SetupRequired bool      `json:"setup_required"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+var mfaChallengeCache = cache.NewTyped[mfaChallenge](cache.WithTTL(mfaChallengeTTL))
+
+func mfaChallengeKey(token string) string {
+	return cache.MFAChallengePrefix + hashToken(token)
+}
+
+// mfaAttemptsKey 挑战令牌的验证次数计数器，提交验证码前递增，并发提交也不能超过 mfaChallengeMaxAttempts 次
+func mfaAttemptsKey(token string) string {
+	return mfaChallengeKey(token) + ":attempts"
+}
+
+// newMFAChallenge 为密码校验通过的用户创建挑战令牌；带有用户标签，用户被禁用或修改密码时一并失效
+func newMFAChallenge(user *models.User, setupRequired bool) (*MFAChallengeError, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(config.Get().MFA.ChallengeExpiry) * time.Minute
+	mfaChallengeCache.SetWithTags(mfaChallengeKey(token), mfaChallenge{
+		UserID:        user.ID,
+		Username:      user.Username,
+		SetupRequired: setupRequired,
+		ExpiresAt:     time.Now().Add(ttl),
+	}, cache.Tag(cache.TagUser, user.ID))
+	return &MFAChallengeError{ChallengeToken: token, ExpiresIn: int64(ttl / time.Second), SetupRequired: setupRequired}, nil
+}
+
+// loadMFAChallenge 读取未过期的挑战
+func loadMFAChallenge(token string) (mfaChallenge, error) {
+	challenge, found := mfaChallengeCache.Get(mfaChallengeKey(token))
+	if !found || time.Now().After(challenge.ExpiresAt) || cache.GetCache().Counter(mfaAttemptsKey(token)) >= mfaChallengeMaxAttempts {
+		return mfaChallenge{}, ErrInvalidMFAChallenge
+	}
+	return challenge, nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/models"
+	"webgos/internal/pwdpolicy"
+	"webgos/internal/xlog"
+)
+
+// passwordChangeTTL 密码过期时修改密码令牌的有效期
+const passwordChangeTTL = 10 * time.Minute
+
+var (
+	// ErrPasswordReused 新密码与最近使用过的密码相同
+	ErrPasswordReused = errors.New("不能使用最近使用过的密码")
+	// ErrWrongPassword 修改密码时原密码错误
+	ErrWrongPassword = errors.New("原密码错误")
+	// ErrInvalidPasswordChange 修改密码令牌不存在或已过期
+	ErrInvalidPasswordChange = errors.New("修改密码已过期，请重新登录")
+)
+
+// PasswordExpiredError 登录校验通过但密码已超过 password_policy.max_age，客户端使用令牌设置新密码后完成登录
+type PasswordExpiredError struct {
+	ChangeToken string `json:"changeToken"`
+	ExpiresIn   int64  `json:"expiresIn"` // 修改密码令牌有效秒数
+	// RecoveryCodes 本次登录中首次启用两步验证生成的恢复码，仅此一次返回
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+func (e *PasswordExpiredError) Error() string {
+	return "密码已过期，请修改密码"
+}
+
+// checkPassword 按密码策略校验 user 的新密码；配置了 password_policy.history 时不能与最近使用过的密码相同
+// user.Password 为当前密码的哈希（新用户为空），一并比较，兼容启用密码历史之前设置的密码
+func checkPassword(db *gorm.DB, user *models.User, password string) error {
+	if err := pwdpolicy.Current().Check(password, user.Username); err != nil {
+		return fmt.Errorf("密码%w", err)
+	}
+	history := config.Get().PasswordPolicy.History
+	if history <= 0 || user.ID == 0 {
+		return nil
+	}
+	var hashes []string
+	if err := db.Model(&models.PasswordHistory{}).Where("user_id = ?", user.ID).
+		Order("id DESC").Limit(history).Pluck("password_hash", &hashes).Error; err != nil {
+		return err
+	}
+	if user.Password != "" {
+		hashes = append(hashes, user.Password)
+	}
+	for _, hash := range hashes {
+		if (&models.User{Password: hash}).CheckPassword(password) {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// recordPassword 记录新设置的密码哈希，只保留最近 password_policy.history 条；未启用密码历史时不记录
+func recordPassword(db *gorm.DB, userID int, hash string) error {
+	history := config.Get().PasswordPolicy.History
+	if history <= 0 {
+		return nil
+	}
+	if err := db.Create(&models.PasswordHistory{UserID: userID, PasswordHash: hash}).Error; err != nil {
+		return err
+	}
+	var ids []int
+	if err := db.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).
+		Order("id DESC").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) <= history {
+		return nil
+	}
+	return db.Where("id IN ?", ids[history:]).Delete(&models.PasswordHistory{}).Error
+}
+
+// passwordExpired 密码是否已超过 password_policy.max_age 天未修改
+func passwordExpired(user *models.User) bool {
+	maxAge := config.Get().PasswordPolicy.MaxAge
+	if maxAge <= 0 || user.PasswordChangedAt == nil {
+		return false
+	}
+	return time.Since(*user.PasswordChangedAt) > time.Duration(maxAge)*24*time.Hour
+}
+
+// setPassword 校验并设置用户密码，写入密码历史；user 需包含 ID、Username 与当前密码哈希
+func setPassword(db *gorm.DB, user *models.User, password string) error {
+	if err := checkPassword(db, user, password); err != nil {
+		return err
+	}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	if err := db.Model(user).Updates(map[string]any{
+		"password":            user.Password,
+		"password_changed_at": user.PasswordChangedAt,
+	}).Error; err != nil {
+		return err
+	}
+	return recordPassword(db, user.ID, user.Password)
+}
+
+// passwordChange 密码过期、等待修改密码的登录
+type passwordChange struct {
+	UserID        int      `json:"user_id"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+var passwordChangeCache = cache.NewTyped[passwordChange](cache.WithTTL(passwordChangeTTL))
+
+func passwordChangeKey(token string) string {
+	return cache.PasswordChangePrefix + hashToken(token)
+}
+
+// newPasswordChange 为密码已过期的用户创建修改密码令牌；带有用户标签，用户被禁用或修改密码时一并失效
+func newPasswordChange(user *models.User, recoveryCodes []string) (*PasswordExpiredError, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	passwordChangeCache.SetWithTags(passwordChangeKey(token), passwordChange{UserID: user.ID, RecoveryCodes: recoveryCodes},
+		cache.Tag(cache.TagUser, user.ID))
+	xlog.Warn("[SECURITY] 密码已过期，要求修改密码 UserID=%d", user.ID)
+	return &PasswordExpiredError{ChangeToken: token, ExpiresIn: int64(passwordChangeTTL / time.Second), RecoveryCodes: recoveryCodes}, nil
+}
+
+// ChangePassword 校验原密码后修改密码，注销该用户除当前会话外的所有会话并删除其 API 密钥
+func (s *userService) ChangePassword(ctx context.Context, userID int, sessionID, oldPassword, newPassword string) error {
+	var user models.User
+	if err := ctxDB(ctx).Take(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if !user.CheckPassword(oldPassword) {
+		return ErrWrongPassword
+	}
+	if oldPassword == newPassword {
+		return ErrPasswordReused
+	}
+	if err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, &user, newPassword)
+	}); err != nil {
+		return err
+	}
+
+	xlog.Warn("[SECURITY] 修改密码 UserID=%d", userID)
+	// 当前会话的访问令牌随版本递增失效，可使用刷新令牌换取新令牌
+	if err := bumpTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := NewSessionService().RevokeAll(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	_, err := NewAPIKeyService().RevokeAll(ctx, userID)
+	return err
+}
+
+// ChangeExpiredPassword 使用修改密码令牌设置新密码，完成登录并注销该用户之前的所有会话、删除其 API 密钥
+func (s *authService) ChangeExpiredPassword(ctx context.Context, changeToken, password string, client ClientInfo) (*TokenPair, error) {
+	key := passwordChangeKey(changeToken)
+	change, found := passwordChangeCache.Get(key)
+	if !found {
+		return nil, ErrInvalidPasswordChange
+	}
+	var user models.User
+	if err := ctxDB(ctx).Take(&user, change.UserID).Error; err != nil {
+		return nil, ErrInvalidPasswordChange
+	}
+	if user.Status != models.UserStatusEnabled {
+		return nil, ErrAccountDisabled
+	}
+	// 未启用密码历史时也不能沿用已过期的密码
+	if user.CheckPassword(password) {
+		return nil, ErrPasswordReused
+	}
+	if err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, &user, password)
+	}); err != nil {
+		return nil, err
+	}
+	passwordChangeCache.Delete(key)
+
+	xlog.Warn("[SECURITY] 修改已过期的密码 UserID=%d", user.ID)
+	if err := revokeUserCredentials(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	// 令牌版本已递增，重新读取后签发
+	if err := ctxDB(ctx).Take(&user, user.ID).Error; err != nil {
+		return nil, err
+	}
+	pair, err := s.startSession(ctx, &user, client)
+	if err != nil {
+		return nil, err
+	}
+	pair.RecoveryCodes = change.RecoveryCodes
+	return pair, nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/mail"
+	"webgos/internal/models"
+	"webgos/internal/xlog"
+)
+
+// passwordResetInterval 同一用户两次发送重置邮件的最小间隔
+const passwordResetInterval = time.Minute
+
+// ErrInvalidResetToken 重置令牌不存在、已过期或已使用
+var ErrInvalidResetToken = errors.New("重置链接无效或已过期")
+
+// ErrPasswordResetUnavailable 未配置邮件发送方式，无法发送重置链接
+var ErrPasswordResetUnavailable = errors.New("未配置邮件发送，找回密码不可用")
+
+// PasswordResetService 通过邮件中的一次性令牌自助重置密码
+type PasswordResetService interface {
+	// Request 按用户名或邮箱申请重置，向账号邮箱发送重置链接
+	// 账号不存在、未设置邮箱、已禁用或发送过于频繁时同样返回 nil，不泄露账号是否存在；
+	// 未配置邮件发送方式时返回 ErrPasswordResetUnavailable，不生成令牌
+	Request(ctx context.Context, account string, client ClientInfo) error
+	// Reset 使用令牌设置新密码，注销该用户的全部会话并清除登录失败计数
+	Reset(ctx context.Context, token, password string) error
+}
+
+type passwordResetService struct{}
+
+func NewPasswordResetService() PasswordResetService {
+	return &passwordResetService{}
+}
+
+var passwordResetThrottle = cache.NewTyped[bool](cache.WithTTL(passwordResetInterval))
+
+func (s *passwordResetService) Request(ctx context.Context, account string, client ClientInfo) error {
+	// 先于查询账号检查，所有账号得到相同的结果
+	if _, err := mail.Get(); err != nil {
+		if errors.Is(err, mail.ErrNotConfigured) {
+			return ErrPasswordResetUnavailable
+		}
+		return err
+	}
+	account = strings.TrimSpace(account)
+	var users []models.User
+	if err := ctxDB(ctx).Where("username = ? OR (email = ? AND email <> '')", account, account).Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Email == "" || user.Status != models.UserStatusEnabled {
+			continue
+		}
+		throttleKey := cache.PasswordResetPrefix + strconv.Itoa(user.ID)
+		if _, found := passwordResetThrottle.Get(throttleKey); found {
+			continue
+		}
+		passwordResetThrottle.Set(throttleKey, true)
+
+		token, err := s.issue(ctx, user.ID, client.IP)
+		if err != nil {
+			return err
+		}
+		xlog.Warn("[SECURITY] 申请重置密码 UserID=%d IP=%s", user.ID, client.IP)
+		msg := resetMessage(&user, token)
+		// 异步发送，响应时间不随账号是否存在而变化
+		go func() {
+			if err := mail.Send(context.Background(), msg); err != nil {
+				xlog.Error("发送重置密码邮件失败 UserID=%d: %v", user.ID, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// issue 生成新的重置令牌，该用户之前未使用的令牌随即作废
+func (s *passwordResetService) issue(ctx context.Context, userID int, ip string) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).Where("user_id = ? AND used_at IS NULL", userID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		// 顺带清理已过期的令牌
+		if err := tx.Where("user_id = ? AND expires_at < ?", userID, now).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    userID,
+			TokenHash: hashToken(token),
+			IP:        ip,
+			ExpiresAt: now.Add(time.Duration(config.Get().PasswordReset.TokenExpiry) * time.Minute),
+		}).Error
+	})
+	return token, err
+}
+
+// resetMessage 重置密码邮件；未配置 password_reset.url 时邮件中只包含令牌
+func resetMessage(user *models.User, token string) mail.Message {
+	cfg := config.Get().PasswordReset
+	link := "重置令牌：" + token
+	if cfg.URL != "" {
+		sep := "?"
+		if strings.Contains(cfg.URL, "?") {
+			sep = "&"
+		}
+		link = cfg.URL + sep + "token=" + url.QueryEscape(token)
+	}
+	name := user.Nickname
+	if name == "" {
+		name = user.Username
+	}
+	return mail.Message{
+		To:      []string{user.Email},
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，您好：\n\n我们收到了重置账号 %s 密码的申请，请在 %d 分钟内使用以下链接设置新密码，链接只能使用一次：\n\n%s\n\n如果不是您本人操作，请忽略本邮件，您的密码不会改变。\n",
+			name, user.Username, cfg.TokenExpiry, link),
+	}
+}
+
+func (s *passwordResetService) Reset(ctx context.Context, token, password string) error {
+	var user models.User
+	err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		var record models.PasswordResetToken
+		if err := tx.Where("token_hash = ?", hashToken(token)).Take(&record).Error; err != nil {
+			return ErrInvalidResetToken
+		}
+		now := time.Now()
+		if record.UsedAt != nil || now.After(record.ExpiresAt) {
+			return ErrInvalidResetToken
+		}
+		// 条件更新防止并发请求重复使用同一令牌
+		result := tx.Model(&models.PasswordResetToken{}).Where("id = ? AND used_at IS NULL", record.ID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+		if err := tx.Model(&models.PasswordResetToken{}).Where("user_id = ? AND used_at IS NULL", record.UserID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, record.UserID).Error; err != nil || user.Status != models.UserStatusEnabled {
+			return ErrInvalidResetToken
+		}
+		return setPassword(tx, &user, password)
+	})
+	if err != nil {
+		return err
+	}
+
+	xlog.Warn("[SECURITY] 通过邮件重置密码 UserID=%d", user.ID)
+	// 能收到邮件即证明账号归属，解除因密码错误导致的锁定
+	NewLoginGuard().Succeed(ctx, user.Username)
+	return revokeUserCredentials(ctx, user.ID)
+}
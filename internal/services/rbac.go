@@ -3,7 +3,6 @@ package services
 import (
 	"context"
 	"errors"
-	"strconv"
 
 	"webgos/internal/cache"
 	"webgos/internal/dto"
@@ -34,9 +33,10 @@ func NewRBACService() RBACService {
 
 func (s *rbacService) AddRole(ctx context.Context, dtoModel dto.AddRoleDTO) (*models.RBACRole, error) {
 	role := &models.RBACRole{
-		Name:   dtoModel.Name,
-		Remark: dtoModel.Remark,
-		Status: dtoModel.Status,
+		Name:       dtoModel.Name,
+		Remark:     dtoModel.Remark,
+		Status:     dtoModel.Status,
+		RequireMFA: dtoModel.RequireMFA,
 	}
 
 	if err := ctxDB(ctx).Create(role).Error; err != nil {
@@ -67,9 +67,14 @@ func (s *rbacService) EditRole(ctx context.Context, dtoModel dto.EditRoleDTO) er
 	if dtoModel.Status != nil {
 		role.Status = *dtoModel.Status
 	}
+	if dtoModel.RequireMFA != nil {
+		role.RequireMFA = *dtoModel.RequireMFA
+	}
 	if err := ctxDB(ctx).Select("*").Updates(&role).Error; err != nil {
 		return err
 	}
+	// 用户分页中包含角色信息
+	InvalidateRolePermissionCache(ctx, role.ID)
 
 	if dtoModel.MenuIDs != nil {
 		if err := s.AssignMenusToRole(ctx, role.ID, dtoModel.MenuIDs); err != nil {
@@ -94,14 +99,22 @@ func (s *rbacService) AssignRolesToUser(ctx context.Context, userID int, roleIDs
 		return errors.New("部分角色不存在")
 	}
 
+	var current []int
+	if err := ctxDB(ctx).Model(&models.RBACUserRole{}).Where("user_id = ?", userID).Pluck("rbac_role_id", &current).Error; err != nil {
+		return err
+	}
+
 	if err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
 		return tx.Model(&user).Association("Roles").Replace(roles)
 	}); err != nil {
 		return err
 	}
 
-	// 角色变更后失效该用户的权限缓存
+	// 角色变更后失效该用户的权限缓存，并要求其刷新访问令牌
 	InvalidateUserPermissionCache(ctx, userID)
+	if !sameIDs(current, roleIDs) {
+		return bumpTokenVersion(ctx, userID)
+	}
 	return nil
 }
 
@@ -218,7 +231,11 @@ func (s *rbacService) DeletePermission(ctx context.Context, id int) error {
 		return errors.New("权限不存在")
 	}
 
+	var menus []models.Menu
 	if err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&permission).Association("Menus").Find(&menus); err != nil {
+			return err
+		}
 		// 清除权限点-菜单关联
 		if err := tx.Model(&permission).Association("Menus").Clear(); err != nil {
 			return err
@@ -227,28 +244,39 @@ func (s *rbacService) DeletePermission(ctx context.Context, id int) error {
 	}); err != nil {
 		return err
 	}
+	for _, menu := range menus {
+		InvalidateMenuPermissionCache(ctx, menu.ID)
+	}
 	return nil
 }
 
-// InvalidateUserPermissionCache 失效指定用户的权限缓存
+// InvalidateUserPermissionCache 失效指定用户的权限缓存，以及其他带有该用户标签的缓存（如包含该用户的分页）
 func InvalidateUserPermissionCache(ctx context.Context, userID int) {
-	cache.GetCache().Delete(cache.PermissionPrefix + ":" + strconv.Itoa(userID))
+	invalidateTags(ctx, cache.Tag(cache.TagUser, userID))
 }
 
-// InvalidateRolePermissionCache 失效拥有指定角色的所有用户的权限缓存
+// InvalidateRolePermissionCache 失效拥有指定角色的所有用户的权限缓存（按角色标签，无需查询角色下的用户）
 func InvalidateRolePermissionCache(ctx context.Context, roleID int) {
-	var userIDs []int
-	ctxDB(ctx).Table("rbac_user_roles").Where("rbac_role_id = ?", roleID).Pluck("user_id", &userIDs)
-	for _, uid := range userIDs {
-		InvalidateUserPermissionCache(ctx, uid)
-	}
+	invalidateTags(ctx, cache.Tag(cache.TagRole, roleID))
 }
 
-// InvalidateMenuPermissionCache 失效绑定了指定菜单的角色下所有用户的权限缓存
+// InvalidateMenuPermissionCache 失效绑定了指定菜单的角色下所有用户的权限缓存（按菜单标签）
 func InvalidateMenuPermissionCache(ctx context.Context, menuID int) {
-	var roleIDs []int
-	ctxDB(ctx).Table("rbac_role_menus").Where("menu_id = ?", menuID).Pluck("rbac_role_id", &roleIDs)
-	for _, rid := range roleIDs {
-		InvalidateRolePermissionCache(ctx, rid)
+	invalidateTags(ctx, cache.Tag(cache.TagMenu, menuID))
+}
+
+// sameIDs 两组 ID 是否相同（忽略顺序与重复）
+func sameIDs(a, b []int) bool {
+	set := make(map[int]bool, len(a))
+	for _, id := range a {
+		set[id] = true
+	}
+	other := make(map[int]bool, len(b))
+	for _, id := range b {
+		if !set[id] {
+			return false
+		}
+		other[id] = true
 	}
+	return len(set) == len(other)
 }
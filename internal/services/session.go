@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"webgos/internal/cache"
+	"webgos/internal/models"
+	"webgos/internal/xlog"
+)
+
+// sessionSeenInterval 会话最近活跃时间的更新间隔，避免每个请求都写数据库
+const sessionSeenInterval = time.Minute
+
+// ErrSessionNotFound 会话不存在或不属于当前用户
+var ErrSessionNotFound = errors.New("会话不存在")
+
+// ClientInfo 发起登录或刷新的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type SessionService interface {
+	// List 用户未注销且未过期的会话，按最近活跃时间倒序；currentID 对应的会话标记为当前会话
+	List(ctx context.Context, userID int, currentID string) ([]models.Session, error)
+	// Revoke 注销用户的指定会话
+	Revoke(ctx context.Context, userID int, sessionID string) error
+	// RevokeAll 注销用户的所有会话，exceptID 不为空时保留该会话，返回注销的会话数
+	RevokeAll(ctx context.Context, userID int, exceptID string) (int, error)
+	// Touch 更新会话最近活跃时间与 IP，同一会话每分钟最多写一次数据库
+	Touch(ctx context.Context, sessionID, ip string)
+}
+
+type sessionService struct{}
+
+func NewSessionService() SessionService {
+	return &sessionService{}
+}
+
+func (s *sessionService) List(ctx context.Context, userID int, currentID string) ([]models.Session, error) {
+	var sessions []models.Session
+	err := activeSessions(ctxSDB(ctx), time.Now()).
+		Where("user_id = ?", userID).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, err
+}
+
+func (s *sessionService) Revoke(ctx context.Context, userID int, sessionID string) error {
+	var count int64
+	if err := activeSessions(ctxDB(ctx), time.Now()).Model(&models.Session{}).
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSessionNotFound
+	}
+	return revokeSessions(ctx, sessionID)
+}
+
+func (s *sessionService) RevokeAll(ctx context.Context, userID int, exceptID string) (int, error) {
+	var ids []string
+	query := activeSessions(ctxDB(ctx), time.Now()).Model(&models.Session{}).Where("user_id = ?", userID)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if err := revokeSessions(ctx, ids...); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+func (s *sessionService) Touch(ctx context.Context, sessionID, ip string) {
+	key := cache.SessionSeenPrefix + sessionID
+	if _, found := cache.GetCache().Get(key); found {
+		return
+	}
+	cache.GetCache().Set(key, true, sessionSeenInterval)
+	if err := ctxDB(ctx).Model(&models.Session{}).Where("id = ?", sessionID).
+		Updates(map[string]any{"last_seen_at": time.Now(), "ip": ip}).Error; err != nil {
+		xlog.Error("更新会话 %s 活跃时间失败: %v", sessionID, err)
+	}
+}
+
+// activeSessions 未注销且未过期的会话
+func activeSessions(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("revoked_at IS NULL AND expires_at > ?", now)
+}
+
+// sessionActive 会话是否仍然有效，缓存中的访问令牌丢失（重启、淘汰）时据此恢复
+func sessionActive(ctx context.Context, sessionID string) bool {
+	var count int64
+	if err := activeSessions(ctxDB(ctx), time.Now()).Model(&models.Session{}).
+		Where("id = ?", sessionID).Count(&count).Error; err != nil {
+		xlog.Error("查询会话 %s 失败: %v", sessionID, err)
+		return false
+	}
+	return count > 0
+}
+
+// enforceMaxSessions 用户的有效会话达到上限时，注销最久未活跃的会话，为新登录腾出一个位置
+// 无法腾出位置时返回错误，由调用方拒绝本次登录
+func enforceMaxSessions(ctx context.Context, userID, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	var ids []string
+	if err := activeSessions(ctxDB(ctx), time.Now()).Model(&models.Session{}).
+		Where("user_id = ?", userID).
+		Order("last_seen_at DESC").
+		Offset(max-1).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	return revokeSessions(ctx, ids...)
+}
+
+// revokeSessions 注销会话及其刷新令牌，并使其访问令牌失效
+// 先更新数据库再失效缓存，避免缓存未命中时按数据库恢复已注销的访问令牌；
+// 会话与刷新令牌在同一事务中注销，失败时返回错误且不失效缓存
+func revokeSessions(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	now := time.Now()
+	if err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).
+			Where("id IN ? AND revoked_at IS NULL", ids).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id IN ? AND revoked_at IS NULL", ids).
+			Update("revoked_at", now).Error
+	}); err != nil {
+		return err
+	}
+	tags := make([]string, len(ids))
+	for i, id := range ids {
+		tags[i] = sessionTag(id)
+	}
+	invalidateTags(ctx, tags...)
+	return nil
+}
+
+func sessionTag(sessionID string) string {
+	return cache.TagSession + ":" + sessionID
+}
@@ -4,15 +4,22 @@ import (
 	"context"
 	"errors"
 
+	"gorm.io/gorm"
+
 	"webgos/internal/cache"
 	"webgos/internal/dto"
 	"webgos/internal/models"
+	"webgos/internal/xdb"
 	"webgos/internal/xlog"
 )
 
 type UserService interface {
 	CreateOrUpdateUser(ctx context.Context, user *models.User) error
 	ResetPassword(ctx context.Context, username, password string) error
+	// ChangePassword 用户修改自己的密码，保留当前会话
+	ChangePassword(ctx context.Context, userID int, sessionID, oldPassword, newPassword string) error
+	// SetUserStatus 修改用户状态，状态变化时使其访问令牌失效，禁用时同时注销其所有会话
+	SetUserStatus(ctx context.Context, userID, status int) error
 	UsersPage(ctx context.Context, query dto.UserQuery) ([]models.User, int64)
 	GetUserInfo(ctx context.Context, userID int) (*models.User, error)
 }
@@ -24,19 +31,73 @@ func NewUserService() UserService {
 }
 
 func (s *userService) CreateOrUpdateUser(ctx context.Context, user *models.User) error {
+	db := ctxDB(ctx)
+
 	if user.Password != "" {
+		// 按密码策略校验，更新时与当前密码及密码历史比较
+		current := models.User{Username: user.Username}
+		if user.ID > 0 {
+			if err := db.Select("id", "username", "password").Take(&current, user.ID).Error; err != nil {
+				return errors.New("用户不存在")
+			}
+			if user.Username != "" {
+				current.Username = user.Username
+			}
+		}
+		if err := checkPassword(db, &current, user.Password); err != nil {
+			return err
+		}
 		if err := user.SetPassword(user.Password); err != nil {
 			return err
 		}
 	}
 
-	db := ctxDB(ctx)
-
 	if user.ID > 0 {
-		return db.Updates(user).Error
+		if err := db.Updates(user).Error; err != nil {
+			return err
+		}
+		invalidateUserCache(ctx, user.ID)
+		// 修改密码后已登录的会话全部失效
+		if user.Password != "" {
+			if err := recordPassword(db, user.ID, user.Password); err != nil {
+				return err
+			}
+			return revokeUserCredentials(ctx, user.ID)
+		}
+		return nil
 	}
 
-	return db.Create(user).Error
+	if err := db.Create(user).Error; err != nil {
+		return err
+	}
+	if user.Password != "" {
+		if err := recordPassword(db, user.ID, user.Password); err != nil {
+			return err
+		}
+	}
+	// 新用户影响所有分页的总数
+	invalidateTags(ctx, cache.TagUsers)
+	return nil
+}
+
+// invalidateTags 失效带有任一标签的缓存；ctx 中有事务时在提交后执行，
+// 避免提交前被其他请求以旧数据重新加载，或在回滚后留下未提交的数据
+func invalidateTags(ctx context.Context, tags ...string) {
+	xdb.AfterCommit(ctx, func() {
+		cache.GetCache().InvalidateTags(tags...)
+	})
+}
+
+// invalidateUserCache 失效带有指定用户标签的缓存（权限缓存、包含这些用户的分页）
+func invalidateUserCache(ctx context.Context, userIDs ...int) {
+	if len(userIDs) == 0 {
+		return
+	}
+	tags := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		tags = append(tags, cache.Tag(cache.TagUser, id))
+	}
+	invalidateTags(ctx, tags...)
 }
 
 func (s *userService) ResetPassword(ctx context.Context, username, password string) error {
@@ -46,34 +107,82 @@ func (s *userService) ResetPassword(ctx context.Context, username, password stri
 		return errors.New("用户不存在")
 	}
 
-	if err := user.SetPassword(password); err != nil {
+	if err := ctxDB(ctx).Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, &user, password)
+	}); err != nil {
 		return err
 	}
-
-	return ctxDB(ctx).Model(&user).Update("Password", user.Password).Error
+	return revokeUserCredentials(ctx, user.ID)
 }
 
-func (s *userService) UsersPage(ctx context.Context, query dto.UserQuery) (users []models.User, total int64) {
-	cacheKey := cache.GenerateKey(cache.UserPagePrefix, query)
-	if cache.GetPage(cacheKey, &users, &total) {
-		return users, total
+func (s *userService) SetUserStatus(ctx context.Context, userID, status int) error {
+	result := ctxDB(ctx).Model(&models.User{}).Where("id = ? AND status <> ?", userID, status).Update("status", status)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	if status != models.UserStatusEnabled {
+		return revokeUserTokens(ctx, userID)
 	}
+	return bumpTokenVersion(ctx, userID)
+}
 
-	db := ctxSDB(ctx).Model(&models.User{})
+// revokeUserTokens 使用户的访问令牌失效并注销其所有会话，需重新登录
+func revokeUserTokens(ctx context.Context, userID int) error {
+	if err := bumpTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+	_, err := NewSessionService().RevokeAll(ctx, userID, "")
+	return err
+}
 
-	if query.Username != "" {
-		db = db.Where("username LIKE ?", "%"+query.Username+"%")
+// revokeUserCredentials 在 revokeUserTokens 的基础上删除用户的全部 API 密钥，用于重置或修改密码：
+// 密码可能已泄露，用它创建的密钥同样不可信。禁用用户时密钥已无法使用，不删除，重新启用后恢复
+func revokeUserCredentials(ctx context.Context, userID int) error {
+	if err := revokeUserTokens(ctx, userID); err != nil {
+		return err
 	}
-	if err := db.Count(&total).Error; err != nil {
+	_, err := NewAPIKeyService().RevokeAll(ctx, userID)
+	return err
+}
+
+// userPageCache 用户分页缓存，带有用户集合及页内用户、角色、部门的标签，相关数据变更时按标签失效
+var userPageCache = cache.NewTyped[cache.Page[models.User]]()
+
+func (s *userService) UsersPage(ctx context.Context, query dto.UserQuery) (users []models.User, total int64) {
+	cacheKey := cache.GenerateKey(cache.UserPagePrefix, query)
+	page, err := userPageCache.GetOrLoadTagged(ctx, cacheKey, func(ctx context.Context) (cache.Page[models.User], []string, error) {
+		var page cache.Page[models.User]
+		db := ctxSDB(ctx).Model(&models.User{})
+
+		if query.Username != "" {
+			db = db.Where("username LIKE ?", "%"+query.Username+"%")
+		}
+		if err := db.Count(&page.Total).Error; err != nil {
+			return page, nil, err
+		}
+		db = db.Scopes(models.Page(query.Page, query.PageSize))
+		err := db.Preload("Roles").Find(&page.Items).Error
+		return page, userPageTags(page.Items), err // 仅成功才缓存
+	})
+	if err != nil {
 		xlog.Error("user page error:%v", err)
-		return users, total
 	}
-	db = db.Scopes(models.Page(query.Page, query.PageSize))
-	if err := db.Preload("Roles").Find(&users).Error; err != nil {
-		return users, total
+	return page.Items, page.Total
+}
+
+// userPageTags 用户分页依赖的数据标签
+func userPageTags(users []models.User) []string {
+	tags := []string{cache.TagUsers}
+	for _, user := range users {
+		tags = append(tags, cache.Tag(cache.TagUser, user.ID))
+		if user.DepartmentID != 0 {
+			tags = append(tags, cache.Tag(cache.TagDepartment, user.DepartmentID))
+		}
+		for _, role := range user.Roles {
+			tags = append(tags, cache.Tag(cache.TagRole, role.ID))
+		}
 	}
-	cache.SetPage(cacheKey, users, total) // 仅成功才缓存
-	return users, total
+	return tags
 }
 
 func (s *userService) GetUserInfo(ctx context.Context, userID int) (*models.User, error) {
@@ -4,4 +4,10 @@ package code
 const (
 	OK    = 0
 	Error = 1
+	// LoginBlocked 账号已锁定或登录过于频繁，响应头 Retry-After 为需等待的秒数
+	LoginBlocked = 1001
+	// MFARequired 密码正确但需要两步验证，data 中的挑战令牌用于提交验证码
+	MFARequired = 1002
+	// PasswordExpired 密码已过期，data 中的令牌用于设置新密码后完成登录
+	PasswordExpired = 1003
 )
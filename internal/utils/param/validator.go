@@ -7,6 +7,8 @@ import (
 	"strings"
 	"sync"
 
+	"webgos/internal/pwdpolicy"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
@@ -88,6 +90,19 @@ func addDefaultValidations(v *validator.Validate) {
 		match, _ := regexp.MatchString(`^1[3-9]\d{9}$`, phone)
 		return match
 	})
+	// 注册自定义验证规则：密码策略，结构体中有 Username 字段时同时检查密码是否包含用户名
+	v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		password := fl.Field().String()
+		if password == "" {
+			return true // 允许空值（配合omitempty使用）
+		}
+		username := ""
+		structValue := reflect.Indirect(reflect.ValueOf(fl.Top().Interface()))
+		if field := structValue.FieldByName("Username"); field.IsValid() && field.Kind() == reflect.String {
+			username = field.String()
+		}
+		return pwdpolicy.Current().Check(password, username) == nil
+	})
 	// 在验证器中注册自定义验证函数，设置runValidationOnNil=true确保在nil字段上执行验证
 	// 确保被验证字段加上required_if_add,omitnil标签，否则后续验证规则会报错，如果没有后续验证规则可以不加
 	v.RegisterValidation("required_if_add", func(fl validator.FieldLevel) bool {
@@ -151,6 +166,12 @@ func getValidationMessage(e validator.FieldError) string {
 		return "手机号格式不正确"
 	case "email":
 		return "邮箱格式不正确"
+	case "password":
+		// 不含用户名重新校验一次以得到具体原因，仍通过说明密码包含用户名
+		if err := pwdpolicy.Current().Check(fmt.Sprint(e.Value()), ""); err != nil {
+			return err.Error()
+		}
+		return "不能包含用户名"
 	case "min":
 		return fmt.Sprintf("不能小于%s", e.Param())
 	case "max":
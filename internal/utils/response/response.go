@@ -8,6 +8,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// CodeContextKey 业务码在 gin.Context 中的键
+const CodeContextKey = "response_code"
+
 // Response 统一响应结构
 type Response struct {
 	Code      int    `json:"code"`
@@ -23,6 +26,8 @@ func Resp(c *gin.Context, code int, msg string, data any) {
 	resp.Msg = msg
 	resp.Data = data
 	resp.RequestID = requestID
+	// 记录业务码，供事务中间件等根据响应结果决定后续处理
+	c.Set(CodeContextKey, code)
 	if code != 0 {
 		xlog.Error("request err: requestID %s url %s method %s code %d msg %s", requestID, c.Request.URL, c.Request.Method, code, msg)
 	}
@@ -2,29 +2,37 @@ package xdb
 
 import (
 	"fmt"
+	"strings"
+	"sync/atomic"
 	"time"
 	"webgos/internal/config"
 	"webgos/internal/xlog"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var (
-	masterDB *gorm.DB   // 主库连接
-	slaveDBs []*gorm.DB // 备库连接池
+	masterDB  *gorm.DB     // 主库连接
+	slavePool *replicaPool // 备库连接池（读写分离未启用时为 nil）
+
+	memoryDBSeq atomic.Int64 // sqlite 内存库序号，保证每次打开的内存库相互隔离
 )
 
 // InitDB 初始化数据库连接
 func InitDB() error {
-	db, err := openDB(config.GlobalConfig.Database.DBConfig)
+	db, err := openDB(config.Get().Database.DBConfig, false)
 	if err != nil {
 		return err
 	}
+	if err := registerStickyCallbacks(db); err != nil {
+		return fmt.Errorf("failed to register callbacks: %w", err)
+	}
 	masterDB = db
 
-	if config.GlobalConfig.Database.ReadWriteSeparation {
+	if config.Get().Database.ReadWriteSeparation {
 		if err := initSlaveDBs(); err != nil {
 			return fmt.Errorf("failed to init slave db: %w", err)
 		}
@@ -33,28 +41,36 @@ func InitDB() error {
 }
 
 // 从库初始化
+// 备库连接不在启动时强制 ping，启动时不可用的备库先被摘除，由后台探测恢复后加回
 func initSlaveDBs() error {
-	slavesConfig := config.GlobalConfig.Database.Slaves
-	// 初始化备库连接池（读写分离）
-	slaveDBs = make([]*gorm.DB, 0, len(slavesConfig))
-	for _, slave := range slavesConfig {
-		sdb, err := openDB(slave)
+	dbConfig := config.Get().Database
+	replicas := make([]*replica, 0, len(dbConfig.Slaves))
+	for _, slave := range dbConfig.Slaves {
+		sdb, err := openDB(slave, true)
 		if err != nil {
+			closeReplicas(replicas)
 			return err
 		}
-		slaveDBs = append(slaveDBs, sdb)
+		replicas = append(replicas, &replica{name: replicaName(slave), db: sdb, weight: slave.Weight})
 	}
+	if len(replicas) == 0 {
+		return nil
+	}
+	slavePool = newReplicaPool(replicas, time.Duration(dbConfig.HealthCheckInterval)*time.Second)
+	slavePool.start()
 	return nil
 }
 
 // openDB 创建数据库连接并设置连接池参数（主库与备库共用）
-func openDB(dbConfig config.DBConfig) (*gorm.DB, error) {
+// lazy 为 true 时跳过建连时的 ping，连接可用性由健康检查负责
+func openDB(dbConfig config.DBConfig, lazy bool) (*gorm.DB, error) {
 	d, err := dialector(dbConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create dialector: %w", err)
 	}
 	db, err := gorm.Open(d, &gorm.Config{
-		Logger: xlog.NewGormLogger(),
+		Logger:               xlog.NewGormLogger(),
+		DisableAutomaticPing: lazy,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect database: %w", err)
@@ -67,6 +83,13 @@ func openDB(dbConfig config.DBConfig) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(dbConfig.MaxOpenConns)                                // 设置最大打开连接数
 	sqlDB.SetMaxIdleConns(dbConfig.MaxIdleConns)                                // 设置最大空闲连接数
 	sqlDB.SetConnMaxLifetime(time.Duration(dbConfig.MaxLifetime) * time.Minute) // 设置连接的最大生命周期
+	if isSQLiteMemory(dbConfig) {
+		// 内存库在最后一个连接关闭时销毁，固定保持单个常驻连接（共享缓存下并发写会直接报表锁）
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
 	return db, nil
 }
 
@@ -88,8 +111,9 @@ func dialector(dbConfig config.DBConfig) (gorm.Dialector, error) {
 			DSN:                  dsn,
 			PreferSimpleProtocol: true, // 依然需要启用文本协议
 		})
-	// case "sqlite":
-	// 	dialector = sqlite.Open(dbConfig.DBName)
+	case "sqlite":
+		// dbname 为数据库文件路径，":memory:" 表示内存库（进程内共享缓存，关闭后数据丢失）
+		dialector = sqlite.Open(sqliteDSN(dbConfig))
 	// case "sqlserver":
 	// 	dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s",
 	// 		dbConfig.Username, dbConfig.Password, dbConfig.Host,
@@ -101,10 +125,34 @@ func dialector(dbConfig config.DBConfig) (gorm.Dialector, error) {
 	return dialector, nil
 }
 
+// sqlite 连接参数：开启外键约束、写锁等待与 WAL 日志模式
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+func isSQLiteMemory(dbConfig config.DBConfig) bool {
+	return dbConfig.Dialect == "sqlite" && dbConfig.DBName == ":memory:"
+}
+
+// sqliteDSN 生成 sqlite 连接串
+// 内存库按序号命名，同一进程内多次打开（如测试）互不影响
+func sqliteDSN(dbConfig config.DBConfig) string {
+	if isSQLiteMemory(dbConfig) {
+		return fmt.Sprintf("file:webgos_mem_%d?mode=memory&cache=shared&_pragma=foreign_keys(1)", memoryDBSeq.Add(1))
+	}
+	if strings.Contains(dbConfig.DBName, "?") {
+		return dbConfig.DBName + "&" + sqlitePragmas
+	}
+	return dbConfig.DBName + "?" + sqlitePragmas
+}
+
 func CloseDB() {
 	if masterDB == nil {
 		return
 	}
+	if slavePool != nil {
+		slavePool.close()
+		closeReplicas(slavePool.replicas)
+		slavePool = nil
+	}
 	sqlDB, err := masterDB.DB()
 	if err != nil {
 		fmt.Println("Failed to get database instance:", err)
@@ -113,8 +161,11 @@ func CloseDB() {
 	if err := sqlDB.Close(); err != nil {
 		fmt.Println("Failed to close database connection:", err)
 	}
-	for _, slaveDB := range slaveDBs {
-		sqlDB, err = slaveDB.DB()
+}
+
+func closeReplicas(replicas []*replica) {
+	for _, r := range replicas {
+		sqlDB, err := r.db.DB()
 		if err != nil {
 			fmt.Println("Failed to get database instance:", err)
 			continue
@@ -1,53 +1,32 @@
 package xdb
 
 import (
-	"math/rand"
-	"sync"
 	"webgos/internal/config"
 
 	"gorm.io/gorm"
 )
 
-var (
-	slaveIndex int
-	slaveLock  sync.Mutex
-)
-
 // GetDB 获取主库连接（写操作）
 func GetDB() *gorm.DB {
 	return masterDB
 }
 
 // GetSlaveDB 获取备库连接（读操作）
+// 未启用读写分离或没有健康的备库时回退到主库，不会返回 nil
 func GetSlaveDB() *gorm.DB {
-	if !config.GlobalConfig.Database.ReadWriteSeparation || len(slaveDBs) == 0 {
-		return nil
+	if slavePool == nil || !config.Get().Database.ReadWriteSeparation {
+		return masterDB
 	}
-	switch config.GlobalConfig.Database.SlaveLoadBalance {
-	case "random":
-		return getRandomSlave()
-	case "round_robin":
-		return getRoundRobinSlave()
-	default:
-		return getRandomSlave()
+	if db := slavePool.pick(config.Get().Database.SlaveLoadBalance); db != nil {
+		return db
 	}
+	return masterDB
 }
 
-// 随机
-func getRandomSlave() *gorm.DB {
-	slaveLock.Lock()
-	defer slaveLock.Unlock()
-
-	index := rand.Intn(len(slaveDBs))
-	return slaveDBs[index]
-}
-
-// 轮询
-func getRoundRobinSlave() *gorm.DB {
-	slaveLock.Lock()
-	defer slaveLock.Unlock()
-
-	slave := slaveDBs[slaveIndex]
-	slaveIndex = (slaveIndex + 1) % len(slaveDBs)
-	return slave
+// SlaveStatus 获取各备库的健康状态，未启用读写分离时返回空列表
+func SlaveStatus() []ReplicaStatus {
+	if slavePool == nil {
+		return []ReplicaStatus{}
+	}
+	return slavePool.status()
 }
@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"webgos/internal/xdb/migrate/baseline"
+
+	"gorm.io/gorm"
+)
+
+// 基线迁移：按 baseline 包中冻结的模型快照建表，已有数据库执行时仅补齐缺失的表与字段
+func init() {
+	Register(Migration{
+		Version: 20260101000000,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			// 官方要求：先启用 PostGIS 扩展（必须执行）
+			// if err := tx.Exec("CREATE EXTENSION IF NOT EXISTS postgis;").Error; err != nil {
+			// 	return err
+			// }
+			return tx.AutoMigrate(baselineModels()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			snapshots := baselineModels()
+			// 倒序删除，先删关联表
+			for i := len(snapshots) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(snapshots[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+func baselineModels() []any {
+	return []any{
+		&baseline.Product{},
+		&baseline.InventoryRecord{},
+		&baseline.User{},
+		&baseline.Department{},
+		&baseline.Menu{},
+		&baseline.RBACRole{},
+		&baseline.RBACPermission{},
+		&baseline.RBACUserRole{},
+		&baseline.RBACRoleMenu{},
+		&baseline.RBACMenuPermission{},
+	}
+}
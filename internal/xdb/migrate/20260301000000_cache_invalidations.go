@@ -0,0 +1,34 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// cacheInvalidation 迁移时的缓存失效事件表结构
+type cacheInvalidation struct {
+	ID        int64     `gorm:"primaryKey"`
+	Op        string    `gorm:"size:20;not null"`
+	CacheKey  string    `gorm:"column:cache_key;size:1024"`
+	NodeID    string    `gorm:"column:node_id;size:100;not null"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (cacheInvalidation) TableName() string {
+	return "cache_invalidations"
+}
+
+// 缓存失效事件表，供数据库轮询方式的缓存失效广播使用
+func init() {
+	Register(Migration{
+		Version: 20260301000000,
+		Name:    "cache_invalidations",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&cacheInvalidation{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&cacheInvalidation{})
+		},
+	})
+}
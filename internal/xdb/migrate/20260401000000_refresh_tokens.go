@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// refreshToken 迁移时的刷新令牌表结构
+type refreshToken struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"index;not null"`
+	FamilyID  string    `gorm:"column:family_id;size:36;index;not null"`
+	TokenHash string    `gorm:"column:token_hash;size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (refreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// 刷新令牌表，访问令牌过期后通过刷新令牌轮换获取新的令牌对
+func init() {
+	Register(Migration{
+		Version: 20260401000000,
+		Name:    "refresh_tokens",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&refreshToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&refreshToken{})
+		},
+	})
+}
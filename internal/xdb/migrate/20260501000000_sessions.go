@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// loginSession 迁移时的登录会话表结构
+type loginSession struct {
+	ID         string `gorm:"primaryKey;size:36"`
+	UserID     int    `gorm:"index;not null"`
+	IP         string `gorm:"size:64"`
+	UserAgent  string `gorm:"column:user_agent;size:512"`
+	CreatedAt  time.Time
+	LastSeenAt time.Time `gorm:"column:last_seen_at"`
+	ExpiresAt  time.Time `gorm:"index;not null"`
+	RevokedAt  *time.Time
+}
+
+func (loginSession) TableName() string {
+	return "sessions"
+}
+
+// 登录会话表，记录设备信息，支持查看与远程注销会话
+func init() {
+	Register(Migration{
+		Version: 20260501000000,
+		Name:    "sessions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&loginSession{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&loginSession{})
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package migrate
+
+import (
+	"gorm.io/gorm"
+)
+
+// tokenVersionUser 迁移新增的用户表字段
+type tokenVersionUser struct {
+	TokenVersion int `gorm:"column:token_version;default:0;not null"`
+}
+
+func (tokenVersionUser) TableName() string {
+	return "users"
+}
+
+// 用户令牌版本字段，修改密码、状态或角色后使已签发的访问令牌失效
+func init() {
+	Register(Migration{
+		Version: 20260601000000,
+		Name:    "user_token_version",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&tokenVersionUser{}, "TokenVersion")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&tokenVersionUser{}, "TokenVersion")
+		},
+	})
+}
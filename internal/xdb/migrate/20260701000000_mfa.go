@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// mfaUser 迁移新增的用户表字段
+type mfaUser struct {
+	MFAEnabled bool   `gorm:"column:mfa_enabled;default:false;not null"`
+	MFASecret  string `gorm:"column:mfa_secret;size:64"`
+}
+
+func (mfaUser) TableName() string {
+	return "users"
+}
+
+// mfaRole 迁移新增的角色表字段
+type mfaRole struct {
+	RequireMFA bool `gorm:"column:require_mfa;default:false;not null"`
+}
+
+func (mfaRole) TableName() string {
+	return "rbac_roles"
+}
+
+// mfaRecoveryCode 迁移时的恢复码表结构
+type mfaRecoveryCode struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    int    `gorm:"index;not null"`
+	CodeHash  string `gorm:"column:code_hash;size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (mfaRecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// 两步验证：用户 TOTP 密钥与启用状态、角色强制两步验证策略、恢复码表
+func init() {
+	Register(Migration{
+		Version: 20260701000000,
+		Name:    "mfa",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []struct {
+				model any
+				field string
+			}{
+				{&mfaUser{}, "MFAEnabled"},
+				{&mfaUser{}, "MFASecret"},
+				{&mfaRole{}, "RequireMFA"},
+			} {
+				if err := tx.Migrator().AddColumn(column.model, column.field); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&mfaRecoveryCode{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&mfaRecoveryCode{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&mfaRole{}, "RequireMFA"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&mfaUser{}, "MFASecret"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&mfaUser{}, "MFAEnabled")
+		},
+	})
+}
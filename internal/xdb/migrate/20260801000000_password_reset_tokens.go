@@ -0,0 +1,36 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// passwordResetToken 迁移时的重置密码令牌表结构
+type passwordResetToken struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"index;not null"`
+	TokenHash string    `gorm:"column:token_hash;size:64;uniqueIndex;not null"`
+	IP        string    `gorm:"size:64"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (passwordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// 重置密码令牌表，通过邮件自助重置密码
+func init() {
+	Register(Migration{
+		Version: 20260801000000,
+		Name:    "password_reset_tokens",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&passwordResetToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&passwordResetToken{})
+		},
+	})
+}
@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// passwordPolicyUser 迁移新增的用户表字段
+type passwordPolicyUser struct {
+	PasswordChangedAt *time.Time `gorm:"column:password_changed_at"`
+}
+
+func (passwordPolicyUser) TableName() string {
+	return "users"
+}
+
+// passwordHistory 迁移时的密码历史表结构
+type passwordHistory struct {
+	ID           int    `gorm:"primaryKey"`
+	UserID       int    `gorm:"index;not null"`
+	PasswordHash string `gorm:"column:password_hash;size:100;not null"`
+	CreatedAt    time.Time
+}
+
+func (passwordHistory) TableName() string {
+	return "password_histories"
+}
+
+// 密码策略：用户密码修改时间（判断密码过期）与密码历史表
+func init() {
+	Register(Migration{
+		Version: 20260901000000,
+		Name:    "password_policy",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&passwordPolicyUser{}, "PasswordChangedAt"); err != nil {
+				return err
+			}
+			// 已有用户从迁移时开始计算密码有效期
+			if err := tx.Model(&passwordPolicyUser{}).Where("password_changed_at IS NULL").
+				UpdateColumn("password_changed_at", time.Now()).Error; err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&passwordHistory{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&passwordHistory{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&passwordPolicyUser{}, "PasswordChangedAt")
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKey 迁移时的 API 密钥表结构
+type apiKey struct {
+	ID         int           `gorm:"primaryKey"`
+	UserID     int           `gorm:"index;not null"`
+	Name       string        `gorm:"size:100;not null"`
+	Prefix     string        `gorm:"size:16;not null"`
+	KeyHash    string        `gorm:"column:key_hash;size:64;uniqueIndex;not null"`
+	Scopes     []apiKeyScope `gorm:"foreignKey:APIKeyID"`
+	CreatedBy  int           `gorm:"column:created_by"`
+	ExpiresAt  *time.Time    `gorm:"index"`
+	LastUsedAt *time.Time    `gorm:"column:last_used_at"`
+	LastUsedIP string        `gorm:"column:last_used_ip;size:64"`
+	CreatedAt  time.Time
+}
+
+func (apiKey) TableName() string {
+	return "api_keys"
+}
+
+// apiKeyScope 迁移时的 API 密钥权限范围表结构
+type apiKeyScope struct {
+	APIKeyID   int    `gorm:"column:api_key_id;primaryKey"`
+	Permission string `gorm:"size:100;primaryKey"`
+}
+
+func (apiKeyScope) TableName() string {
+	return "api_key_scopes"
+}
+
+// API 密钥表，机器客户端不再需要以用户身份登录获取 JWT
+func init() {
+	Register(Migration{
+		Version: 20261001000000,
+		Name:    "api_keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&apiKey{}, &apiKeyScope{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&apiKeyScope{}, &apiKey{})
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"gorm.io/gorm"
+
+	"webgos/internal/mfakey"
+)
+
+// mfaSecretUser 加密后的 TOTP 密钥字段，密文比 Base32 明文长
+type mfaSecretUser struct {
+	ID        int    `gorm:"primaryKey"`
+	MFASecret string `gorm:"column:mfa_secret;size:255"`
+}
+
+func (mfaSecretUser) TableName() string {
+	return "users"
+}
+
+// mfaPlainSecretUser 加密前的 TOTP 密钥字段，回滚时恢复
+type mfaPlainSecretUser struct {
+	ID        int    `gorm:"primaryKey"`
+	MFASecret string `gorm:"column:mfa_secret;size:64"`
+}
+
+func (mfaPlainSecretUser) TableName() string {
+	return "users"
+}
+
+// 加密保存 TOTP 密钥：加长 users.mfa_secret，并加密已有的明文密钥（含软删除的用户）
+func init() {
+	Register(Migration{
+		Version: 20261101000000,
+		Name:    "mfa_secret_encryption",
+		Up: func(tx *gorm.DB) error {
+			if err := alterMFASecret(tx, &mfaSecretUser{}); err != nil {
+				return err
+			}
+			var users []mfaSecretUser
+			if err := tx.Where("mfa_secret <> ''").Find(&users).Error; err != nil {
+				return err
+			}
+			for _, user := range users {
+				if mfakey.Sealed(user.MFASecret) {
+					continue
+				}
+				sealed, err := mfakey.Seal(user.MFASecret)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&mfaSecretUser{}).Where("id = ?", user.ID).Update("mfa_secret", sealed).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			var users []mfaSecretUser
+			if err := tx.Where("mfa_secret <> ''").Find(&users).Error; err != nil {
+				return err
+			}
+			for _, user := range users {
+				secret, err := mfakey.Open(user.MFASecret)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&mfaSecretUser{}).Where("id = ?", user.ID).Update("mfa_secret", secret).Error; err != nil {
+					return err
+				}
+			}
+			return alterMFASecret(tx, &mfaPlainSecretUser{})
+		},
+	})
+}
+
+// alterMFASecret 修改 mfa_secret 的长度；SQLite 的 TEXT 不限长度，且修改列会重建表并丢失索引，跳过
+func alterMFASecret(tx *gorm.DB, model any) error {
+	if tx.Dialector.Name() == "sqlite" {
+		return nil
+	}
+	return tx.Migrator().AlterColumn(model, "MFASecret")
+}
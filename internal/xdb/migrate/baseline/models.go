@@ -0,0 +1,138 @@
+// Package baseline 基线迁移使用的模型快照
+// 结构体与表名、字段、约束均冻结为基线迁移上线时的定义，不随 internal/models 变更；
+// 类型名与原模型保持一致，GORM 据此推导的表名、关联表与外键约束名不变
+// 已上线的基线迁移不要修改本包，表结构变更请新增迁移
+package baseline
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BaseFields struct {
+	ID        int `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *gorm.DeletedAt `gorm:"index"`
+}
+
+type Product struct {
+	BaseFields
+	Name  string
+	Stock int
+}
+
+type InventoryRecord struct {
+	BaseFields
+	ProductID int
+	Quantity  int
+	Type      string
+}
+
+type User struct {
+	BaseFields
+	Username     string `gorm:"unique"`
+	Nickname     string
+	Email        string
+	Phone        string
+	Password     string
+	Gender       string
+	Age          int
+	Status       int        `gorm:"default:1"`
+	DepartmentID int        `gorm:"column:department_id;default:0"`
+	Roles        []RBACRole `gorm:"many2many:rbac_user_roles;"`
+}
+
+type Department struct {
+	BaseFields
+	Name     string `gorm:"size:50;unique;not null"`
+	ParentID int    `gorm:"column:parent_id;default:0"`
+	LeaderID *int   `gorm:"column:leader_id"`
+	Remark   string `gorm:"size:200"`
+	Status   int    `gorm:"default:1"`
+	Sort     int    `gorm:"column:sort;default:0"`
+	Leader   *User  `gorm:"foreignKey:LeaderID"`
+}
+
+func (Department) TableName() string {
+	return "departments"
+}
+
+type Menu struct {
+	BaseFields
+	Name        string           `gorm:"size:50;not null;comment:菜单名称"`
+	Path        string           `gorm:"size:255;comment:路由路径"`
+	Component   string           `gorm:"size:255;comment:组件路径"`
+	Type        string           `gorm:"size:20;not null;comment:菜单类型"`
+	Status      int              `gorm:"default:1;comment:状态 0-禁用 1-启用"`
+	Meta        MenuMeta         `gorm:"embedded"`
+	Pid         int              `gorm:"comment:父级菜单ID"`
+	Permissions []RBACPermission `gorm:"many2many:rbac_menu_permissions"`
+}
+
+type MenuMeta struct {
+	Title              string `gorm:"size:100;comment:菜单标题"`
+	Icon               string `gorm:"size:50;comment:菜单图标"`
+	AffixTab           bool   `gorm:"comment:固定标签页"`
+	HideChildrenInMenu bool   `gorm:"comment:隐藏子菜单"`
+	HideInBreadcrumb   bool   `gorm:"comment:在面包屑中隐藏"`
+	HideInMenu         bool   `gorm:"comment:在菜单中隐藏"`
+	HideInTab          bool   `gorm:"comment:在标签页中隐藏"`
+	KeepAlive          bool   `gorm:"comment:保持活跃状态"`
+	Sort               int    `gorm:"column:sort;comment:排序"`
+	Badge              string `gorm:"size:20;comment:徽标文本"`
+	BadgeType          string `gorm:"size:20;comment:徽标类型"`
+	BadgeVariants      string `gorm:"size:20;comment:徽标样式"`
+	IframeSrc          string `gorm:"size:255;comment:iframe地址"`
+	Link               string `gorm:"size:255;comment:外链地址"`
+}
+
+func (Menu) TableName() string {
+	return "menus"
+}
+
+type RBACRole struct {
+	BaseFields
+	Name   string `gorm:"size:50;unique"`
+	Remark string `gorm:"size:200"`
+	Status int    `gorm:"default:1;comment:状态 0-禁用 1-启用"`
+	Users  []User `gorm:"many2many:rbac_user_roles;"`
+	Menus  []Menu `gorm:"many2many:rbac_role_menus"`
+}
+
+func (RBACRole) TableName() string {
+	return "rbac_roles"
+}
+
+type RBACPermission struct {
+	BaseFields
+	Name        string `gorm:"size:100;unique"`
+	Description string `gorm:"size:200"`
+	Path        string `gorm:"size:255"`
+	Method      string `gorm:"size:20"`
+	Menus       []Menu `gorm:"many2many:rbac_menu_permissions"`
+}
+
+type RBACUserRole struct {
+	UserID int `gorm:"column:user_id;primaryKey"`
+	RoleID int `gorm:"column:rbac_role_id;primaryKey"`
+}
+
+type RBACRoleMenu struct {
+	RoleID int `gorm:"column:rbac_role_id;primaryKey"`
+	MenuID int `gorm:"column:menu_id;primaryKey"`
+}
+
+func (RBACRoleMenu) TableName() string {
+	return "rbac_role_menus"
+}
+
+type RBACMenuPermission struct {
+	MenuID       int `gorm:"column:menu_id;primaryKey"`
+	PermissionID int `gorm:"column:rbac_permission_id;primaryKey"`
+}
+
+func (RBACMenuPermission) TableName() string {
+	return "rbac_menu_permissions"
+}
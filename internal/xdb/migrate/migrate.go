@@ -1,18 +1,69 @@
 package migrate
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
 	"webgos/internal/config"
-	"webgos/internal/models"
 	"webgos/internal/xdb"
 	"webgos/internal/xlog"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SchemaMigration 迁移历史表，记录已执行的版本与校验和
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	Checksum  string    `gorm:"size:64;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// SchemaMigrationLock 迁移锁表，同一时刻只有持有 ID=1 记录的实例可以执行迁移
+type SchemaMigrationLock struct {
+	ID       int       `gorm:"primaryKey;autoIncrement:false"`
+	LockedBy string    `gorm:"size:255;not null"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+const (
+	lockID        = 1
+	staleLockTime = 10 * time.Minute // 超过该时间未释放的锁视为持有者已崩溃，可被抢占
 )
 
-// AutoMigrate 执行数据库迁移
+// Options 迁移选项
+type Options struct {
+	DryRun      bool          // 仅输出待执行的迁移，不修改数据库
+	LockTimeout time.Duration // 等待其他实例释放迁移锁的最长时间，默认 1 分钟
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Dirty     bool // 已执行但校验和与当前代码不一致
+	Missing   bool // 数据库中已执行，但代码中已不存在该迁移
+}
+
+// AutoMigrate 启动时执行迁移（由 auto_migrate 配置控制）
 func AutoMigrate() error {
-	globalConfig := config.GlobalConfig
+	globalConfig := config.Get()
 	if globalConfig.AutoMigrate {
 		xlog.Access("Starting auto migration...")
-		if err := migrate(); err != nil {
+		if _, err := Up(context.Background(), Options{}); err != nil {
 			return err
 		}
 		xlog.Access("Auto migration completed")
@@ -20,25 +71,233 @@ func AutoMigrate() error {
 		xlog.Access("Auto migration is disabled")
 	}
 	return nil
+}
 
+// Up 按版本号顺序执行所有未执行的迁移，返回本次执行（DryRun 时为待执行）的迁移
+// 每个迁移与其历史记录在同一事务中提交；已执行迁移的校验和不一致时拒绝执行
+func Up(ctx context.Context, opts Options) ([]Migration, error) {
+	var pending []Migration
+	err := withLock(ctx, opts, func(db *gorm.DB) error {
+		migrations, applied, err := load(db)
+		if err != nil {
+			return err
+		}
+		if err := verify(migrations, applied); err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; !ok {
+				pending = append(pending, m)
+			}
+		}
+		for _, m := range pending {
+			if opts.DryRun {
+				describe("pending", m)
+				continue
+			}
+			start := time.Now()
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, Checksum: m.checksum, AppliedAt: time.Now()}).Error
+			}); err != nil {
+				return fmt.Errorf("migration %d_%s up failed: %w", m.Version, m.Name, err)
+			}
+			xlog.Access("migration %d_%s applied in %s", m.Version, m.Name, time.Since(start))
+		}
+		return nil
+	})
+	return pending, err
+}
+
+// Down 按版本号倒序回滚所有版本号大于 target 的已执行迁移，target 为 0 表示全部回滚
+// 返回本次回滚（DryRun 时为待回滚）的迁移
+func Down(ctx context.Context, target int64, opts Options) ([]Migration, error) {
+	var rollback []Migration
+	err := withLock(ctx, opts, func(db *gorm.DB) error {
+		migrations, applied, err := load(db)
+		if err != nil {
+			return err
+		}
+		if err := verify(migrations, applied); err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; ok && m.Version > target {
+				if m.Down == nil {
+					return fmt.Errorf("migration %d_%s does not support down", m.Version, m.Name)
+				}
+				rollback = append(rollback, m)
+			}
+		}
+		for _, m := range rollback {
+			if opts.DryRun {
+				describe("rollback", m)
+				continue
+			}
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, m.Version).Error
+			}); err != nil {
+				return fmt.Errorf("migration %d_%s down failed: %w", m.Version, m.Name, err)
+			}
+			xlog.Access("migration %d_%s rolled back", m.Version, m.Name)
+		}
+		return nil
+	})
+	return rollback, err
+}
+
+// GetStatus 返回全部迁移的执行状态，按版本号升序
+func GetStatus(ctx context.Context) ([]Status, error) {
+	db := xdb.GetDB().WithContext(ctx)
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	migrations, applied, err := load(db)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Status, 0, len(migrations))
+	known := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		s := Status{Version: m.Version, Name: m.Name}
+		if record, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = &record.AppliedAt
+			s.Dirty = record.Checksum != m.checksum
+		}
+		list = append(list, s)
+	}
+	for _, record := range applied {
+		if !known[record.Version] {
+			list = append(list, Status{Version: record.Version, Name: record.Name, Applied: true, AppliedAt: &record.AppliedAt, Missing: true})
+		}
+	}
+	return list, nil
+}
+
+// load 读取代码中的迁移与数据库中的执行记录
+func load(db *gorm.DB) ([]Migration, map[int64]SchemaMigration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, nil, err
+	}
+	var records []SchemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, nil, err
+	}
+	applied := make(map[int64]SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return migrations, applied, nil
+}
+
+// verify 校验已执行迁移的校验和
+func verify(migrations []Migration, applied map[int64]SchemaMigration) error {
+	for _, m := range migrations {
+		if record, ok := applied[m.Version]; ok && record.Checksum != m.checksum {
+			return fmt.Errorf("migration %d_%s checksum mismatch: applied %s, current %s", m.Version, m.Name, record.Checksum, m.checksum)
+		}
+	}
+	return nil
+}
+
+func describe(action string, m Migration) {
+	if m.IsSQL() {
+		sql := m.upSQL
+		if action == "rollback" {
+			sql = m.downSQL
+		}
+		xlog.Access("[dry-run] %s %d_%s:\n%s", action, m.Version, m.Name, sql)
+		return
+	}
+	xlog.Access("[dry-run] %s %d_%s (go)", action, m.Version, m.Name)
+}
+
+// withLock 获取迁移锁后执行 fn，多个实例同时启动时只有一个执行迁移，其余等待后发现已无待执行迁移
+func withLock(ctx context.Context, opts Options, fn func(db *gorm.DB) error) error {
+	db := xdb.GetDB().WithContext(ctx)
+	if err := db.AutoMigrate(&SchemaMigration{}, &SchemaMigrationLock{}); err != nil {
+		return fmt.Errorf("failed to create migration tables: %w", err)
+	}
+	if opts.DryRun {
+		// 预览不修改数据库，无需加锁
+		return fn(db)
+	}
+
+	timeout := opts.LockTimeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+	owner := lockOwner()
+	deadline := time.Now().Add(timeout)
+	for {
+		acquired, err := tryLock(db, owner)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return errors.New("timeout waiting for migration lock")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	defer func() {
+		if err := db.Where("id = ? AND locked_by = ?", lockID, owner).Delete(&SchemaMigrationLock{}).Error; err != nil {
+			xlog.Error("failed to release migration lock: %v", err)
+		}
+	}()
+	return fn(db)
+}
+
+// tryLock 通过主键唯一约束抢占锁，锁已过期时接管
+func tryLock(db *gorm.DB, owner string) (bool, error) {
+	now := time.Now()
+	if err := db.Create(&SchemaMigrationLock{ID: lockID, LockedBy: owner, LockedAt: now}).Error; err == nil {
+		return true, nil
+	}
+
+	var lock SchemaMigrationLock
+	if err := db.Take(&lock, lockID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 锁恰好被释放，下一轮重试
+			return false, nil
+		}
+		return false, err
+	}
+	if now.Sub(lock.LockedAt) < staleLockTime {
+		return false, nil
+	}
+	result := db.Model(&SchemaMigrationLock{}).
+		Where("id = ? AND locked_by = ? AND locked_at = ?", lockID, lock.LockedBy, lock.LockedAt).
+		Updates(map[string]any{"locked_by": owner, "locked_at": now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		xlog.Warn("migration lock held by %s since %s is stale, taken over", lock.LockedBy, lock.LockedAt.Format(time.RFC3339))
+		return true, nil
+	}
+	return false, nil
 }
 
-func migrate() error {
-	// 3. 官方要求：先启用 PostGIS 扩展（必须执行）
-	// if err := database.GetDB().Exec("CREATE EXTENSION IF NOT EXISTS postgis;").Error; err != nil {
-	// 	xlog.Error("启用 PostGIS 失败: %v", err)
-	// }
-
-	// 执行自动迁移
-	return xdb.GetDB().AutoMigrate(
-		&models.Product{},
-		&models.InventoryRecord{},
-		&models.User{},
-		&models.Department{},
-		&models.Menu{},
-		&models.RBACRole{},
-		&models.RBACPermission{},
-		&models.RBACUserRole{},
-		&models.RBACRoleMenu{},
-		&models.RBACMenuPermission{})
+func lockOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), uuid.NewString()[:8])
 }
@@ -0,0 +1,224 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Migration 单个版本化迁移
+// Version 为递增整数，推荐使用创建时间 yyyymmddHHMMSS，保证多人并行开发时不冲突
+// Go 迁移通过 Register 注册 Up/Down 函数；SQL 迁移放在 sql 目录下，命名为 <version>_<name>.up.sql / <version>_<name>.down.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为 nil 时不支持回滚
+
+	upSQL    string
+	downSQL  string
+	checksum string
+}
+
+// Checksum 迁移内容校验和：SQL 迁移为 up 脚本内容的 sha256；
+// Go 迁移为源文件 <version>_<name>.go 与同名快照目录 <name>/ 下源文件内容的 sha256，
+// 源文件不在本包中（如测试中注册）时退化为版本号与名称的 sha256
+// 已执行迁移的校验和与当前代码不一致时拒绝继续迁移，避免已上线的迁移被悄悄修改
+func (m Migration) Checksum() string {
+	return m.checksum
+}
+
+// IsSQL 是否为 SQL 文件迁移
+func (m Migration) IsSQL() bool {
+	return m.upSQL != ""
+}
+
+//go:embed sql
+var sqlFiles embed.FS
+
+// Go 迁移源文件及其模型快照，用于计算校验和
+//
+//go:embed *.go */*.go
+var goFiles embed.FS
+
+// 匹配 SQL 迁移文件名：<version>_<name>.up.sql / <version>_<name>.down.sql
+var sqlFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	registryMu sync.Mutex
+	registered = make(map[int64]Migration)
+)
+
+// Register 注册 Go 迁移，通常在迁移文件的 init 中调用，版本号重复时 panic
+func Register(m Migration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if m.Version <= 0 || m.Name == "" || m.Up == nil {
+		panic(fmt.Sprintf("migrate: invalid migration %d_%s", m.Version, m.Name))
+	}
+	if exist, ok := registered[m.Version]; ok {
+		panic(fmt.Sprintf("migrate: duplicate version %d (%s, %s)", m.Version, exist.Name, m.Name))
+	}
+	source, ok := goSource(goFiles, m.Version, m.Name)
+	if !ok {
+		source = fmt.Sprintf("%d_%s", m.Version, m.Name)
+	}
+	m.checksum = checksum(source)
+	registered[m.Version] = m
+}
+
+// Migrations 返回全部迁移（Go 与 SQL），按版本号升序
+func Migrations() ([]Migration, error) {
+	registryMu.Lock()
+	all := make(map[int64]Migration, len(registered))
+	for v, m := range registered {
+		all[v] = m
+	}
+	registryMu.Unlock()
+
+	sqlMigrations, err := loadSQLMigrations(sqlFiles, "sql")
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range sqlMigrations {
+		if exist, ok := all[m.Version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d (%s, %s)", m.Version, exist.Name, m.Name)
+		}
+		all[m.Version] = m
+	}
+
+	list := make([]Migration, 0, len(all))
+	for _, m := range all {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// loadSQLMigrations 读取目录下的 SQL 迁移文件，up 文件必须存在，down 文件可选
+func loadSQLMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := sqlFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s, %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.upSQL = string(content)
+		} else {
+			m.downSQL = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.upSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s is missing up.sql", m.Version, m.Name)
+		}
+		m.checksum = checksum(m.upSQL)
+		m.Up = execSQL(m.upSQL)
+		if m.downSQL != "" {
+			m.Down = execSQL(m.downSQL)
+		}
+		list = append(list, *m)
+	}
+	return list, nil
+}
+
+// goSource 读取 Go 迁移的源文件与同名快照目录下的源文件，按文件名顺序拼接
+func goSource(fsys fs.FS, version int64, name string) (string, bool) {
+	content, err := fs.ReadFile(fsys, fmt.Sprintf("%d_%s.go", version, name))
+	if err != nil {
+		return "", false
+	}
+	var source strings.Builder
+	source.Write(content)
+	// 没有快照目录时 ReadDir 返回错误，只计算迁移文件本身
+	entries, _ := fs.ReadDir(fsys, name)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		file := path.Join(name, entry.Name())
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return "", false
+		}
+		source.WriteString("\n// " + file + "\n")
+		source.Write(content)
+	}
+	return source.String(), true
+}
+
+// execSQL 逐条执行 SQL 脚本，语句以行尾分号分隔
+func execSQL(script string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// splitStatements 按行尾分号拆分语句，忽略 -- 注释行与空语句
+func splitStatements(script string) []string {
+	stmts := make([]string, 0)
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			if stmt := strings.TrimSpace(current.String()); stmt != ";" {
+				stmts = append(stmts, stmt)
+			}
+			current.Reset()
+		}
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
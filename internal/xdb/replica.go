@@ -0,0 +1,228 @@
+package xdb
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+	"webgos/internal/config"
+	"webgos/internal/xlog"
+
+	"gorm.io/gorm"
+)
+
+// 备库摘除后重新探测的最大退避时间
+const maxProbeBackoff = 5 * time.Minute
+
+// replica 备库节点及其健康状态
+type replica struct {
+	name   string // host:port/dbname，仅用于日志与状态展示
+	db     *gorm.DB
+	weight int
+
+	// 以下字段由 replicaPool.mu 保护
+	healthy       bool
+	currentWeight int           // 平滑加权轮询的当前权重
+	failures      int           // 连续探测失败次数
+	backoff       time.Duration // 摘除后的重新探测间隔，按失败次数指数增长
+	nextProbe     time.Time     // 下次允许探测的时间
+	lastError     string
+	lastCheckAt   time.Time
+}
+
+// ReplicaStatus 备库健康状态快照
+type ReplicaStatus struct {
+	Name        string    `json:"name"`
+	Weight      int       `json:"weight"`
+	Healthy     bool      `json:"healthy"`
+	Failures    int       `json:"failures"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastCheckAt time.Time `json:"last_check_at"`
+	NextProbeAt time.Time `json:"next_probe_at"`
+}
+
+// replicaPool 备库连接池：后台定时探测，探测失败的备库被摘除，按指数退避重新探测，恢复后自动加回
+type replicaPool struct {
+	mu       sync.Mutex
+	replicas []*replica
+	rrIndex  int
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newReplicaPool(replicas []*replica, interval time.Duration) *replicaPool {
+	return &replicaPool{
+		replicas: replicas,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// start 同步执行一次探测后启动后台探测协程
+func (p *replicaPool) start() {
+	p.probe()
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.probe()
+			}
+		}
+	}()
+}
+
+// close 停止后台探测并等待协程退出
+func (p *replicaPool) close() {
+	close(p.stop)
+	<-p.done
+}
+
+// probe 并发探测所有到期的备库
+func (p *replicaPool) probe() {
+	now := time.Now()
+	p.mu.Lock()
+	due := make([]*replica, 0, len(p.replicas))
+	for _, r := range p.replicas {
+		if !now.Before(r.nextProbe) {
+			due = append(due, r)
+		}
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, r := range due {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+			p.report(r, ping(r.db, p.interval))
+		}(r)
+	}
+	wg.Wait()
+}
+
+// report 记录探测结果并切换健康状态
+func (p *replicaPool) report(r *replica, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	first := r.lastCheckAt.IsZero()
+	r.lastCheckAt = now
+	if err == nil {
+		if !r.healthy && !first {
+			xlog.Access("slave db %s is healthy, re-admitted", r.name)
+		}
+		r.healthy = true
+		r.failures = 0
+		r.backoff = 0
+		r.lastError = ""
+		r.nextProbe = time.Time{}
+		return
+	}
+
+	r.failures++
+	r.lastError = err.Error()
+	if r.backoff == 0 {
+		r.backoff = p.interval
+	} else {
+		r.backoff = min(r.backoff*2, maxProbeBackoff)
+	}
+	r.nextProbe = now.Add(r.backoff)
+	if r.healthy || first {
+		xlog.Error("slave db %s ejected: %v", r.name, err)
+	} else {
+		xlog.Warn("slave db %s still unhealthy (failures=%d, next probe in %s): %v", r.name, r.failures, r.backoff, err)
+	}
+	r.healthy = false
+	r.currentWeight = 0
+}
+
+// pick 按策略从健康备库中选择一个，没有健康备库时返回 nil
+func (p *replicaPool) pick(strategy string) *gorm.DB {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	healthy := make([]*replica, 0, len(p.replicas))
+	for _, r := range p.replicas {
+		if r.healthy {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	switch strategy {
+	case "round_robin":
+		// 轮询
+		p.rrIndex = (p.rrIndex + 1) % len(healthy)
+		return healthy[p.rrIndex].db
+	case "weighted":
+		return pickWeighted(healthy).db
+	default:
+		// 随机
+		return healthy[rand.Intn(len(healthy))].db
+	}
+}
+
+// pickWeighted 平滑加权轮询（与 nginx 一致），避免高权重节点被连续选中
+func pickWeighted(replicas []*replica) *replica {
+	total := 0
+	var best *replica
+	for _, r := range replicas {
+		r.currentWeight += r.weight
+		total += r.weight
+		if best == nil || r.currentWeight > best.currentWeight {
+			best = r
+		}
+	}
+	best.currentWeight -= total
+	return best
+}
+
+// status 返回所有备库的状态快照
+func (p *replicaPool) status() []ReplicaStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	list := make([]ReplicaStatus, 0, len(p.replicas))
+	for _, r := range p.replicas {
+		list = append(list, ReplicaStatus{
+			Name:        r.name,
+			Weight:      r.weight,
+			Healthy:     r.healthy,
+			Failures:    r.failures,
+			LastError:   r.lastError,
+			LastCheckAt: r.lastCheckAt,
+			NextProbeAt: r.nextProbe,
+		})
+	}
+	return list
+}
+
+// ping 探测连接是否可用，超时时间不超过探测间隔
+func ping(db *gorm.DB, timeout time.Duration) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), min(timeout, 3*time.Second))
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+func replicaName(dbConfig config.DBConfig) string {
+	if dbConfig.Dialect == "sqlite" {
+		return dbConfig.DBName
+	}
+	return fmt.Sprintf("%s:%d/%s", dbConfig.Host, dbConfig.Port, dbConfig.DBName)
+}
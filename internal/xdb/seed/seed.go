@@ -0,0 +1,466 @@
+// Package seed 加载 YAML/JSON 种子数据（部门、菜单、菜单权限绑定、角色、用户），按自然键幂等写入
+// 与方言无关，可用于初始化新库、启动时补齐基础数据以及测试准备数据
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"webgos/internal/models"
+	"webgos/internal/xdb"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// Fixture 种子数据文件结构，字段名与接口 JSON 字段保持一致
+type Fixture struct {
+	Departments []Department `json:"departments"`
+	Menus       []Menu       `json:"menus"`
+	Roles       []Role       `json:"roles"`
+	Users       []User       `json:"users"`
+}
+
+// Department 部门，自然键为 name，parent 为上级部门名称
+type Department struct {
+	Name   string `json:"name"`
+	Parent string `json:"parent"`
+	Remark string `json:"remark"`
+	Sort   int    `json:"sort"`
+	Status *int   `json:"status"`
+}
+
+// Menu 菜单，自然键为 name，parent 为上级菜单名称，permissions 为绑定的权限点名称（path#METHOD）
+type Menu struct {
+	Name        string          `json:"name"`
+	Parent      string          `json:"parent"`
+	Path        string          `json:"path"`
+	Component   string          `json:"component"`
+	Type        string          `json:"type"`
+	Status      *int            `json:"status"`
+	Meta        models.MenuMeta `json:"meta"`
+	Permissions []string        `json:"permissions"`
+}
+
+// Role 角色，自然键为 name，menus 为可访问的菜单名称
+type Role struct {
+	Name   string   `json:"name"`
+	Remark string   `json:"remark"`
+	Status *int     `json:"status"`
+	Menus  []string `json:"menus"`
+}
+
+// User 用户，自然键为 username；password 仅在创建时使用，已存在用户的密码不会被覆盖
+type User struct {
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	Nickname   string   `json:"nickname"`
+	Email      string   `json:"email"`
+	Phone      string   `json:"phone"`
+	Gender     string   `json:"gender"`
+	Status     *int     `json:"status"`
+	Department string   `json:"department"`
+	Roles      []string `json:"roles"`
+}
+
+// Count 单类数据的写入统计
+type Count struct {
+	Created int
+	Updated int
+}
+
+// Result 一次加载的写入统计
+type Result struct {
+	Departments Count
+	Menus       Count
+	Permissions Count // 菜单绑定时数据库中尚不存在、由种子数据补建的权限点
+	Roles       Count
+	Users       Count
+}
+
+func (r *Result) String() string {
+	format := func(name string, c Count) string {
+		return fmt.Sprintf("%s: %d created, %d updated", name, c.Created, c.Updated)
+	}
+	return strings.Join([]string{
+		format("departments", r.Departments),
+		format("menus", r.Menus),
+		format("permissions", r.Permissions),
+		format("roles", r.Roles),
+		format("users", r.Users),
+	}, "; ")
+}
+
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv 替换 ${NAME} 形式的环境变量引用（如密码），其余 $ 字符保持原样；引用未定义的变量视为错误
+func expandEnv(content []byte) ([]byte, error) {
+	var missing []string
+	expanded := envPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		name := string(envPattern.FindSubmatch(match)[1])
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return []byte(value)
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined environment variable(s): %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// ParseFile 解析种子数据文件，按扩展名区分 YAML（.yaml/.yml）与 JSON（.json）
+func ParseFile(path string) (*Fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+	if content, err = expandEnv(content); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// 先转换为 JSON，使 YAML 与 JSON 共用同一套字段名（如 meta.hideInMenu）
+		var raw any
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if content, err = json.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("%s: unsupported seed file type, expected .yaml, .yml or .json", path)
+	}
+
+	fixture := &Fixture{}
+	if err := json.Unmarshal(content, fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// LoadFiles 解析并在同一事务中加载多个种子数据文件，任一文件失败则全部回滚
+func LoadFiles(ctx context.Context, paths ...string) (*Result, error) {
+	fixtures := make([]*Fixture, 0, len(paths))
+	for _, path := range paths {
+		fixture, err := ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return Apply(ctx, fixtures...)
+}
+
+// Apply 在同一事务中按 部门 -> 菜单（含权限绑定）-> 角色 -> 用户 的顺序写入种子数据
+// 已存在的记录（含软删除的记录，会被恢复）按自然键更新，关联关系只追加不删除，重复执行结果一致
+func Apply(ctx context.Context, fixtures ...*Fixture) (*Result, error) {
+	result := &Result{}
+	err := xdb.Transaction(ctx, func(ctx context.Context) error {
+		l := &loader{tx: xdb.WriteDB(ctx), result: result}
+		for _, f := range fixtures {
+			if err := l.apply(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type loader struct {
+	tx     *gorm.DB
+	result *Result
+}
+
+func (l *loader) apply(f *Fixture) error {
+	if err := upsertTree(f.Departments, func(d Department) (string, string) { return d.Name, d.Parent },
+		l.departmentID, l.upsertDepartment); err != nil {
+		return fmt.Errorf("departments: %w", err)
+	}
+	if err := upsertTree(f.Menus, func(m Menu) (string, string) { return m.Name, m.Parent },
+		l.menuID, l.upsertMenu); err != nil {
+		return fmt.Errorf("menus: %w", err)
+	}
+	for _, r := range f.Roles {
+		if err := l.upsertRole(r); err != nil {
+			return fmt.Errorf("roles: %s: %w", r.Name, err)
+		}
+	}
+	for _, u := range f.Users {
+		if err := l.upsertUser(u); err != nil {
+			return fmt.Errorf("users: %s: %w", u.Username, err)
+		}
+	}
+	return nil
+}
+
+// upsertTree 按父子依赖顺序写入树形数据：上级可以定义在同一批数据的任意位置，也可以已存在于数据库
+func upsertTree[T any](items []T, key func(T) (name, parent string), lookup func(name string) (int, error), upsert func(item T, parentID int) error) error {
+	pending := items
+	for len(pending) > 0 {
+		var next []T
+		for _, item := range pending {
+			_, parent := key(item)
+			parentID := 0
+			if parent != "" {
+				id, err := lookup(parent)
+				if err != nil {
+					return err
+				}
+				if id == 0 {
+					next = append(next, item)
+					continue
+				}
+				parentID = id
+			}
+			if err := upsert(item, parentID); err != nil {
+				return err
+			}
+		}
+		if len(next) == len(pending) {
+			name, parent := key(next[0])
+			return fmt.Errorf("%s: parent %q not found", name, parent)
+		}
+		pending = next
+	}
+	return nil
+}
+
+// find 按自然键查找记录（含软删除的记录），不存在时 dest 保持零值
+func (l *loader) find(dest any, column, value string) error {
+	return l.tx.Unscoped().Where(column+" = ?", value).Order("id").Limit(1).Find(dest).Error
+}
+
+// save 创建或更新记录，并恢复软删除的记录
+// status 为 0 时单独更新：创建时零值字段会被 gorm 的 default:1 覆盖
+func (l *loader) save(model any, count *Count, created bool, status *int) error {
+	if err := l.tx.Unscoped().Save(model).Error; err != nil {
+		return err
+	}
+	if status != nil && *status == 0 {
+		if err := l.tx.Model(model).Update("status", 0).Error; err != nil {
+			return err
+		}
+	}
+	if created {
+		count.Created++
+	} else {
+		count.Updated++
+	}
+	return nil
+}
+
+func statusOr(status *int) int {
+	if status == nil {
+		return 1
+	}
+	return *status
+}
+
+func (l *loader) departmentID(name string) (int, error) {
+	var dept models.Department
+	err := l.tx.Model(&models.Department{}).Where("name = ?", name).Order("id").Limit(1).Find(&dept).Error
+	return dept.ID, err
+}
+
+func (l *loader) upsertDepartment(d Department, parentID int) error {
+	if d.Name == "" {
+		return errors.New("department name is required")
+	}
+	var dept models.Department
+	if err := l.find(&dept, "name", d.Name); err != nil {
+		return err
+	}
+	created := dept.ID == 0
+	dept.Name = d.Name
+	dept.ParentID = parentID
+	dept.Remark = d.Remark
+	dept.Sort = d.Sort
+	dept.Status = statusOr(d.Status)
+	dept.DeletedAt = nil
+	return l.save(&dept, &l.result.Departments, created, d.Status)
+}
+
+func (l *loader) menuID(name string) (int, error) {
+	var menu models.Menu
+	err := l.tx.Model(&models.Menu{}).Where("name = ?", name).Order("id").Limit(1).Find(&menu).Error
+	return menu.ID, err
+}
+
+func (l *loader) upsertMenu(m Menu, parentID int) error {
+	if m.Name == "" || m.Type == "" {
+		return fmt.Errorf("menu %q: name and type are required", m.Name)
+	}
+	var menu models.Menu
+	if err := l.find(&menu, "name", m.Name); err != nil {
+		return err
+	}
+	created := menu.ID == 0
+	menu.Name = m.Name
+	menu.Pid = parentID
+	menu.Path = m.Path
+	menu.Component = m.Component
+	menu.Type = m.Type
+	menu.Status = statusOr(m.Status)
+	menu.Meta = m.Meta
+	menu.DeletedAt = nil
+	if err := l.save(&menu, &l.result.Menus, created, m.Status); err != nil {
+		return err
+	}
+
+	if len(m.Permissions) == 0 {
+		return nil
+	}
+	permissions := make([]models.RBACPermission, 0, len(m.Permissions))
+	for _, name := range m.Permissions {
+		permission, err := l.ensurePermission(name)
+		if err != nil {
+			return fmt.Errorf("menu %q: %w", m.Name, err)
+		}
+		permissions = append(permissions, *permission)
+	}
+	return l.tx.Model(&menu).Omit("Permissions.*").Association("Permissions").Append(permissions)
+}
+
+// ensurePermission 按名称（path#METHOD）查找权限点，不存在时按名称补建
+// 权限点通常由路由同步（SyncPermissions）生成，补建后再次同步会按名称更新其描述
+func (l *loader) ensurePermission(name string) (*models.RBACPermission, error) {
+	var permission models.RBACPermission
+	if err := l.find(&permission, "name", name); err != nil {
+		return nil, err
+	}
+	if permission.ID != 0 && permission.DeletedAt == nil {
+		return &permission, nil
+	}
+	path, method, ok := strings.Cut(name, "#")
+	if !ok || path == "" || method == "" {
+		return nil, fmt.Errorf("invalid permission name %q, expected path#METHOD", name)
+	}
+	created := permission.ID == 0
+	permission.Name = name
+	permission.Path = path
+	permission.Method = method
+	permission.DeletedAt = nil
+	if err := l.save(&permission, &l.result.Permissions, created, nil); err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+func (l *loader) upsertRole(r Role) error {
+	if r.Name == "" {
+		return errors.New("role name is required")
+	}
+	var role models.RBACRole
+	if err := l.find(&role, "name", r.Name); err != nil {
+		return err
+	}
+	created := role.ID == 0
+	role.Name = r.Name
+	role.Remark = r.Remark
+	role.Status = statusOr(r.Status)
+	role.DeletedAt = nil
+	if err := l.save(&role, &l.result.Roles, created, r.Status); err != nil {
+		return err
+	}
+
+	if len(r.Menus) == 0 {
+		return nil
+	}
+	menus := make([]models.Menu, 0, len(r.Menus))
+	for _, name := range r.Menus {
+		id, err := l.menuID(name)
+		if err != nil {
+			return err
+		}
+		if id == 0 {
+			return fmt.Errorf("menu %q not found", name)
+		}
+		menus = append(menus, models.Menu{BaseFields: models.BaseFields{ID: id}})
+	}
+	return l.tx.Model(&role).Omit("Menus.*").Association("Menus").Append(menus)
+}
+
+func (l *loader) upsertUser(u User) error {
+	if u.Username == "" {
+		return errors.New("username is required")
+	}
+	var user models.User
+	if err := l.find(&user, "username", u.Username); err != nil {
+		return err
+	}
+	created := user.ID == 0
+	if created {
+		if u.Password == "" {
+			return errors.New("password is required when creating a user")
+		}
+		if err := user.SetPassword(u.Password); err != nil {
+			return err
+		}
+	}
+
+	departmentID := 0
+	if u.Department != "" {
+		id, err := l.departmentID(u.Department)
+		if err != nil {
+			return err
+		}
+		if id == 0 {
+			return fmt.Errorf("department %q not found", u.Department)
+		}
+		departmentID = id
+	}
+
+	user.Username = u.Username
+	user.Nickname = u.Nickname
+	if user.Nickname == "" {
+		user.Nickname = u.Username
+	}
+	user.Email = u.Email
+	user.Phone = u.Phone
+	user.Gender = u.Gender
+	user.Status = statusOr(u.Status)
+	user.DepartmentID = departmentID
+	user.DeletedAt = nil
+	if created {
+		if err := l.save(&user, &l.result.Users, created, u.Status); err != nil {
+			return err
+		}
+	} else {
+		// 已有用户只更新种子数据中的字段，不覆盖密码、令牌版本、两步验证等由业务维护的字段
+		if err := l.tx.Unscoped().Model(&user).
+			Select("Nickname", "Email", "Phone", "Gender", "Status", "DepartmentID", "DeletedAt").
+			Updates(&user).Error; err != nil {
+			return err
+		}
+		l.result.Users.Updated++
+	}
+
+	if len(u.Roles) == 0 {
+		return nil
+	}
+	roles := make([]models.RBACRole, 0, len(u.Roles))
+	for _, name := range u.Roles {
+		var role models.RBACRole
+		if err := l.tx.Where("name = ?", name).Limit(1).Find(&role).Error; err != nil {
+			return err
+		}
+		if role.ID == 0 {
+			return fmt.Errorf("role %q not found", name)
+		}
+		roles = append(roles, role)
+	}
+	return l.tx.Model(&user).Omit("Roles.*").Association("Roles").Append(roles)
+}
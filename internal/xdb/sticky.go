@@ -0,0 +1,172 @@
+package xdb
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+	"webgos/internal/config"
+
+	"gorm.io/gorm"
+)
+
+// 读路由选项
+type readRoute int
+
+const (
+	routeAuto    readRoute = iota // 默认：写后粘滞窗口内走主库，否则走备库
+	routeMaster                   // 强制主库
+	routeReplica                  // 强制备库（允许读到复制延迟前的旧数据）
+)
+
+type readRouteKey struct{}
+
+// ForceMaster 返回强制读主库的 context，用于强一致性读
+func ForceMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readRouteKey{}, routeMaster)
+}
+
+// ForceReplica 返回强制读备库的 context，忽略写后粘滞窗口，用于可接受延迟的大查询
+func ForceReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readRouteKey{}, routeReplica)
+}
+
+// ReadDB 获取读操作连接（已绑定 ctx）
+// ctx 中有事务时返回事务（沿用事务开启时绑定的 context）；同一用户或同一请求通过主库写入后，在 database.sticky_master 秒内的读操作都路由到主库，保证读到自己的写入
+func ReadDB(ctx context.Context) *gorm.DB {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	route, _ := ctx.Value(readRouteKey{}).(readRoute)
+	switch route {
+	case routeMaster:
+		return GetDB().WithContext(ctx)
+	case routeReplica:
+		return GetSlaveDB().WithContext(ctx)
+	}
+	if sticky.active(ctx) {
+		return GetDB().WithContext(ctx)
+	}
+	return GetSlaveDB().WithContext(ctx)
+}
+
+// stickyMarks 记录发生过写操作的用户与请求，键为 user:<id> 或 req:<request_id>
+type stickyMarks struct {
+	mu      sync.Mutex
+	until   map[string]time.Time
+	sweepAt time.Time
+}
+
+var sticky = &stickyMarks{until: make(map[string]time.Time)}
+
+// stickyIdentity 用户与请求标识，由 WithStickyIdentity 复制到不依赖 gin.Context 的 context 中
+type stickyIdentity struct {
+	userID    int
+	requestID string
+}
+
+type stickyIdentityKey struct{}
+
+// WithStickyIdentity 将 from（通常为 *gin.Context）中的用户与请求标识复制到 ctx
+// 用于以请求 context 开启的事务：事务内的写操作同样标记粘滞窗口，又不持有请求结束后会被复用的 gin.Context
+func WithStickyIdentity(ctx, from context.Context) context.Context {
+	userID, _ := from.Value("user_id").(int)
+	requestID, _ := from.Value("request_id").(string)
+	return context.WithValue(ctx, stickyIdentityKey{}, stickyIdentity{userID: userID, requestID: requestID})
+}
+
+// stickyKeys 从 ctx 中提取用户与请求标识，handler 直接传入 *gin.Context 时可读取 JWT、RequestID 中间件设置的值
+func stickyKeys(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	identity, ok := ctx.Value(stickyIdentityKey{}).(stickyIdentity)
+	if !ok {
+		identity.userID, _ = ctx.Value("user_id").(int)
+		identity.requestID, _ = ctx.Value("request_id").(string)
+	}
+	keys := make([]string, 0, 2)
+	if identity.userID > 0 {
+		keys = append(keys, "user:"+strconv.Itoa(identity.userID))
+	}
+	if identity.requestID != "" {
+		keys = append(keys, "req:"+identity.requestID)
+	}
+	return keys
+}
+
+// stickyWindow 粘滞窗口时长，未启用读写分离或配置为负数时为 0
+func stickyWindow() time.Duration {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Database.ReadWriteSeparation || cfg.Database.StickyMaster <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.Database.StickyMaster) * time.Second
+}
+
+func (s *stickyMarks) mark(ctx context.Context) {
+	window := stickyWindow()
+	if window == 0 {
+		return
+	}
+	keys := stickyKeys(ctx)
+	if len(keys) == 0 {
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		s.until[key] = now.Add(window)
+	}
+	// 定期清理过期标记，避免请求 ID 无限累积
+	if now.After(s.sweepAt) {
+		for key, until := range s.until {
+			if now.After(until) {
+				delete(s.until, key)
+			}
+		}
+		s.sweepAt = now.Add(window)
+	}
+}
+
+func (s *stickyMarks) active(ctx context.Context) bool {
+	if stickyWindow() == 0 {
+		return false
+	}
+	keys := stickyKeys(ctx)
+	if len(keys) == 0 {
+		return false
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if until, ok := s.until[key]; ok && now.Before(until) {
+			return true
+		}
+	}
+	return false
+}
+
+// registerStickyCallbacks 在主库写操作成功后标记粘滞窗口
+func registerStickyCallbacks(db *gorm.DB) error {
+	markFn := func(tx *gorm.DB) {
+		if tx.Error == nil && !tx.DryRun {
+			sticky.mark(tx.Statement.Context)
+		}
+	}
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("xdb:sticky_create", markFn); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("xdb:sticky_update", markFn); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("xdb:sticky_delete", markFn); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("xdb:sticky_raw", markFn)
+}
@@ -0,0 +1,111 @@
+package xdb
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// TxContextKey 事务在 gin.Context 中的键，middleware.Transaction 通过 c.Set 写入
+// 服务层直接接收 *gin.Context 作为 ctx 时，可通过 ctx.Value(TxContextKey) 取到请求级事务
+const TxContextKey = "xdb:tx"
+
+// TxHooksContextKey 请求级事务的提交后回调在 gin.Context 中的键，middleware.Transaction 通过 c.Set 写入
+const TxHooksContextKey = "xdb:tx_hooks"
+
+type txKey struct{}
+
+type hooksKey struct{}
+
+// TxHooks 事务提交后执行的回调，由开启事务的一方在提交成功后调用 Run，回滚时丢弃
+type TxHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// Add 登记提交后执行的回调
+func (h *TxHooks) Add(fn func()) {
+	h.mu.Lock()
+	h.fns = append(h.fns, fn)
+	h.mu.Unlock()
+}
+
+// Run 按登记顺序执行回调
+func (h *TxHooks) Run() {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+func hooksFromContext(ctx context.Context) *TxHooks {
+	if hooks, ok := ctx.Value(hooksKey{}).(*TxHooks); ok {
+		return hooks
+	}
+	if hooks, ok := ctx.Value(TxHooksContextKey).(*TxHooks); ok {
+		return hooks
+	}
+	return nil
+}
+
+// AfterCommit 在 ctx 中的事务提交后执行 fn，回滚时不执行；ctx 中没有事务时立即执行
+// 用于清除缓存等提交前不能做的操作：提交前清除的缓存可能被其他请求以提交前的数据重新加载
+func AfterCommit(ctx context.Context, fn func()) {
+	if TxFromContext(ctx) != nil {
+		if hooks := hooksFromContext(ctx); hooks != nil {
+			hooks.Add(fn)
+			return
+		}
+	}
+	fn()
+}
+
+// WithTx 返回携带事务的 context
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext 获取 ctx 中的事务，不存在时返回 nil
+func TxFromContext(ctx context.Context) *gorm.DB {
+	if ctx == nil {
+		return nil
+	}
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	if tx, ok := ctx.Value(TxContextKey).(*gorm.DB); ok {
+		return tx
+	}
+	return nil
+}
+
+// Transaction 在事务中执行 fn，事务随 ctx 传递，fn 内通过 ctx 获取的连接（WriteDB/ReadDB）都在同一事务中
+// fn 返回错误或 panic 时回滚，否则提交；ctx 中已有事务时以保存点嵌套执行
+// fn 内通过 AfterCommit 登记的回调在提交后执行，嵌套执行时随外层事务提交
+func Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// 嵌套时沿用外层事务开启时绑定的 context
+	db := TxFromContext(ctx)
+	if db == nil {
+		db = GetDB().WithContext(ctx)
+	}
+	hooks := &TxHooks{}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(WithTx(ctx, tx), hooksKey{}, hooks))
+	}); err != nil {
+		return err
+	}
+	AfterCommit(ctx, hooks.Run)
+	return nil
+}
+
+// WriteDB 获取写操作连接（已绑定 ctx），ctx 中有事务时返回事务（沿用事务开启时绑定的 context）
+func WriteDB(ctx context.Context) *gorm.DB {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return GetDB().WithContext(ctx)
+}
@@ -1,13 +1,16 @@
 package xlog
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"webgos/internal/config"
 
@@ -48,63 +51,144 @@ type log struct {
 }
 
 var Xlogger *log
-var logLevel logger.LogLevel = logger.Info
 
-// 是否开启访问日志
-var logAccess bool
+// 日志级别与访问日志开关支持配置热更新，使用原子变量保证并发读写安全
+var (
+	logLevel      atomic.Int64 // 业务日志级别（logger.LogLevel）
+	logAccess     atomic.Bool  // 是否开启访问日志
+	sqlLevel      atomic.Int64 // SQL日志级别（logger.LogLevel）
+	subscribeOnce sync.Once
+)
 
 // 使用Xlogger创建一个GORM日志实例
+// 返回的实例在SQL日志级别热更新后自动生效，无需重建数据库连接
 func NewGormLogger() logger.Interface {
 	if Xlogger == nil {
 		panic("Xlogger is not initialized. Please call InitLogger first.")
 	}
+	return gormLogger{}
+}
+
+// GORM 日志格式，与 gorm logger.New 开启 Colorful 时一致
+const (
+	gormSlowThreshold = 200 * time.Millisecond
+	gormInfoStr       = logger.Green + "%s\n" + logger.Reset + logger.Green + "[info] " + logger.Reset
+	gormWarnStr       = logger.BlueBold + "%s\n" + logger.Reset + logger.Magenta + "[warn] " + logger.Reset
+	gormErrStr        = logger.Magenta + "%s\n" + logger.Reset + logger.Red + "[error] " + logger.Reset
+	gormTraceStr      = logger.Green + "%s\n" + logger.Reset + logger.Yellow + "[%.3fms] " + logger.BlueBold + "[rows:%v]" + logger.Reset + " %s"
+	gormTraceWarnStr  = logger.Green + "%s " + logger.Yellow + "%s\n" + logger.Reset + logger.RedBold + "[%.3fms] " + logger.Yellow + "[rows:%v]" + logger.Magenta + " %s" + logger.Reset
+	gormTraceErrStr   = logger.RedBold + "%s " + logger.MagentaBold + "%s\n" + logger.Reset + logger.Yellow + "[%.3fms] " + logger.BlueBold + "[rows:%v]" + logger.Reset + " %s"
+)
+
+// gormLogger 按当前SQL日志级别输出到 Xlogger
+// 不能包装 logger.New 返回的实例，否则 utils.FileWithLineNum 会把包装层识别为调用方
+type gormLogger struct {
+	level *logger.LogLevel // LogMode 指定的固定级别（如 db.Debug()），为 nil 时跟随配置
+}
+
+func (l gormLogger) currentLevel() logger.LogLevel {
+	if l.level != nil {
+		return *l.level
+	}
+	return logger.LogLevel(sqlLevel.Load())
+}
+
+func (l gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return gormLogger{level: &level}
+}
+
+func (l gormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.currentLevel() >= logger.Info {
+		Xlogger.Printf(gormInfoStr+msg, append([]any{sqlCaller()}, data...)...)
+	}
+}
 
-	globalConfig := config.GlobalConfig
-	sqllogLevel := logger.Info
-	switch globalConfig.Log.LevelSQL {
+func (l gormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.currentLevel() >= logger.Warn {
+		Xlogger.Printf(gormWarnStr+msg, append([]any{sqlCaller()}, data...)...)
+	}
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.currentLevel() >= logger.Error {
+		Xlogger.Printf(gormErrStr+msg, append([]any{sqlCaller()}, data...)...)
+	}
+}
+
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	level := l.currentLevel()
+	if level <= logger.Silent {
+		return
+	}
+
+	elapsed := float64(time.Since(begin).Nanoseconds()) / 1e6
+	switch {
+	case err != nil && level >= logger.Error:
+		sql, rows := fc()
+		Xlogger.Printf(gormTraceErrStr, sqlCaller(), err, elapsed, rowsOf(rows), sql)
+	case time.Since(begin) > gormSlowThreshold && level >= logger.Warn:
+		sql, rows := fc()
+		slowLog := fmt.Sprintf("SLOW SQL >= %v", gormSlowThreshold)
+		Xlogger.Printf(gormTraceWarnStr, sqlCaller(), slowLog, elapsed, rowsOf(rows), sql)
+	case level == logger.Info:
+		sql, rows := fc()
+		Xlogger.Printf(gormTraceStr, sqlCaller(), elapsed, rowsOf(rows), sql)
+	}
+}
+
+// sqlCaller 返回发起 SQL 的业务代码位置，跳过 GORM 内部与本日志包的调用栈
+func sqlCaller() string {
+	pcs := [32]uintptr{}
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "gorm.io/") && !strings.HasPrefix(frame.Function, "webgos/internal/xlog.") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func rowsOf(rows int64) any {
+	if rows == -1 {
+		return "-"
+	}
+	return rows
+}
+
+// parseLevel 解析日志级别配置（严格大小写），无法识别时使用 Info
+func parseLevel(level string) logger.LogLevel {
+	switch level {
 	case "Error":
-		sqllogLevel = logger.Error
+		return logger.Error
 	case "Warn":
-		sqllogLevel = logger.Warn
-	case "Info":
-		sqllogLevel = logger.Info
+		return logger.Warn
 	case "Silent":
-		sqllogLevel = logger.Silent
+		return logger.Silent
 	default:
-		sqllogLevel = logger.Info
+		return logger.Info
 	}
+}
 
-	gormLogger := logger.New(Xlogger, logger.Config{
-		SlowThreshold:             200 * time.Millisecond,
-		LogLevel:                  sqllogLevel,
-		IgnoreRecordNotFoundError: false,
-		ParameterizedQueries:      false,
-		Colorful:                  true,
-	})
-	return gormLogger
+// applyConfig 应用可热更新的日志配置：日志级别、访问日志开关、SQL日志级别
+func applyConfig(cfg *config.Config) {
+	logLevel.Store(int64(parseLevel(cfg.Log.Level)))
+	logAccess.Store(cfg.Log.Access)
+	sqlLevel.Store(int64(parseLevel(cfg.Log.LevelSQL)))
 }
 
 // InitLogger 创建一个新的日志实例
 func InitLogger() error {
 	logDir := "./logs" // 默认日志目录
-	logAccess = true   // 默认开启访问日志
 	isDebug := true
 
-	config := config.GlobalConfig
-	if config != nil {
-		logDir = config.Runtime.Dir + "/logs"
-		logAccess = config.Log.Access
-		isDebug = config.Server.Mode == "debug"
-		switch config.Log.Level {
-		case "Error":
-			logLevel = logger.Error
-		case "Warn":
-			logLevel = logger.Warn
-		case "Info":
-			logLevel = logger.Info
-		default:
-			logLevel = logger.Info
-		}
+	cfg := config.Get()
+	if cfg != nil {
+		logDir = cfg.Runtime.Dir + "/logs"
+		isDebug = cfg.Server.Mode == "debug"
 	}
 
 	if err := os.MkdirAll(logDir, 0755); err != nil {
@@ -120,27 +204,43 @@ func InitLogger() error {
 		logDir:    logDir,
 	}
 
+	if cfg != nil {
+		applyConfig(cfg)
+	} else {
+		// 未加载配置时（如单元测试）使用默认值
+		logLevel.Store(int64(logger.Info))
+		logAccess.Store(true)
+		sqlLevel.Store(int64(logger.Info))
+	}
+
+	// 订阅配置热更新，重复初始化时只订阅一次
+	subscribeOnce.Do(func() {
+		config.Subscribe("xlog", func(_, cfg *config.Config) {
+			applyConfig(cfg)
+		})
+	})
+
 	go Xlogger.flushLoop() // 启动后台刷新协程
 	return nil
 }
 
 // Info 记录信息级别日志
 func Info(format string, v ...any) {
-	if logLevel >= logger.Info {
+	if logger.LogLevel(logLevel.Load()) >= logger.Info {
 		Xlogger.enqueue("INFO", format, v...)
 	}
 }
 
 // Warn 记录警告级别日志
 func Warn(format string, v ...any) {
-	if logLevel >= logger.Warn {
+	if logger.LogLevel(logLevel.Load()) >= logger.Warn {
 		Xlogger.enqueue("WARN", format, v...)
 	}
 }
 
 // Error 记录错误级别日志
 func Error(format string, v ...any) {
-	if logLevel >= logger.Error {
+	if logger.LogLevel(logLevel.Load()) >= logger.Error {
 		Xlogger.enqueue("ERROR", format, v...)
 	}
 }
@@ -152,7 +252,7 @@ func Debug(format string, v ...any) {
 
 // Access 记录访问日志
 func Access(format string, v ...any) {
-	if logAccess {
+	if logAccess.Load() {
 		Xlogger.enqueue("ACCESS", format, v...)
 	}
 }
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webgos/internal/cache"
+	"webgos/internal/dto"
+	"webgos/internal/middleware"
+	"webgos/internal/models"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	ctx := context.Background()
+	rbac := services.NewRBACService()
+	keys := services.NewAPIKeyService()
+
+	// 服务账号拥有查询与修改产品的权限
+	scanner := createTestUser(t, "scanner")
+	perms := []models.RBACPermission{
+		{Name: "/api/products#GET", Path: "/api/products", Method: "GET"},
+		{Name: "/api/products#POST", Path: "/api/products", Method: "POST"},
+		{Name: "/api/users#GET", Path: "/api/users", Method: "GET"},
+	}
+	require.NoError(t, xdb.GetDB().Create(&perms).Error)
+	menu, err := services.NewMenuService().AddMenu(ctx, dto.MenuDTO{Name: "Product", Path: "/product", Type: "menu", Status: 1})
+	require.NoError(t, err)
+	require.NoError(t, services.NewMenuService().AssignPermissionsToMenu(ctx, menu.ID, []int{perms[0].ID, perms[1].ID}))
+	role, err := rbac.AddRole(ctx, dto.AddRoleDTO{Name: "扫码枪", Status: 1, MenuIDs: []int{menu.ID}})
+	require.NoError(t, err)
+	require.NoError(t, rbac.AssignRolesToUser(ctx, scanner.ID, []int{role.ID}))
+
+	// 权限范围只能是用户拥有的权限点
+	_, err = keys.Create(ctx, scanner.ID, 1, dto.CreateAPIKeyDTO{Name: "扫码枪", Scopes: []string{"/api/users#GET"}})
+	assert.ErrorContains(t, err, "没有该权限")
+	_, err = keys.Create(ctx, scanner.ID, 1, dto.CreateAPIKeyDTO{Name: "扫码枪", Scopes: []string{"/api/nothing#GET"}})
+	assert.ErrorContains(t, err, "权限点不存在")
+	created, err := keys.Create(ctx, scanner.ID, 1, dto.CreateAPIKeyDTO{Name: "扫码枪", Scopes: []string{"/api/products#GET"}, ExpiresIn: 30})
+	require.NoError(t, err)
+	assert.Equal(t, created.Key[:len(created.Prefix)], created.Prefix)
+	require.NotNil(t, created.ExpiresAt)
+	var stored models.APIKey
+	require.NoError(t, xdb.GetDB().Take(&stored, created.ID).Error)
+	assert.Len(t, stored.KeyHash, 64)
+
+	// 只拥有创建权限与只读权限的管理员，不能为超管或其他用户创建超出自己权限的密钥
+	permGrant := models.RBACPermission{Name: "/api/system/api_key#POST", Path: "/api/system/api_key", Method: "POST"}
+	require.NoError(t, xdb.GetDB().Create(&permGrant).Error)
+	adminMenu, err := services.NewMenuService().AddMenu(ctx, dto.MenuDTO{Name: "APIKey", Path: "/api_key", Type: "menu", Status: 1})
+	require.NoError(t, err)
+	require.NoError(t, services.NewMenuService().AssignPermissionsToMenu(ctx, adminMenu.ID, []int{permGrant.ID, perms[0].ID}))
+	adminRole, err := rbac.AddRole(ctx, dto.AddRoleDTO{Name: "密钥管理员", Status: 1, MenuIDs: []int{adminMenu.ID}})
+	require.NoError(t, err)
+	keyAdmin := createTestUser(t, "keyadmin")
+	require.NoError(t, rbac.AssignRolesToUser(ctx, keyAdmin.ID, []int{adminRole.ID}))
+	super := createTestUser(t, "super")
+	_, err = keys.Create(ctx, super.ID, keyAdmin.ID, dto.CreateAPIKeyDTO{Name: "提权", Scopes: []string{"/api/users#GET"}})
+	assert.ErrorIs(t, err, services.ErrAPIKeyForbidden)
+	_, err = keys.Create(ctx, scanner.ID, keyAdmin.ID, dto.CreateAPIKeyDTO{Name: "提权", Scopes: []string{"/api/products#POST"}})
+	assert.ErrorContains(t, err, "操作人没有该权限")
+	readonly, err := keys.Create(ctx, scanner.ID, keyAdmin.ID, dto.CreateAPIKeyDTO{Name: "只读", Scopes: []string{"/api/products#GET"}})
+	require.NoError(t, err)
+	require.NoError(t, keys.Revoke(ctx, 0, readonly.ID))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api", middleware.JWT(), middleware.RBAC())
+	api.GET("/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.POST("/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.GET("/api_key", middleware.NoAPIKey(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	request := func(method, path, header, value string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 范围内的接口可访问；用户有权限但不在范围内的接口、禁止密钥访问的接口被拒绝
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/products", "X-API-Key", created.Key))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/products", "Authorization", "ApiKey "+created.Key))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/api/products", "X-API-Key", created.Key))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/api_key", "X-API-Key", created.Key))
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/api/products", "X-API-Key", created.Key+"x"))
+	list, err := keys.List(ctx, scanner.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, []string{"/api/products#GET"}, list[0].ScopeNames)
+	assert.NotNil(t, list[0].LastUsedAt)
+
+	// 用户失去权限后密钥随之受限，与 RBAC 取交集
+	require.NoError(t, rbac.AssignRolesToUser(ctx, scanner.ID, []int{}))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/products", "X-API-Key", created.Key))
+	require.NoError(t, rbac.AssignRolesToUser(ctx, scanner.ID, []int{role.ID}))
+
+	// 用户被禁用或密钥删除后立即失效
+	users := services.NewUserService()
+	require.NoError(t, users.SetUserStatus(ctx, scanner.ID, 0))
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/api/products", "X-API-Key", created.Key))
+	require.NoError(t, users.SetUserStatus(ctx, scanner.ID, models.UserStatusEnabled))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/products", "X-API-Key", created.Key))
+	assert.ErrorIs(t, keys.Revoke(ctx, scanner.ID+1, created.ID), services.ErrAPIKeyNotFound)
+	require.NoError(t, keys.Revoke(ctx, scanner.ID, created.ID))
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/api/products", "X-API-Key", created.Key))
+
+	// 修改或重置密码、强制下线后密钥全部删除
+	for i, revoke := range []func() error{
+		func() error { return users.ChangePassword(ctx, scanner.ID, "", "123456", "abcdef") },
+		func() error { return users.ResetPassword(ctx, "scanner", "123456") },
+		func() error {
+			revoked, err := keys.RevokeAll(ctx, scanner.ID)
+			assert.Equal(t, 1, revoked)
+			return err
+		},
+	} {
+		key, err := keys.Create(ctx, scanner.ID, 1, dto.CreateAPIKeyDTO{Name: "扫码枪", Scopes: []string{"/api/products#GET"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/products", "X-API-Key", key.Key), i)
+		require.NoError(t, revoke())
+		assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/api/products", "X-API-Key", key.Key), i)
+	}
+}
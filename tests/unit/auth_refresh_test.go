@@ -0,0 +1,64 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"webgos/internal/cache"
+	"webgos/internal/models"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthRefreshRotation(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	ctx := context.Background()
+	auth := services.NewAuthService()
+	createTestUser(t, "dave")
+
+	pair, err := auth.Login(ctx, "dave", "123456", services.ClientInfo{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 15*60, pair.ExpiresIn)
+	assert.EqualValues(t, 168*3600, pair.RefreshExpiresIn)
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+
+	// 数据库只保存刷新令牌的哈希
+	var count int64
+	xdb.GetDB().Model(&models.RefreshToken{}).Where("token_hash = ?", pair.RefreshToken).Count(&count)
+	assert.Zero(t, count)
+
+	rotated, err := auth.Refresh(ctx, pair.RefreshToken, services.ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEqual(t, pair.RefreshToken, rotated.RefreshToken)
+	_, err = auth.ValidateToken(ctx, rotated.AccessToken)
+	require.NoError(t, err)
+
+	// 重用已轮换的刷新令牌：注销整个家族，包括新签发的访问令牌与刷新令牌
+	_, err = auth.Refresh(ctx, pair.RefreshToken, services.ClientInfo{})
+	assert.ErrorIs(t, err, services.ErrRefreshTokenReused)
+	_, err = auth.ValidateToken(ctx, rotated.AccessToken)
+	assert.Error(t, err)
+	_, err = auth.Refresh(ctx, rotated.RefreshToken, services.ClientInfo{})
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	_, err = auth.Refresh(ctx, "unknown", services.ClientInfo{})
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	// 登出只注销当前登录，其他登录不受影响
+	first, err := auth.Login(ctx, "dave", "123456", services.ClientInfo{})
+	require.NoError(t, err)
+	second, err := auth.Login(ctx, "dave", "123456", services.ClientInfo{})
+	require.NoError(t, err)
+	require.NoError(t, auth.Logout(ctx, first.AccessToken, ""))
+	_, err = auth.ValidateToken(ctx, first.AccessToken)
+	assert.Error(t, err)
+	_, err = auth.Refresh(ctx, first.RefreshToken, services.ClientInfo{})
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	_, err = auth.ValidateToken(ctx, second.AccessToken)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"webgos/internal/cache"
+	"webgos/internal/dto"
+	"webgos/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenVersionRevocation(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	ctx := context.Background()
+	auth := services.NewAuthService()
+	users := services.NewUserService()
+	rbac := services.NewRBACService()
+	user := createTestUser(t, "grace")
+	role, err := rbac.AddRole(ctx, dto.AddRoleDTO{Name: "审计", Status: 1})
+	require.NoError(t, err)
+
+	pair, err := auth.Login(ctx, "grace", "123456", services.ClientInfo{})
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+
+	// 角色变更：访问令牌失效，刷新后恢复
+	require.NoError(t, rbac.AssignRolesToUser(ctx, user.ID, []int{role.ID}))
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, services.ErrTokenStale)
+	pair, err = auth.Refresh(ctx, pair.RefreshToken, services.ClientInfo{})
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	// 角色未变化时不影响已签发的令牌
+	require.NoError(t, rbac.AssignRolesToUser(ctx, user.ID, []int{role.ID}))
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+
+	// 修改密码：所有会话失效，需重新登录
+	require.NoError(t, users.ResetPassword(ctx, "grace", "654321"))
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	assert.Error(t, err)
+	_, err = auth.Refresh(ctx, pair.RefreshToken, services.ClientInfo{})
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	// 禁用账号：拒绝登录，已签发的令牌失效；重新启用后可登录
+	pair, err = auth.Login(ctx, "grace", "654321", services.ClientInfo{})
+	require.NoError(t, err)
+	require.NoError(t, users.SetUserStatus(ctx, user.ID, 0))
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	assert.Error(t, err)
+	_, err = auth.Refresh(ctx, pair.RefreshToken, services.ClientInfo{})
+	assert.Error(t, err)
+	_, err = auth.Login(ctx, "grace", "654321", services.ClientInfo{})
+	assert.ErrorIs(t, err, services.ErrAccountDisabled)
+
+	require.NoError(t, users.SetUserStatus(ctx, user.ID, 1))
+	_, err = auth.Login(ctx, "grace", "654321", services.ClientInfo{})
+	assert.NoError(t, err)
+}
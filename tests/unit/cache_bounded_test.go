@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"webgos/internal/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evictionLog 记录淘汰回调
+type evictionLog struct {
+	mu      sync.Mutex
+	reasons map[string]cache.EvictReason
+}
+
+func (l *evictionLog) record(key string, _ any, reason cache.EvictReason) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reasons[key] = reason
+}
+
+func (l *evictionLog) get(key string) cache.EvictReason {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reasons[key]
+}
+
+func newBoundedCache(t *testing.T, opts cache.BoundedOptions) (*cache.BoundedCache, *evictionLog) {
+	t.Helper()
+	log := &evictionLog{reasons: map[string]cache.EvictReason{}}
+	opts.OnEvicted = log.record
+	if opts.CleanupInterval == 0 {
+		opts.CleanupInterval = -1
+	}
+	c := cache.NewBoundedCache(opts)
+	t.Cleanup(func() { c.Close() })
+	return c, log
+}
+
+func cached(c cache.ICache, key string) bool {
+	_, ok := c.Get(key)
+	return ok
+}
+
+func TestBoundedCacheEviction(t *testing.T) {
+	t.Run("lru", func(t *testing.T) {
+		c, log := newBoundedCache(t, cache.BoundedOptions{MaxEntries: 3})
+		c.Set("a", 1, time.Minute)
+		c.Set("b", 2, time.Minute)
+		c.Set("c", 3, time.Minute)
+		c.Get("a") // b 成为最久未使用
+		c.Set("d", 4, time.Minute)
+
+		assert.Equal(t, 3, c.Len())
+		assert.False(t, cached(c, "b"))
+		assert.Equal(t, cache.EvictCapacity, log.get("b"))
+		for _, key := range []string{"a", "c", "d"} {
+			assert.True(t, cached(c, key), key)
+		}
+	})
+
+	t.Run("lfu", func(t *testing.T) {
+		c, log := newBoundedCache(t, cache.BoundedOptions{MaxEntries: 3, Policy: cache.PolicyLFU})
+		c.Set("a", 1, time.Minute)
+		c.Set("b", 2, time.Minute)
+		c.Set("c", 3, time.Minute)
+		for i := 0; i < 3; i++ {
+			c.Get("a")
+			c.Get("c")
+		}
+		c.Get("b")
+		c.Set("d", 4, time.Minute) // 淘汰访问次数最少的 b，新写入的项不参与本次淘汰
+		assert.Equal(t, 3, c.Len())
+		assert.Equal(t, cache.EvictCapacity, log.get("b"))
+
+		c.Set("e", 5, time.Minute) // d 访问次数最少，先于高频项被淘汰
+		assert.Equal(t, cache.EvictCapacity, log.get("d"))
+		assert.True(t, cached(c, "a"))
+		assert.True(t, cached(c, "c"))
+		assert.True(t, cached(c, "e"))
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		c, _ := newBoundedCache(t, cache.BoundedOptions{MaxBytes: 2048})
+		for i := 0; i < 10; i++ {
+			c.Set(fmt.Sprintf("k%d", i), make([]byte, 400), time.Minute)
+			assert.LessOrEqual(t, c.Bytes(), int64(2048))
+		}
+		assert.Less(t, c.Len(), 10)
+		assert.True(t, cached(c, "k9"))
+
+		// 单个超过预算的值不缓存，覆盖写入按新值重新计算
+		c.Set("huge", make([]byte, 4096), time.Minute)
+		assert.False(t, cached(c, "huge"))
+		before := c.Bytes()
+		c.Set("k9", make([]byte, 10), time.Minute)
+		assert.Less(t, c.Bytes(), before)
+
+		c.Flush()
+		assert.Zero(t, c.Len())
+		assert.Zero(t, c.Bytes())
+	})
+}
+
+func TestBoundedCacheQuotaAndIndex(t *testing.T) {
+	c, log := newBoundedCache(t, cache.BoundedOptions{
+		MaxEntries: 100,
+		Quotas:     map[string]int{"token:": 2, cache.UserPagePrefix: 3},
+	})
+
+	// 前缀配额只在该前缀内淘汰
+	c.Set("other", 1, time.Minute)
+	c.Set("token:1", 1, time.Minute)
+	c.Set("token:2", 1, time.Minute)
+	c.Set("token:3", 1, time.Minute)
+	assert.Equal(t, cache.EvictQuota, log.get("token:1"))
+	assert.True(t, cached(c, "token:2"))
+	assert.True(t, cached(c, "other"))
+
+	// 配额淘汰后前缀索引保持一致：DeleteByPrefix 只删除仍存在的键，再写入的键可被再次按前缀删除
+	var keys []string
+	for i := 0; i < 5; i++ {
+		key := cache.GenerateKey(cache.UserPagePrefix, map[string]int{"page": i})
+		keys = append(keys, key)
+		c.Set(key, i, time.Minute)
+	}
+	assert.Equal(t, cache.EvictQuota, log.get(keys[0]))
+	assert.Equal(t, cache.EvictQuota, log.get(keys[1]))
+	c.DeleteByPrefix(cache.UserPagePrefix)
+	for _, key := range keys {
+		assert.False(t, cached(c, key))
+	}
+	c.Set(keys[0], 0, time.Minute)
+	c.DeleteByPrefix(cache.UserPagePrefix)
+	assert.False(t, cached(c, keys[0]))
+	assert.Equal(t, 3, c.Len())
+}
+
+func TestBoundedCacheExpiry(t *testing.T) {
+	c, log := newBoundedCache(t, cache.BoundedOptions{MaxEntries: 2, CleanupInterval: 20 * time.Millisecond})
+	c.Set("short", 1, 30*time.Millisecond)
+	c.Set("forever", 1, cache.NoExpiration)
+	assert.Eventually(t, func() bool { return log.get("short") == cache.EvictExpired }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, c.Len())
+
+	// 容量不足时淘汰的项已过期，按过期原因回调
+	c2, log2 := newBoundedCache(t, cache.BoundedOptions{MaxEntries: 2})
+	c2.Set("stale", 1, 10*time.Millisecond)
+	c2.Set("fresh", 1, time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	c2.Set("new", 1, time.Minute)
+	assert.Equal(t, cache.EvictExpired, log2.get("stale"))
+	assert.True(t, cached(c2, "fresh"))
+
+	// 主动删除不触发回调
+	c2.Delete("fresh")
+	assert.Empty(t, log2.get("fresh"))
+	value, ok := c2.Get("new")
+	require.True(t, ok)
+	assert.Equal(t, 1, value)
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"testing"
+	"time"
+	"webgos/internal/cache"
+	"webgos/internal/xdb"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNodes 创建共享同一总线的两个实例，各自持有独立的本地缓存
+func newNodes(t *testing.T, newBus func() cache.Bus) (a, b *cache.BroadcastCache) {
+	t.Helper()
+	var err error
+	a, err = cache.NewBroadcastCache(cache.NewMemoryCache(), newBus(), "node-a")
+	require.NoError(t, err)
+	b, err = cache.NewBroadcastCache(cache.NewMemoryCache(), newBus(), "node-b")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return a, b
+}
+
+func assertPropagates(t *testing.T, a, b *cache.BroadcastCache) {
+	t.Helper()
+	cached := func(c cache.ICache, key string) bool {
+		_, ok := c.Get(key)
+		return ok
+	}
+	pageKey := cache.GenerateKey(cache.UserPagePrefix, map[string]int{"page": 1})
+	for _, c := range []cache.ICache{a, b} {
+		c.Set("permissions:1", map[string]bool{"x": true}, time.Minute)
+		c.Set(pageKey, 1, time.Minute)
+		c.Set("other", 1, time.Minute)
+	}
+
+	a.Delete("permissions:1")
+	assert.Eventually(t, func() bool { return !cached(b, "permissions:1") }, 2*time.Second, 10*time.Millisecond)
+
+	b.DeleteByPrefix(cache.UserPagePrefix)
+	assert.Eventually(t, func() bool { return !cached(a, pageKey) }, 2*time.Second, 10*time.Millisecond)
+
+	// 自身发出的事件被忽略：删除后立即重新写入的值不会被回声事件清除
+	a.Set("permissions:1", map[string]bool{"y": true}, time.Minute)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, cached(a, "permissions:1"))
+	assert.True(t, cached(a, "other"))
+
+	a.Flush()
+	assert.Eventually(t, func() bool { return !cached(b, "other") }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestCacheBusMemory(t *testing.T) {
+	bus := cache.NewMemoryBus()
+	a, b := newNodes(t, func() cache.Bus { return bus })
+	assertPropagates(t, a, b)
+}
+
+func TestCacheBusDB(t *testing.T) {
+	setupSQLite(t, "")
+	a, b := newNodes(t, func() cache.Bus { return cache.NewDBBus(xdb.GetDB(), 20*time.Millisecond) })
+	assertPropagates(t, a, b)
+}
+
+func TestCacheBusRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	a, b := newNodes(t, func() cache.Bus { return cache.NewRedisBus(client, "test:cache:invalidate") })
+	assertPropagates(t, a, b)
+}
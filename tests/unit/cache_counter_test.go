@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"webgos/internal/cache"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheIncr(t *testing.T) {
+	redisCache, mr := newRedisCache(t, cache.GobCodec{})
+	bounded := cache.NewBoundedCache(cache.BoundedOptions{MaxEntries: 100, CleanupInterval: -1})
+	t.Cleanup(func() { bounded.Close() })
+
+	for name, c := range map[string]cache.ICache{
+		"memory":  cache.NewMemoryCache(),
+		"bounded": bounded,
+		"redis":   redisCache,
+		"stats":   cache.NewStatsCache(cache.NewMemoryCache(), cache.NewStats()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Zero(t, c.Counter("hits"))
+
+			// 并发递增不丢失计数
+			var wg sync.WaitGroup
+			for range 50 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					c.Incr("hits", time.Minute)
+				}()
+			}
+			wg.Wait()
+			assert.EqualValues(t, 50, c.Counter("hits"))
+			assert.EqualValues(t, 51, c.Incr("hits", time.Minute))
+
+			c.Delete("hits")
+			assert.Zero(t, c.Counter("hits"))
+			assert.EqualValues(t, 1, c.Incr("hits", time.Minute))
+		})
+	}
+
+	// 每次递增重新计算过期时间
+	redisCache.Incr("expiring", time.Second)
+	mr.FastForward(800 * time.Millisecond)
+	redisCache.Incr("expiring", time.Second)
+	mr.FastForward(800 * time.Millisecond)
+	assert.EqualValues(t, 2, redisCache.Counter("expiring"))
+	mr.FastForward(time.Second)
+	assert.Zero(t, redisCache.Counter("expiring"))
+}
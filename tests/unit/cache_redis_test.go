@@ -0,0 +1,137 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisCache(t *testing.T, codec cache.Codec) (*cache.RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	c := cache.NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}), codec, "test:")
+	t.Cleanup(func() { c.Close() })
+	return c, mr
+}
+
+func TestRedisCacheBasic(t *testing.T) {
+	c, mr := newRedisCache(t, nil)
+
+	c.Set("token", true, time.Second)
+	v, ok := c.Get("token")
+	require.True(t, ok)
+	assert.Equal(t, true, v)
+	assert.True(t, mr.Exists("test:token"), "keys are namespaced by prefix")
+
+	mr.FastForward(2 * time.Second)
+	_, ok = c.Get("token")
+	assert.False(t, ok)
+
+	c.Set("default", 1, cache.DefaultExpiration)
+	assert.Equal(t, 5*time.Minute, mr.TTL("test:default"))
+	c.Set("forever", 1, cache.NoExpiration)
+	assert.Zero(t, mr.TTL("test:forever"))
+
+	c.Delete("default")
+	_, ok = c.Get("default")
+	assert.False(t, ok)
+
+	// Flush 只清理当前命名空间
+	require.NoError(t, mr.Set("other:key", "x"))
+	c.Flush()
+	_, ok = c.Get("forever")
+	assert.False(t, ok)
+	assert.True(t, mr.Exists("other:key"))
+}
+
+func TestRedisCacheDeleteByPrefix(t *testing.T) {
+	c, mr := newRedisCache(t, nil)
+
+	// "前缀@哈希" 格式的键通过索引集合删除
+	k1 := cache.GenerateKey(cache.UserPagePrefix, map[string]int{"page": 1})
+	k2 := cache.GenerateKey(cache.UserPagePrefix, map[string]int{"page": 2})
+	c.Set(k1, 1, time.Second)
+	c.Set(k2, 2, cache.NoExpiration)
+	assert.Zero(t, mr.TTL("test:idx:"+cache.UserPagePrefix), "index lives as long as its longest-lived key")
+	c.DeleteByPrefix(cache.UserPagePrefix)
+	_, ok1 := c.Get(k1)
+	_, ok2 := c.Get(k2)
+	assert.False(t, ok1 || ok2)
+	assert.False(t, mr.Exists("test:idx:"+cache.UserPagePrefix))
+
+	// 其他键通过 SCAN 匹配删除，通配符按字面匹配
+	for i := 1; i <= 3; i++ {
+		c.Set(fmt.Sprintf("%s:%d", cache.PermissionPrefix, i), i, time.Minute)
+	}
+	c.Set("permissions*x", 1, time.Minute)
+	c.Set("menus:1", 1, time.Minute)
+	c.DeleteByPrefix(cache.PermissionPrefix + ":")
+	_, ok := c.Get(cache.PermissionPrefix + ":2")
+	assert.False(t, ok)
+	_, ok = c.Get("permissions*x")
+	assert.True(t, ok)
+	_, ok = c.Get("menus:1")
+	assert.True(t, ok)
+}
+
+func TestRedisCacheCodecs(t *testing.T) {
+	permissions := map[string]bool{"/api/user/info#GET": true}
+
+	// JSON 解码为通用类型，GetValue 转换回具体类型
+	c, _ := newRedisCache(t, cache.JSONCodec{})
+	old := cache.SetCache(c)
+	t.Cleanup(func() { cache.SetCache(old) })
+	c.Set("permissions:1", permissions, time.Minute)
+	v, _ := c.Get("permissions:1")
+	assert.IsType(t, map[string]any{}, v)
+	var got map[string]bool
+	require.True(t, cache.GetValue("permissions:1", &got))
+	assert.Equal(t, permissions, got)
+
+	// gob 保留写入时的具体类型
+	g, _ := newRedisCache(t, cache.GobCodec{})
+	g.Set("permissions:1", permissions, time.Minute)
+	v, ok := g.Get("permissions:1")
+	require.True(t, ok)
+	assert.Equal(t, permissions, v)
+}
+
+func TestCacheInitRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	path := writeConfigFile(t, fmt.Sprintf(`
+server:
+  port: 8080
+database:
+  dialect: "sqlite"
+  dbname: ":memory:"
+cache:
+  driver: "redis"
+  codec: "gob"
+  redis:
+    addr: %q
+`, mr.Addr()))
+	_, err := config.LoadConfig(path)
+	require.NoError(t, err)
+
+	old := cache.GetCache()
+	require.NoError(t, cache.Init())
+	t.Cleanup(func() {
+		cache.Close()
+		cache.SetCache(old)
+	})
+	require.IsType(t, &cache.StatsCache{}, cache.GetCache())
+	require.IsType(t, &cache.RedisCache{}, cache.GetCache().(*cache.StatsCache).Unwrap())
+	cache.GetCache().Set("k", "v", time.Minute)
+	assert.True(t, mr.Exists("webgos:k"))
+
+	mr.Close()
+	_, ok := cache.GetCache().Get("k")
+	assert.False(t, ok, "unreachable redis is treated as a cache miss")
+}
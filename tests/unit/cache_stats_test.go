@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webgos/common/json"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/handlers"
+	"webgos/internal/routes"
+	"webgos/internal/utils/code"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findPrefixStats(t *testing.T, snapshot cache.StatsSnapshot, prefix string) cache.PrefixStats {
+	t.Helper()
+	for _, p := range snapshot.Prefixes {
+		if p.Prefix == prefix {
+			return p
+		}
+	}
+	t.Fatalf("prefix %s not found in %+v", prefix, snapshot.Prefixes)
+	return cache.PrefixStats{}
+}
+
+func TestCacheStats(t *testing.T) {
+	stats := cache.NewStats()
+	bounded := cache.NewBoundedCache(cache.BoundedOptions{
+		Quotas:          map[string]int{cache.TokenPrefix: 2},
+		CleanupInterval: -1,
+		OnEvicted:       stats.OnEvicted,
+	})
+	c := cache.NewStatsCache(bounded, stats)
+	t.Cleanup(func() { c.Close() })
+
+	for _, token := range []string{"a", "b", "c"} {
+		c.Set(cache.TokenPrefix+token, true, time.Minute)
+	}
+	c.Get(cache.TokenPrefix + "c")
+	c.Get(cache.TokenPrefix + "a") // 已被配额淘汰
+	pageKey := cache.GenerateKey(cache.UserPagePrefix, map[string]int{"page": 1})
+	c.Set(pageKey, 1, time.Minute)
+	c.DeleteByPrefix(cache.UserPagePrefix)
+	c.Set("custom", 1, time.Minute)
+
+	snapshot, err := stats.Snapshot(context.Background(), c)
+	require.NoError(t, err)
+	token := findPrefixStats(t, snapshot, cache.TokenPrefix)
+	assert.EqualValues(t, 2, token.Entries)
+	assert.EqualValues(t, 3, token.Sets)
+	assert.EqualValues(t, 1, token.Hits)
+	assert.EqualValues(t, 1, token.Misses)
+	assert.EqualValues(t, 1, token.Evictions)
+	assert.InDelta(t, 0.5, token.HitRatio, 0.001)
+
+	page := findPrefixStats(t, snapshot, cache.UserPagePrefix)
+	assert.EqualValues(t, 0, page.Entries)
+	assert.EqualValues(t, 1, page.Deletes)
+	assert.EqualValues(t, 1, findPrefixStats(t, snapshot, "other").Entries)
+	assert.EqualValues(t, 3, snapshot.Total.Entries)
+	assert.EqualValues(t, 5, snapshot.Total.Sets)
+
+	// 登记的业务前缀单独分组
+	cache.RegisterStatsPrefix("report:")
+	assert.Equal(t, "report:", cache.StatsGroup("report:daily"))
+
+	stats.Reset()
+	snapshot, err = stats.Snapshot(context.Background(), c)
+	require.NoError(t, err)
+	assert.Zero(t, snapshot.Total.Sets)
+	assert.EqualValues(t, 3, snapshot.Total.Entries)
+}
+
+func TestCacheAdminEndpoints(t *testing.T) {
+	stats := cache.GetStats()
+	stats.Reset()
+	old := cache.SetCache(cache.NewStatsCache(cache.NewMemoryCache(), stats))
+	t.Cleanup(func() { cache.SetCache(old) })
+
+	pageKey := cache.GenerateKey(cache.UserPagePrefix, map[string]int{"page": 1})
+	cache.GetCache().Set(pageKey, map[string]int{"total": 1}, time.Minute)
+	cache.GetCache().Set(cache.PermissionPrefix+":1", map[string]bool{"/api/x#GET": true}, time.Minute)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/system/cache", handlers.GetCacheStats)
+	r.POST("/api/system/cache/inspect", handlers.InspectCacheKey)
+	r.POST("/api/system/cache/delete_prefix", handlers.DeleteCachePrefix)
+	r.POST("/api/system/cache/flush", handlers.FlushCache)
+
+	call := func(method, path string, body any) map[string]any {
+		t.Helper()
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return resp
+	}
+
+	resp := call(http.MethodPost, "/api/system/cache/inspect", map[string]string{"key": pageKey})
+	require.EqualValues(t, code.OK, resp["code"], resp)
+	entry := resp["data"].(map[string]any)
+	assert.Equal(t, true, entry["found"])
+	assert.Equal(t, cache.UserPagePrefix, entry["group"])
+	assert.Equal(t, map[string]any{"total": float64(1)}, entry["value"])
+
+	resp = call(http.MethodPost, "/api/system/cache/inspect", map[string]string{})
+	assert.NotEqualValues(t, code.OK, resp["code"], "key is required")
+
+	resp = call(http.MethodPost, "/api/system/cache/delete_prefix", map[string]string{"prefix": cache.UserPagePrefix})
+	require.EqualValues(t, code.OK, resp["code"], resp)
+	_, ok := cache.GetCache().Get(pageKey)
+	assert.False(t, ok)
+
+	resp = call(http.MethodGet, "/api/system/cache", nil)
+	require.EqualValues(t, code.OK, resp["code"], resp)
+	total := resp["data"].(map[string]any)["total"].(map[string]any)
+	assert.EqualValues(t, 1, total["entries"])
+	assert.EqualValues(t, 1, total["hits"])
+	assert.EqualValues(t, 1, total["misses"])
+
+	resp = call(http.MethodPost, "/api/system/cache/flush", nil)
+	require.EqualValues(t, code.OK, resp["code"], resp)
+	_, ok = cache.GetCache().Get(cache.PermissionPrefix + ":1")
+	assert.False(t, ok)
+}
+
+func TestCacheAdminPermissionPoints(t *testing.T) {
+	setupSQLite(t, ":memory:")
+	routes.New(config.Get())
+	names := map[string]bool{}
+	for _, route := range routes.Routes() {
+		names[route.Name] = true
+	}
+	for _, name := range []string{
+		"/api/system/cache#GET",
+		"/api/system/cache/inspect#POST",
+		"/api/system/cache/delete_prefix#POST",
+		"/api/system/cache/flush#POST",
+	} {
+		assert.True(t, names[name], name)
+	}
+}
@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webgos/internal/cache"
+	"webgos/internal/dto"
+	"webgos/internal/middleware"
+	"webgos/internal/models"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheInvalidateTags(t *testing.T) {
+	redisCache, _ := newRedisCache(t, cache.JSONCodec{})
+	bounded := cache.NewBoundedCache(cache.BoundedOptions{MaxEntries: 100, CleanupInterval: -1})
+	t.Cleanup(func() { bounded.Close() })
+
+	for name, c := range map[string]cache.ICache{
+		"memory":  cache.NewMemoryCache(),
+		"bounded": bounded,
+		"redis":   redisCache,
+	} {
+		t.Run(name, func(t *testing.T) {
+			pageKey := cache.GenerateKey(cache.UserPagePrefix, map[string]int{"page": 1})
+			c.SetWithTags(pageKey, 1, time.Minute, cache.TagUsers, cache.Tag(cache.TagUser, 42))
+			c.SetWithTags("permissions:42", 1, time.Minute, cache.Tag(cache.TagUser, 42), cache.Tag(cache.TagRole, 3))
+			c.SetWithTags("permissions:7", 1, time.Minute, cache.Tag(cache.TagUser, 7), cache.Tag(cache.TagRole, 3))
+			c.Set("other", 1, time.Minute)
+
+			c.InvalidateTags(cache.Tag(cache.TagUser, 42))
+			assert.False(t, cached(c, pageKey))
+			assert.False(t, cached(c, "permissions:42"))
+			assert.True(t, cached(c, "permissions:7"))
+
+			// 任一标签匹配即删除，未带标签的键不受影响
+			c.InvalidateTags(cache.Tag(cache.TagMenu, 1), cache.Tag(cache.TagRole, 3))
+			assert.False(t, cached(c, "permissions:7"))
+			assert.True(t, cached(c, "other"))
+
+			// 前缀索引与标签索引互不影响
+			c.SetWithTags(pageKey, 2, time.Minute, cache.TagUsers)
+			c.DeleteByPrefix(cache.UserPagePrefix)
+			assert.False(t, cached(c, pageKey))
+			c.InvalidateTags(cache.TagUsers)
+			c.Flush()
+		})
+	}
+
+	// 进程内缓存覆盖写入时替换原有标签
+	for name, c := range map[string]cache.ICache{"memory": cache.NewMemoryCache(), "bounded": bounded} {
+		t.Run(name+"/retag", func(t *testing.T) {
+			c.SetWithTags("k", 1, time.Minute, "a")
+			c.SetWithTags("k", 2, time.Minute, "b")
+			c.InvalidateTags("a")
+			assert.True(t, cached(c, "k"))
+			c.Set("k", 3, time.Minute)
+			c.InvalidateTags("b")
+			assert.True(t, cached(c, "k"))
+		})
+	}
+}
+
+func TestCacheInvalidateTagsBroadcast(t *testing.T) {
+	bus := cache.NewMemoryBus()
+	a, b := newNodes(t, func() cache.Bus { return bus })
+	for _, c := range []cache.ICache{a, b} {
+		c.SetWithTags("permissions:1", 1, time.Minute, cache.Tag(cache.TagRole, 1))
+		c.SetWithTags("permissions:2", 1, time.Minute, cache.Tag(cache.TagRole, 2))
+	}
+	a.InvalidateTags(cache.Tag(cache.TagRole, 1))
+	assert.False(t, cached(b, "permissions:1"))
+	assert.True(t, cached(b, "permissions:2"))
+}
+
+func TestRBACTagInvalidation(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	ctx := context.Background()
+	rbac := services.NewRBACService()
+	menuService := services.NewMenuService()
+
+	user := createTestUser(t, "bob")
+	perm := models.RBACPermission{Name: "/api/reports#GET", Path: "/api/reports", Method: "GET"}
+	require.NoError(t, xdb.GetDB().Create(&perm).Error)
+	menu, err := menuService.AddMenu(ctx, dto.MenuDTO{Name: "Report", Path: "/report", Type: "menu", Status: 1})
+	require.NoError(t, err)
+	role, err := rbac.AddRole(ctx, dto.AddRoleDTO{Name: "报表", Status: 1})
+	require.NoError(t, err)
+	require.NoError(t, rbac.AssignRolesToUser(ctx, user.ID, []int{role.ID}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", user.ID) })
+	r.Use(middleware.RBAC())
+	r.GET("/api/reports", func(c *gin.Context) { c.Status(http.StatusOK) })
+	allowed := func() bool {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/reports", nil))
+		return w.Code == http.StatusOK
+	}
+
+	// 权限被缓存后，角色绑定菜单与菜单绑定权限都通过标签失效该用户的权限缓存
+	assert.False(t, allowed())
+	require.NoError(t, rbac.AssignMenusToRole(ctx, role.ID, []int{menu.ID}))
+	assert.False(t, allowed())
+	require.NoError(t, menuService.AssignPermissionsToMenu(ctx, menu.ID, []int{perm.ID}))
+	assert.True(t, allowed())
+	require.NoError(t, rbac.AssignMenusToRole(ctx, role.ID, nil))
+	assert.False(t, allowed())
+
+	// 用户分页带有页内用户的标签
+	query := dto.UserQuery{Page: 1, PageSize: 10}
+	users, _ := services.NewUserService().UsersPage(ctx, query)
+	require.Len(t, users, 1)
+	user.Nickname = "bobby"
+	user.Password = ""
+	require.NoError(t, services.NewUserService().CreateOrUpdateUser(ctx, user))
+	users, _ = services.NewUserService().UsersPage(ctx, query)
+	assert.Equal(t, "bobby", users[0].Nickname)
+
+	createTestUser(t, "carol")
+	_, total := services.NewUserService().UsersPage(ctx, query)
+	assert.EqualValues(t, 2, total)
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"webgos/internal/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type typedItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTypedCacheGetOrLoad(t *testing.T) {
+	typed := cache.NewTyped[cache.Page[typedItem]](cache.WithCache(cache.NewMemoryCache()), cache.WithTTL(time.Minute))
+	ctx := context.Background()
+
+	// 并发未命中只执行一次 loader
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (cache.Page[typedItem], error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return cache.Page[typedItem]{Items: []typedItem{{ID: 1, Name: "a"}}, Total: 1}, nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			page, err := typed.GetOrLoad(ctx, "page@1", loader)
+			assert.NoError(t, err)
+			assert.EqualValues(t, 1, page.Total)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, calls.Load())
+
+	page, ok := typed.Get("page@1")
+	require.True(t, ok)
+	assert.Equal(t, "a", page.Items[0].Name)
+
+	// 加载错误不缓存
+	boom := errors.New("boom")
+	_, err := typed.GetOrLoad(ctx, "page@2", func(ctx context.Context) (cache.Page[typedItem], error) {
+		return cache.Page[typedItem]{}, boom
+	})
+	assert.ErrorIs(t, err, boom)
+	_, ok = typed.Get("page@2")
+	assert.False(t, ok)
+}
+
+func TestTypedCacheNegative(t *testing.T) {
+	c, _ := newRedisCache(t, cache.JSONCodec{})
+	typed := cache.NewTyped[typedItem](cache.WithCache(c), cache.WithNegativeTTL(time.Minute))
+	ctx := context.Background()
+
+	var calls int
+	notFound := func(ctx context.Context) (typedItem, error) {
+		calls++
+		return typedItem{}, gorm.ErrRecordNotFound
+	}
+	_, err := typed.GetOrLoad(ctx, "item:404", notFound)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = typed.GetOrLoad(ctx, "item:404", notFound)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	assert.Equal(t, 1, calls, "negative result is served from cache")
+	_, ok := typed.Get("item:404")
+	assert.False(t, ok)
+
+	// 删除后重新加载；JSON 解码结果转换回具体类型
+	typed.Delete("item:404")
+	item, err := typed.GetOrLoad(ctx, "item:404", func(ctx context.Context) (typedItem, error) {
+		return typedItem{ID: 404, Name: "created"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "created", item.Name)
+	item, ok = typed.Get("item:404")
+	require.True(t, ok)
+	assert.Equal(t, typedItem{ID: 404, Name: "created"}, item)
+}
@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"webgos/internal/cache"
+	"webgos/internal/cli"
+	"webgos/internal/config"
+	"webgos/internal/models"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLI(t *testing.T) {
+	dbname := filepath.ToSlash(filepath.Join(t.TempDir(), "cli.db"))
+	configPath := writeConfigFile(t, fmt.Sprintf(`
+server:
+  mode: "debug"
+  port: 8080
+database:
+  dialect: "sqlite"
+  dbname: %q
+log:
+  level_sql: "Silent"
+runtime:
+  dir: "."
+super_account: "root"
+`, dbname))
+	t.Cleanup(xdb.CloseDB)
+	old := cache.GetCache()
+	t.Cleanup(func() { cache.SetCache(old) })
+
+	run := func(stdin string, args ...string) (string, error) {
+		var out bytes.Buffer
+		app := &cli.App{Out: &out, In: strings.NewReader(stdin), Serve: func(string) error {
+			t.Fatal("serve must not be called")
+			return nil
+		}}
+		err := app.Run(append([]string{"-c", configPath}, args...))
+		return out.String(), err
+	}
+
+	out, err := run("", "migrate", "up", "--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, out, "[dry-run] up   20260101000000_baseline")
+
+	out, err = run("", "migrate", "up")
+	require.NoError(t, err)
+	assert.Contains(t, out, "up   20260101000000_baseline")
+
+	out, err = run("", "migrate", "status")
+	require.NoError(t, err)
+	assert.Regexp(t, `20260101000000\s+baseline\s+applied`, out)
+
+	// 密码从标准输入读取
+	out, err = run("secret\n", "user", "create", "--super")
+	require.NoError(t, err)
+	assert.Contains(t, out, "user root created")
+	var user models.User
+	require.NoError(t, xdb.GetDB().Where("username = ?", "root").First(&user).Error)
+	assert.NotEqual(t, "secret", user.Password)
+
+	_, err = run("", "user", "create", "--username", "root", "--password", "x")
+	assert.ErrorContains(t, err, "already exists")
+
+	out, err = run("", "rbac", "sync")
+	require.NoError(t, err)
+	assert.Contains(t, out, "synced")
+	var count int64
+	require.NoError(t, xdb.GetDB().Model(&models.RBACPermission{}).Count(&count).Error)
+	assert.Positive(t, count)
+
+	// 手工插入一个不存在对应路由的权限点，prune --dry-run 只列出不删除
+	require.NoError(t, xdb.GetDB().Create(&models.RBACPermission{Name: "/api/removed#GET", Path: "/api/removed", Method: "GET"}).Error)
+	out, err = run("", "rbac", "prune", "--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, out, "[dry-run] pruned /api/removed#GET")
+	out, err = run("", "rbac", "prune")
+	require.NoError(t, err)
+	assert.Contains(t, out, "pruned /api/removed#GET")
+	out, err = run("", "rbac", "prune")
+	require.NoError(t, err)
+	assert.Contains(t, out, "no stale permissions")
+
+	out, err = run("", "routes", "list")
+	require.NoError(t, err)
+	assert.Regexp(t, `GET\s+/api/user/info`, out)
+
+	_, err = run("", "migrate", "down")
+	assert.ErrorContains(t, err, "--to is required")
+	_, err = run("", "bogus")
+	assert.ErrorContains(t, err, "unknown command")
+}
+
+func TestCLIResetPasswordSharedCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	dbname := filepath.ToSlash(filepath.Join(t.TempDir(), "cli.db"))
+	configPath := writeConfigFile(t, fmt.Sprintf(`
+server:
+  mode: "debug"
+  port: 8080
+database:
+  dialect: "sqlite"
+  dbname: %q
+log:
+  level_sql: "Silent"
+runtime:
+  dir: "."
+cache:
+  driver: "redis"
+  redis:
+    addr: %q
+`, dbname, mr.Addr()))
+	t.Cleanup(xdb.CloseDB)
+	old := cache.GetCache()
+	t.Cleanup(func() { cache.SetCache(old) })
+	run := func(args ...string) {
+		t.Helper()
+		app := &cli.App{Out: &bytes.Buffer{}, In: strings.NewReader("")}
+		require.NoError(t, app.Run(append([]string{"-c", configPath}, args...)))
+	}
+	run("migrate", "up")
+	run("user", "create", "--username", "mallory", "--password", "123456")
+
+	// 运行中的服务实例使用同一 Redis，令牌校验结果已缓存
+	cfg := config.Get().Cache
+	codec, err := cache.NewCodec(cfg.Codec)
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	server := cache.NewRedisCache(client, codec, cfg.Redis.Prefix)
+	cache.SetCache(server)
+	ctx := context.Background()
+	auth := services.NewAuthService()
+	pair, err := auth.Login(ctx, "mallory", "123456", services.ClientInfo{IP: "10.0.0.1"})
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+
+	// 命令行进程使用配置的缓存重置密码，运行中的实例立即拒绝旧令牌
+	cache.SetCache(cache.NewMemoryCache())
+	run("user", "reset-password", "--username", "mallory", "--password", "abcdef")
+	cache.SetCache(server)
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	assert.Error(t, err)
+}
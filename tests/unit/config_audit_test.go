@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"testing"
+	"webgos/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func auditRules(cfg *config.Config) map[string]string {
+	rules := make(map[string]string)
+	for _, f := range config.Audit(cfg) {
+		rules[f.Rule] = f.Severity
+	}
+	return rules
+}
+
+func TestConfigAudit(t *testing.T) {
+	t.Run("InsecureDefaults", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.JWT.Secret = config.DefaultJWTSecret
+		cfg.Server.Swag = true
+		cfg.Server.Pprof = true
+		cfg.Server.PprofAddr = ":6060"
+		cfg.CORS.AllowOrigins = []string{"*"}
+		cfg.CORS.AllowCredentials = true
+		cfg.Database.Password = "123456"
+		cfg.Mail.Driver = "log"
+
+		rules := auditRules(cfg)
+		assert.Equal(t, config.SeverityCritical, rules["default-secret"])
+		assert.Equal(t, config.SeverityCritical, rules["public-swagger"])
+		assert.Equal(t, config.SeverityCritical, rules["public-pprof"])
+		assert.Equal(t, config.SeverityCritical, rules["cors-wildcard-credentials"])
+		assert.Equal(t, config.SeverityCritical, rules["empty-super-account"])
+		assert.Equal(t, config.SeverityWarning, rules["weak-db-password"])
+		assert.Equal(t, config.SeverityCritical, rules["mail-not-delivered"])
+	})
+
+	t.Run("SecureConfig", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+		cfg.Server.Host = "127.0.0.1"
+		cfg.Server.Swag = true
+		cfg.Server.Pprof = true
+		cfg.Server.PprofAddr = "localhost:6060"
+		cfg.CORS.AllowOrigins = []string{"https://erp.example.com"}
+		cfg.CORS.AllowCredentials = true
+		cfg.SuperAccount = "super"
+		cfg.Database.Password = "s3cure-Passw0rd"
+
+		findings := config.Audit(cfg)
+		assert.Empty(t, findings, config.FormatFindings(findings))
+	})
+
+	t.Run("ShortSecret", func(t *testing.T) {
+		cfg := &config.Config{SuperAccount: "super"}
+		cfg.JWT.Secret = "short-but-not-default"
+		assert.Equal(t, config.SeverityCritical, auditRules(cfg)["short-secret"])
+	})
+
+	t.Run("WeakMFAKey", func(t *testing.T) {
+		cfg := &config.Config{SuperAccount: "super"}
+		cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+		cfg.MFA.SecretKey = "short"
+		assert.Equal(t, config.SeverityCritical, auditRules(cfg)["weak-mfa-key"])
+	})
+}
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"webgos/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const overlayYAML = `
+server:
+  port: 8080
+database:
+  host: "localhost"
+  port: 5432
+  username: "postgres"
+  password: "from-yaml"
+  dbname: "hserp"
+  dialect: "postgres"
+  slaves:
+    - host: "slave0"
+      port: 5432
+jwt:
+  secret: "from-yaml"
+`
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// useConfig 替换当前配置，测试结束后恢复原快照：content 不为空时加载该 yaml，否则复制当前配置；
+// edit 不为空时在此基础上修改。复制为浅拷贝，修改切片或 map 字段时应整体替换
+func useConfig(t *testing.T, content string, edit func(cfg *config.Config)) *config.Config {
+	t.Helper()
+	old := config.Current()
+	t.Cleanup(func() { config.SetCurrent(old) })
+	if content != "" {
+		_, err := config.LoadConfig(writeConfigFile(t, content))
+		require.NoError(t, err)
+	}
+	require.NotNil(t, config.Current(), "config not loaded")
+	snap := *config.Current()
+	cfg := *snap.Config
+	if edit != nil {
+		edit(&cfg)
+	}
+	snap.Config = &cfg
+	config.SetCurrent(&snap)
+	return &cfg
+}
+
+func sourceOf(path string) string {
+	for _, s := range config.Sources() {
+		if s.Path == path {
+			return s.String()
+		}
+	}
+	return ""
+}
+
+func TestConfigOverlay(t *testing.T) {
+	configPath := writeConfigFile(t, overlayYAML)
+
+	t.Run("EnvAndSecretFile", func(t *testing.T) {
+		secretPath := filepath.Join(t.TempDir(), "db_password")
+		require.NoError(t, os.WriteFile(secretPath, []byte("from-file\n"), 0600))
+
+		t.Setenv("WEBGOS_SERVER_PORT", "9090")
+		t.Setenv("WEBGOS_DATABASE_PASSWORD_FILE", secretPath)
+		t.Setenv("WEBGOS_DATABASE_SLAVES_0_HOST", "slave0-env")
+		t.Setenv("WEBGOS_DATABASE_SLAVES_1_HOST", "slave1-env")
+
+		cfg, err := config.LoadConfig(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.Server.Port)
+		assert.Equal(t, "from-file", cfg.Database.Password)
+		assert.Equal(t, "from-yaml", cfg.JWT.Secret)
+		require.Len(t, cfg.Database.Slaves, 2)
+		assert.Equal(t, "slave0-env", cfg.Database.Slaves[0].Host)
+		assert.Equal(t, 5432, cfg.Database.Slaves[0].Port)
+		assert.Equal(t, "slave1-env", cfg.Database.Slaves[1].Host)
+
+		assert.Equal(t, "env:WEBGOS_SERVER_PORT", sourceOf("server.port"))
+		assert.Equal(t, "file:"+secretPath, sourceOf("database.password"))
+		assert.Equal(t, "yaml", sourceOf("jwt.secret"))
+		assert.Equal(t, "default", sourceOf("database.max_open_conns"))
+	})
+
+	t.Run("ValidateAfterOverlay", func(t *testing.T) {
+		t.Setenv("WEBGOS_DATABASE_HOST", "")
+		t.Setenv("WEBGOS_DATABASE_HEALTH_CHECK_INTERVAL", "-1")
+		_, err := config.LoadConfig(configPath)
+		assert.ErrorContains(t, err, "database host is required")
+		assert.ErrorContains(t, err, "health_check_interval must not be negative")
+	})
+
+	t.Run("ConflictAndInvalidValue", func(t *testing.T) {
+		t.Setenv("WEBGOS_JWT_SECRET", "a")
+		t.Setenv("WEBGOS_JWT_SECRET_FILE", "/nonexistent")
+		t.Setenv("WEBGOS_SERVER_PORT", "abc")
+		_, err := config.LoadConfig(configPath)
+		assert.ErrorContains(t, err, "both set")
+		assert.ErrorContains(t, err, "WEBGOS_SERVER_PORT")
+	})
+}
@@ -0,0 +1,67 @@
+package unit
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"webgos/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigReload(t *testing.T) {
+	// 测试结束后取消订阅，并恢复原配置快照及订阅者应用的日志级别
+	previous := config.Current()
+	configPath := writeConfigFile(t, overlayYAML+"log:\n  level: \"Info\"\n")
+	_, err := config.LoadConfig(configPath)
+	require.NoError(t, err)
+	version := config.Current().Version
+
+	var notified []string
+	unsubscribe := config.Subscribe("test", func(old, new *config.Config) {
+		notified = append(notified, old.Log.Level+"->"+new.Log.Level)
+	})
+	t.Cleanup(func() {
+		unsubscribe()
+		config.SetCurrent(previous)
+	})
+
+	t.Run("HotField", func(t *testing.T) {
+		content := overlayYAML + "log:\n  level: \"Error\"\n"
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+
+		snap, err := config.Reload(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, version+1, snap.Version)
+		assert.Equal(t, "Error", config.Get().Log.Level)
+		assert.Equal(t, []string{"Info->Error"}, notified)
+	})
+
+	t.Run("RestartRequiredField", func(t *testing.T) {
+		content := strings.Replace(overlayYAML, "port: 8080", "port: 8081", 1)
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+
+		_, err := config.Reload(configPath)
+		assert.ErrorContains(t, err, "server.port")
+		assert.Equal(t, 8080, config.Get().Server.Port)
+		assert.Equal(t, version+1, config.Current().Version)
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte("server: ["), 0644))
+		_, err := config.Reload(configPath)
+		assert.Error(t, err)
+		assert.Equal(t, "Error", config.Get().Log.Level)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(overlayYAML+"log:\n  level: \"Warn\"\n"), 0644))
+		called := false
+		config.Subscribe("once", func(_, _ *config.Config) { called = true })()
+		_, err := config.Reload(configPath)
+		require.NoError(t, err)
+		assert.False(t, called)
+		assert.Equal(t, []string{"Info->Error", "Error->Warn"}, notified)
+	})
+}
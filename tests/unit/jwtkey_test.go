@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"webgos/common/json"
+	"webgos/internal/config"
+	"webgos/internal/handlers"
+	"webgos/internal/jwtkey"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair 生成密钥并写入 PEM 文件，返回私钥与公钥文件路径
+func writeKeyPair(t *testing.T, private any) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	privatePath := filepath.Join(dir, "private.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	der, err = x509.MarshalPKIXPublicKey(private.(interface{ Public() crypto.PublicKey }).Public())
+	require.NoError(t, err)
+	publicPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+	return privatePath, publicPath
+}
+
+func loadJWTConfig(t *testing.T, jwtYAML string) {
+	t.Helper()
+	_, err := config.LoadConfig(writeConfigFile(t, fmt.Sprintf(`
+server:
+  port: 8080
+database:
+  dialect: "sqlite"
+  dbname: ":memory:"
+runtime:
+  dir: "."
+jwt:
+%s`, jwtYAML)))
+	require.NoError(t, err)
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaPrivate, rsaPublic := writeKeyPair(t, rsaKey)
+	edPrivate, _ := writeKeyPair(t, edKey)
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Minute).Unix()}
+	}
+
+	loadJWTConfig(t, fmt.Sprintf(`  secret: "shared-secret-shared-secret-shared"
+  keys:
+    - kid: "k1"
+      private_key_file: %q
+`, rsaPrivate))
+	keys, err := jwtkey.Get()
+	require.NoError(t, err)
+	oldToken, err := keys.Sign(claims())
+	require.NoError(t, err)
+	parsed, err := keys.Parse(oldToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "k1", parsed.Header["kid"])
+	assert.Equal(t, "RS256", parsed.Method.Alg())
+
+	// 持有 secret 也无法伪造 HS256 令牌
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString([]byte("shared-secret-shared-secret-shared"))
+	require.NoError(t, err)
+	_, err = keys.Parse(forged, jwt.MapClaims{})
+	assert.Error(t, err)
+
+	// 轮换：新密钥签名，旧密钥只保留公钥用于校验
+	loadJWTConfig(t, fmt.Sprintf(`  signing_key: "k2"
+  keys:
+    - kid: "k1"
+      public_key_file: %q
+    - kid: "k2"
+      private_key_file: %q
+`, rsaPublic, edPrivate))
+	keys, err = jwtkey.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "k2", keys.SigningKey().Kid)
+	_, err = keys.Parse(oldToken, jwt.MapClaims{})
+	assert.NoError(t, err, "token signed by the retired key still verifies")
+	newToken, err := keys.Sign(claims())
+	require.NoError(t, err)
+	parsed, err = keys.Parse(newToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+	// JWKS 公开所有校验公钥
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", handlers.JWKS)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var set jwtkey.JWKS
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "RSA", set.Keys[0].Kty)
+	assert.Equal(t, "k1", set.Keys[0].Kid)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+	assert.Equal(t, "OKP", set.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", set.Keys[1].Crv)
+
+	// 签名密钥必须配置私钥
+	_, err = config.LoadConfig(writeConfigFile(t, fmt.Sprintf(`
+server:
+  port: 8080
+database:
+  dialect: "sqlite"
+  dbname: ":memory:"
+jwt:
+  signing_key: "k1"
+  keys:
+    - kid: "k1"
+      public_key_file: %q
+`, rsaPublic)))
+	assert.ErrorContains(t, err, "has no private_key_file")
+}
@@ -0,0 +1,35 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webgos/internal/config"
+	"webgos/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigIPLimiter(t *testing.T) {
+	useConfig(t, overlayYAML+"limiter:\n  auth_rate: 1\n  auth_capacity: 1\n", nil)
+	engine := gin.New()
+	engine.Use(middleware.ConfigIPLimiter(func(cfg *config.Config) (int, int) {
+		return cfg.Limiter.AuthRate, cfg.Limiter.AuthCapacity
+	}))
+	engine.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	request := func() int {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request())
+	assert.Equal(t, http.StatusInternalServerError, request())
+
+	// 配置更新后按新参数重建令牌桶
+	useConfig(t, "", func(cfg *config.Config) { cfg.Limiter.AuthCapacity = 2 })
+	assert.Equal(t, http.StatusOK, request())
+	assert.Equal(t, http.StatusOK, request())
+	assert.Equal(t, http.StatusInternalServerError, request())
+}
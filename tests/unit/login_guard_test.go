@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"webgos/common/json"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/handlers"
+	"webgos/internal/services"
+	"webgos/internal/utils/code"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginGuard(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	ctx := context.Background()
+	auth := services.NewAuthService()
+	guard := services.NewLoginGuard()
+	createTestUser(t, "heidi")
+	client := services.ClientInfo{IP: "10.0.0.9"}
+
+	// 失败后渐进延迟，延迟期间不校验密码
+	_, err := auth.Login(ctx, "heidi", "wrong-password", client)
+	assert.EqualError(t, err, "密码错误")
+	_, err = auth.Login(ctx, "heidi", "123456", client)
+	var blocked *services.LoginBlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.False(t, blocked.Locked)
+	assert.InDelta(t, 1.0, blocked.RetryAfter.Seconds(), 0.1)
+
+	// 连续失败达到上限后锁定，正确密码也无法登录
+	useConfig(t, "", func(cfg *config.Config) { cfg.Lockout.BaseDelay = -1 })
+	for i := 0; i < 4; i++ {
+		_, err = auth.Login(ctx, "Heidi", "wrong-password", client)
+		require.Error(t, err)
+	}
+	_, err = auth.Login(ctx, "heidi", "123456", client)
+	require.ErrorAs(t, err, &blocked)
+	assert.True(t, blocked.Locked)
+	status := guard.Status(ctx, "heidi")
+	assert.Equal(t, 5, status.Count)
+	assert.False(t, status.LockedUntil.IsZero())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/auth/login", handlers.Login)
+	body, _ := json.Marshal(map[string]string{"username": "heidi", "password": "123456"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.EqualValues(t, code.LoginBlocked, resp["code"])
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// 管理员解锁后可登录，成功登录清除失败计数
+	guard.Unlock(ctx, "heidi")
+	_, err = auth.Login(ctx, "heidi", "123456", client)
+	require.NoError(t, err)
+	assert.Zero(t, guard.Status(ctx, "heidi").Count)
+
+	// 并发失败不丢失计数，恰好达到上限时锁定
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, guard.Fail(ctx, "heidi", ""))
+		}()
+	}
+	wg.Wait()
+	status = guard.Status(ctx, "heidi")
+	assert.Equal(t, 5, status.Count)
+	assert.False(t, status.LockedUntil.IsZero())
+	guard.Unlock(ctx, "heidi")
+
+	// 同一 IP 尝试多个账号，失败次数达到上限
+	useConfig(t, "", func(cfg *config.Config) { cfg.Lockout.IPMaxFailures = 3 })
+	attacker := services.ClientInfo{IP: "10.0.0.66"}
+	for _, name := range []string{"ivan", "judy"} {
+		_, err = auth.Login(ctx, name, "123456", attacker)
+		assert.EqualError(t, err, "用户不存在")
+	}
+	_, err = auth.Login(ctx, "heidi", "bad-password", attacker)
+	assert.ErrorIs(t, err, services.ErrIPBanned)
+}
@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"webgos/common/totp"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/dto"
+	"webgos/internal/mfakey"
+	"webgos/internal/models"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 附录 B 的 SHA1 测试向量，取后 6 位
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for unix, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924"} {
+		code, err := totp.CodeAt(secret, totp.Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, code)
+	}
+
+	now := time.Unix(1111111109, 0)
+	step, ok := totp.Validate(secret, "081804", now.Add(totp.Period*time.Second), 1)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+	_, ok = totp.Validate(secret, "081804", now.Add(2*totp.Period*time.Second), 1)
+	assert.False(t, ok)
+
+	generated, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, generated, 32)
+	assert.Equal(t, "otpauth://totp/webgos:alice@example.com?algorithm=SHA1&digits=6&issuer=webgos&period=30&secret="+generated,
+		totp.URI("webgos", "alice@example.com", generated))
+}
+
+func TestMFALogin(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	useConfig(t, "", func(cfg *config.Config) { cfg.Lockout.BaseDelay = -1 })
+	ctx := context.Background()
+	auth := services.NewAuthService()
+	mfa := services.NewMFAService()
+	client := services.ClientInfo{IP: "10.0.0.7"}
+	codeAt := func(secret string, offset int64) string {
+		code, err := totp.CodeAt(secret, totp.Step(time.Now())+offset)
+		require.NoError(t, err)
+		return code
+	}
+
+	// 启用：生成密钥后使用验证码确认，返回恢复码
+	ivan := createTestUser(t, "ivan")
+	setup, err := mfa.Setup(ctx, ivan.ID)
+	require.NoError(t, err)
+	assert.Contains(t, setup.URI, "secret="+setup.Secret)
+	// 密钥加密保存，只能用当前配置派生的密钥解密
+	var stored models.User
+	require.NoError(t, xdb.GetDB().Select("mfa_secret").Take(&stored, ivan.ID).Error)
+	assert.True(t, mfakey.Sealed(stored.MFASecret))
+	assert.NotContains(t, stored.MFASecret, setup.Secret)
+	opened, err := mfakey.Open(stored.MFASecret)
+	require.NoError(t, err)
+	assert.Equal(t, setup.Secret, opened)
+	_, err = mfa.Confirm(ctx, ivan.ID, "000000")
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+	// 记下确认时使用的验证码，跨过时间步边界后重放仍应被拒绝
+	confirmedCode := codeAt(setup.Secret, -1)
+	recoveryCodes, err := mfa.Confirm(ctx, ivan.ID, confirmedCode)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, 10)
+	_, err = mfa.Setup(ctx, ivan.ID)
+	assert.ErrorIs(t, err, services.ErrMFAAlreadyEnabled)
+
+	// 登录返回挑战令牌，验证码通过后签发令牌；已使用的验证码不能重放
+	_, err = auth.Login(ctx, "ivan", "123456", client)
+	var challenge *services.MFAChallengeError
+	require.ErrorAs(t, err, &challenge)
+	assert.False(t, challenge.SetupRequired)
+	_, err = auth.VerifyMFA(ctx, challenge.ChallengeToken, confirmedCode, client)
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+	assert.Equal(t, 1, services.NewLoginGuard().Status(ctx, "ivan").Count)
+	pair, err := auth.VerifyMFA(ctx, challenge.ChallengeToken, codeAt(setup.Secret, 0), client)
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	assert.Zero(t, services.NewLoginGuard().Status(ctx, "ivan").Count)
+	_, err = auth.VerifyMFA(ctx, challenge.ChallengeToken, codeAt(setup.Secret, 1), client)
+	assert.ErrorIs(t, err, services.ErrInvalidMFAChallenge)
+
+	// 恢复码只能使用一次
+	_, err = auth.Login(ctx, "ivan", "123456", client)
+	require.ErrorAs(t, err, &challenge)
+	_, err = auth.VerifyMFA(ctx, challenge.ChallengeToken, " "+recoveryCodes[0], client)
+	require.NoError(t, err)
+	assert.ErrorIs(t, mfa.Verify(ctx, ivan.ID, recoveryCodes[0]), services.ErrInvalidMFACode)
+	status, err := mfa.Status(ctx, ivan.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 9, status.RecoveryCodesRemaining)
+
+	// 并发提交：同一验证码只有一个请求通过，错误验证码最多校验 5 次
+	verifyConcurrently := func(code string) (passed, wrong int) {
+		_, err := auth.Login(ctx, "ivan", "123456", client)
+		require.ErrorAs(t, err, &challenge)
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := auth.VerifyMFA(ctx, challenge.ChallengeToken, code, client)
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					passed++
+				} else if errors.Is(err, services.ErrInvalidMFACode) {
+					wrong++
+				}
+			}()
+		}
+		wg.Wait()
+		return passed, wrong
+	}
+	passed, _ := verifyConcurrently(codeAt(setup.Secret, 1))
+	assert.Equal(t, 1, passed)
+	_, wrong := verifyConcurrently("000000")
+	assert.LessOrEqual(t, wrong, 5)
+	services.NewLoginGuard().Unlock(ctx, "ivan")
+
+	// 关闭后直接登录
+	require.NoError(t, mfa.Disable(ctx, ivan.ID, recoveryCodes[1]))
+	pair, err = auth.Login(ctx, "ivan", "123456", client)
+	require.NoError(t, err)
+	assert.NotEmpty(t, pair.AccessToken)
+
+	// 角色要求两步验证：未启用的用户登录时绑定验证器，确认后返回令牌与恢复码，且不能关闭
+	rbac := services.NewRBACService()
+	role, err := rbac.AddRole(ctx, dto.AddRoleDTO{Name: "管理员", Status: 1, RequireMFA: true})
+	require.NoError(t, err)
+	judy := createTestUser(t, "judy")
+	require.NoError(t, rbac.AssignRolesToUser(ctx, judy.ID, []int{role.ID}))
+	_, err = auth.Login(ctx, "judy", "123456", client)
+	require.ErrorAs(t, err, &challenge)
+	assert.True(t, challenge.SetupRequired)
+	setup, err = auth.SetupMFA(ctx, challenge.ChallengeToken)
+	require.NoError(t, err)
+	pair, err = auth.VerifyMFA(ctx, challenge.ChallengeToken, codeAt(setup.Secret, 0), client)
+	require.NoError(t, err)
+	assert.Len(t, pair.RecoveryCodes, 10)
+	assert.ErrorIs(t, mfa.Disable(ctx, judy.ID, pair.RecoveryCodes[0]), services.ErrMFARequiredByRole)
+
+	// 管理员重置后需重新绑定
+	require.NoError(t, mfa.Reset(ctx, judy.ID))
+	_, err = auth.Login(ctx, "judy", "123456", client)
+	require.ErrorAs(t, err, &challenge)
+	assert.True(t, challenge.SetupRequired)
+}
@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+	"webgos/internal/mfakey"
+	"webgos/internal/models"
+	"webgos/internal/xdb"
+	"webgos/internal/xdb/migrate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const (
+	baselineVersion = 20260101000000
+	probeVersion    = 29990101000000 // 保证排在所有正式迁移之后
+
+	mfaSecretEncryptionVersion = 20261101000000
+)
+
+var registerProbeOnce sync.Once
+
+func registerProbeMigration() {
+	registerProbeOnce.Do(func() {
+		migrate.Register(migrate.Migration{
+			Version: probeVersion,
+			Name:    "create_migrate_probes",
+			Up: func(tx *gorm.DB) error {
+				return tx.Exec("CREATE TABLE migrate_probes (id INTEGER PRIMARY KEY)").Error
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Exec("DROP TABLE migrate_probes").Error
+			},
+		})
+	})
+}
+
+func statusOf(t *testing.T, version int64) migrate.Status {
+	t.Helper()
+	list, err := migrate.GetStatus(context.Background())
+	require.NoError(t, err)
+	for _, s := range list {
+		if s.Version == version {
+			return s
+		}
+	}
+	t.Fatalf("migration %d not found", version)
+	return migrate.Status{}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	registerProbeMigration()
+	setupSQLite(t, "")
+	ctx := context.Background()
+	hasProbes := func() bool { return xdb.GetDB().Migrator().HasTable("migrate_probes") }
+
+	require.True(t, statusOf(t, baselineVersion).Applied)
+	require.True(t, statusOf(t, probeVersion).Applied)
+
+	// 回滚到探针之前的版本：只回滚探针迁移
+	target := int64(probeVersion - 1)
+	plan, err := migrate.Down(ctx, target, migrate.Options{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+	assert.True(t, hasProbes(), "dry-run must not change schema")
+
+	rolled, err := migrate.Down(ctx, target, migrate.Options{})
+	require.NoError(t, err)
+	require.Len(t, rolled, 1)
+	assert.False(t, hasProbes())
+	assert.False(t, statusOf(t, probeVersion).Applied)
+	assert.True(t, statusOf(t, baselineVersion).Applied)
+
+	pending, err := migrate.Up(ctx, migrate.Options{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.False(t, hasProbes())
+
+	applied, err := migrate.Up(ctx, migrate.Options{})
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.True(t, hasProbes())
+
+	applied, err = migrate.Up(ctx, migrate.Options{})
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestMigrateMFASecretEncryption(t *testing.T) {
+	setupSQLite(t, "")
+	ctx := context.Background()
+	secretOf := func(id int) string {
+		var user models.User
+		require.NoError(t, xdb.GetDB().Unscoped().Select("mfa_secret").Take(&user, id).Error)
+		return user.MFASecret
+	}
+
+	// 回滚后恢复明文，再次执行时加密已有的明文密钥
+	user := createTestUser(t, "mona")
+	plain := "JBSWY3DPEHPK3PXP"
+	sealed, err := mfakey.Seal(plain)
+	require.NoError(t, err)
+	require.NoError(t, xdb.GetDB().Model(user).Update("mfa_secret", sealed).Error)
+	_, err = migrate.Down(ctx, mfaSecretEncryptionVersion-1, migrate.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, plain, secretOf(user.ID))
+
+	_, err = migrate.Up(ctx, migrate.Options{})
+	require.NoError(t, err)
+	assert.True(t, mfakey.Sealed(secretOf(user.ID)))
+	opened, err := mfakey.Open(secretOf(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, plain, opened)
+}
+
+func TestMigrateChecksumMismatch(t *testing.T) {
+	setupSQLite(t, "")
+	checksumOf := func() string {
+		var record migrate.SchemaMigration
+		require.NoError(t, xdb.GetDB().Take(&record, baselineVersion).Error)
+		return record.Checksum
+	}
+
+	// Go 迁移的校验和覆盖源文件与模型快照，而不只是版本号与名称
+	nameOnly := sha256.Sum256([]byte("20260101000000_baseline"))
+	assert.NotEqual(t, hex.EncodeToString(nameOnly[:]), checksumOf())
+
+	require.NoError(t, xdb.GetDB().Model(&migrate.SchemaMigration{}).
+		Where("version = ?", baselineVersion).Update("checksum", "tampered").Error)
+
+	assert.True(t, statusOf(t, baselineVersion).Dirty)
+	_, err := migrate.Up(context.Background(), migrate.Options{})
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestMigrateLock(t *testing.T) {
+	setupSQLite(t, "")
+	ctx := context.Background()
+	lock := migrate.SchemaMigrationLock{ID: 1, LockedBy: "other-instance", LockedAt: time.Now()}
+	require.NoError(t, xdb.GetDB().Create(&lock).Error)
+
+	_, err := migrate.Up(ctx, migrate.Options{LockTimeout: 600 * time.Millisecond})
+	assert.ErrorContains(t, err, "migration lock")
+
+	// 持有者崩溃后锁过期，可被接管并在完成后释放
+	require.NoError(t, xdb.GetDB().Model(&lock).Update("locked_at", time.Now().Add(-time.Hour)).Error)
+	_, err = migrate.Up(ctx, migrate.Options{})
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, xdb.GetDB().Model(&migrate.SchemaMigrationLock{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
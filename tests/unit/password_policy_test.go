@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/dto"
+	"webgos/internal/models"
+	"webgos/internal/pwdpolicy"
+	"webgos/internal/services"
+	"webgos/internal/utils/param"
+	"webgos/internal/xdb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := pwdpolicy.Policy{MinLength: 8, MinClasses: 3, BanCommon: true, Banned: []string{"Webgos2026!"}, DisallowUsername: true}
+	rule := func(password, username string) string {
+		var perr *pwdpolicy.PolicyError
+		if err := policy.Check(password, username); err != nil {
+			require.ErrorAs(t, err, &perr)
+			return perr.Rule
+		}
+		return ""
+	}
+	assert.Equal(t, pwdpolicy.RuleLength, rule("Ab1!", ""))
+	assert.Equal(t, pwdpolicy.RuleClasses, rule("abcdefgh1", ""))
+	assert.Equal(t, pwdpolicy.RuleCommon, rule("Password1", ""))
+	assert.Equal(t, pwdpolicy.RuleCommon, rule("webgos2026!", ""))
+	assert.Equal(t, pwdpolicy.RuleUsername, rule("xAlice-2026", "alice"))
+	assert.Empty(t, rule("xAlice-2026", ""))
+	assert.Empty(t, rule("Tr0ub4dor&3", "alice"))
+
+	// 验证标签使用当前配置，并取同一结构体的 Username 字段
+	useConfig(t, `
+server:
+  port: 8080
+database:
+  dialect: "sqlite"
+  dbname: ":memory:"
+runtime:
+  dir: "."
+password_policy:
+  min_length: 8
+  disallow_username: true
+`, nil)
+	err := param.GetValidator().Struct(dto.UserRegister{Username: "alice", Password: "1234567"})
+	assert.ErrorContains(t, param.ValidationError(err), "密码 长度不能少于8个字符")
+	err = param.GetValidator().Struct(dto.UserRegister{Username: "alice", Password: "alice-2026"})
+	assert.ErrorContains(t, param.ValidationError(err), "密码 不能包含用户名")
+	assert.NoError(t, param.GetValidator().Struct(dto.UserRegister{Username: "alice", Password: "bob-2026"}))
+}
+
+func TestPasswordHistoryAndExpiry(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	useConfig(t, "", func(cfg *config.Config) {
+		cfg.PasswordPolicy.History, cfg.PasswordPolicy.MaxAge = 2, 90
+	})
+	ctx := context.Background()
+	users := services.NewUserService()
+	auth := services.NewAuthService()
+	client := services.ClientInfo{IP: "10.0.0.9"}
+
+	// 不能重复使用最近 2 次的密码，更早的密码可以再次使用
+	leo := createTestUser(t, "leo")
+	pair, err := auth.Login(ctx, "leo", "123456", client)
+	require.NoError(t, err)
+	assert.ErrorIs(t, users.ChangePassword(ctx, leo.ID, "", "wrong!", "abcdef"), services.ErrWrongPassword)
+	assert.ErrorIs(t, users.ChangePassword(ctx, leo.ID, "", "123456", "123456"), services.ErrPasswordReused)
+	require.NoError(t, users.ChangePassword(ctx, leo.ID, "", "123456", "abcdef"))
+	assert.ErrorIs(t, users.ChangePassword(ctx, leo.ID, "", "abcdef", "123456"), services.ErrPasswordReused)
+	require.NoError(t, users.ChangePassword(ctx, leo.ID, "", "abcdef", "ghijkl"))
+	require.NoError(t, users.ChangePassword(ctx, leo.ID, "", "ghijkl", "123456"))
+	var count int64
+	xdb.GetDB().Model(&models.PasswordHistory{}).Where("user_id = ?", leo.ID).Count(&count)
+	assert.EqualValues(t, 2, count)
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	assert.Error(t, err)
+
+	// 密码过期后登录返回修改密码令牌，设置新密码后签发令牌
+	require.NoError(t, xdb.GetDB().Model(leo).UpdateColumn("password_changed_at", time.Now().AddDate(0, 0, -91)).Error)
+	_, err = auth.Login(ctx, "leo", "123456", client)
+	var expired *services.PasswordExpiredError
+	require.ErrorAs(t, err, &expired)
+	_, err = auth.ChangeExpiredPassword(ctx, expired.ChangeToken, "123456", client)
+	assert.ErrorIs(t, err, services.ErrPasswordReused)
+	_, err = auth.ChangeExpiredPassword(ctx, expired.ChangeToken, "12", client)
+	assert.ErrorContains(t, err, "长度不能少于6个字符")
+	pair, err = auth.ChangeExpiredPassword(ctx, expired.ChangeToken, "mnopqr", client)
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	_, err = auth.ChangeExpiredPassword(ctx, expired.ChangeToken, "stuvwx", client)
+	assert.ErrorIs(t, err, services.ErrInvalidPasswordChange)
+	_, err = auth.Login(ctx, "leo", "mnopqr", client)
+	require.NoError(t, err)
+}
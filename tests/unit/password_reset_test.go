@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/mail"
+	"webgos/internal/models"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type captureSender chan mail.Message
+
+func (s captureSender) Send(ctx context.Context, msg mail.Message) error {
+	s <- msg
+	return nil
+}
+
+func TestPasswordReset(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	sent := make(captureSender, 4)
+	mail.SetSender(sent)
+	t.Cleanup(func() { mail.SetSender(nil) })
+	useConfig(t, "", func(cfg *config.Config) {
+		cfg.PasswordReset.URL = "https://app.example.com/reset"
+	})
+	ctx := context.Background()
+	auth := services.NewAuthService()
+	resets := services.NewPasswordResetService()
+	client := services.ClientInfo{IP: "10.0.0.8"}
+
+	user := createTestUser(t, "kate")
+	require.NoError(t, xdb.GetDB().Model(user).Update("email", "kate@example.com").Error)
+	pair, err := auth.Login(ctx, "kate", "123456", client)
+	require.NoError(t, err)
+
+	// 按邮箱申请，邮件中包含重置链接；一分钟内重复申请不再发送
+	require.NoError(t, resets.Request(ctx, "kate@example.com", client))
+	var msg mail.Message
+	select {
+	case msg = <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("reset mail not sent")
+	}
+	assert.Equal(t, []string{"kate@example.com"}, msg.To)
+	match := regexp.MustCompile(`https://app\.example\.com/reset\?token=([\w-]+)`).FindStringSubmatch(msg.Body)
+	require.Len(t, match, 2, msg.Body)
+	token := match[1]
+	require.NoError(t, resets.Request(ctx, "kate", client))
+	var count int64
+	xdb.GetDB().Model(&models.PasswordResetToken{}).Count(&count)
+	assert.EqualValues(t, 1, count)
+
+	// 不存在的账号同样返回成功
+	require.NoError(t, resets.Request(ctx, "nobody@example.com", client))
+
+	// 重置后旧会话失效，令牌不能再次使用
+	assert.ErrorIs(t, resets.Reset(ctx, "wrong-token", "654321"), services.ErrInvalidResetToken)
+	require.NoError(t, resets.Reset(ctx, token, "654321"))
+	_, err = auth.ValidateToken(ctx, pair.AccessToken)
+	assert.Error(t, err)
+	_, err = auth.Refresh(ctx, pair.RefreshToken, client)
+	assert.Error(t, err)
+	_, err = auth.Login(ctx, "kate", "654321", client)
+	require.NoError(t, err)
+	assert.ErrorIs(t, resets.Reset(ctx, token, "abcdef"), services.ErrInvalidResetToken)
+	select {
+	case msg = <-sent:
+		t.Fatalf("unexpected mail: %+v", msg)
+	default:
+	}
+}
+
+func TestPasswordResetWithoutMail(t *testing.T) {
+	setupSQLite(t, "")
+	useConfig(t, "", func(cfg *config.Config) { cfg.Mail.Driver = "none" })
+	user := createTestUser(t, "liam")
+	require.NoError(t, xdb.GetDB().Model(user).Update("email", "liam@example.com").Error)
+
+	// 未配置邮件发送时拒绝申请，不生成重置令牌
+	_, err := mail.Get()
+	assert.ErrorIs(t, err, mail.ErrNotConfigured)
+	resets := services.NewPasswordResetService()
+	assert.ErrorIs(t, resets.Request(context.Background(), "liam", services.ClientInfo{}), services.ErrPasswordResetUnavailable)
+	var count int64
+	xdb.GetDB().Model(&models.PasswordResetToken{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestFileMailSender(t *testing.T) {
+	dir := t.TempDir()
+	sender := mail.FileSender{Dir: dir, From: "webgos <noreply@example.com>"}
+	require.NoError(t, sender.Send(context.Background(), mail.Message{To: []string{"kate@example.com"}, Subject: "重置密码", Body: "链接"}))
+	assert.Error(t, sender.Send(context.Background(), mail.Message{To: []string{"a@example.com\r\nBcc: b@example.com"}, Subject: "x"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: kate@example.com\r\n")
+	assert.Contains(t, string(data), "Subject: =?UTF-8?b?6YeN572u5a+G56CB?=\r\n")
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"webgos/internal/models"
+	"webgos/internal/xdb"
+	"webgos/internal/xdb/seed"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 上级定义在下级之后，验证按依赖顺序写入
+const seedYAML = `
+departments:
+  - name: 后端组
+    parent: 研发中心
+  - name: 研发中心
+menus:
+  - name: SystemDept
+    parent: System
+    type: menu
+    path: /system/dept
+    meta: { title: system.dept.title, hideInMenu: true }
+    permissions: ["/api/department/tree#GET"]
+  - name: System
+    type: catalog
+    path: /system
+roles:
+  - name: 管理员
+    menus: [System, SystemDept]
+users:
+  - username: seeded
+    password: ${SEED_TEST_PASSWORD}
+    department: 后端组
+    roles: [管理员]
+`
+
+func TestSeedLoadFiles(t *testing.T) {
+	setupSQLite(t, "")
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seed.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(seedYAML), 0644))
+	t.Setenv("SEED_TEST_PASSWORD", "first")
+
+	result, err := seed.LoadFiles(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, seed.Count{Created: 2}, result.Departments)
+	assert.Equal(t, seed.Count{Created: 2}, result.Menus)
+	assert.Equal(t, seed.Count{Created: 1}, result.Permissions)
+	assert.Equal(t, seed.Count{Created: 1}, result.Users)
+
+	db := xdb.GetDB()
+	var parent, child models.Menu
+	require.NoError(t, db.Where("name = ?", "System").First(&parent).Error)
+	require.NoError(t, db.Preload("Permissions").Where("name = ?", "SystemDept").First(&child).Error)
+	assert.Equal(t, parent.ID, child.Pid)
+	assert.True(t, child.Meta.HideInMenu)
+	require.Len(t, child.Permissions, 1)
+	assert.Equal(t, "GET", child.Permissions[0].Method)
+
+	var user models.User
+	require.NoError(t, db.Preload("Roles").Where("username = ?", "seeded").First(&user).Error)
+	require.Len(t, user.Roles, 1)
+	assert.True(t, user.CheckPassword("first"))
+
+	// 重复加载只更新，不重复创建，也不覆盖已有用户的密码、令牌版本与两步验证等字段
+	require.NoError(t, db.Model(&user).UpdateColumns(map[string]any{
+		"token_version": 3, "mfa_enabled": true, "mfa_secret": "SECRET", "status": 0,
+	}).Error)
+	t.Setenv("SEED_TEST_PASSWORD", "second")
+	result, err = seed.LoadFiles(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, seed.Count{Updated: 2}, result.Departments)
+	assert.Equal(t, seed.Count{Updated: 1}, result.Users)
+	var count int64
+	require.NoError(t, db.Model(&models.RBACRoleMenu{}).Count(&count).Error)
+	assert.EqualValues(t, 2, count)
+	user = models.User{}
+	require.NoError(t, db.Where("username = ?", "seeded").First(&user).Error)
+	assert.True(t, user.CheckPassword("first"))
+	assert.Equal(t, 3, user.TokenVersion)
+	assert.True(t, user.MFAEnabled)
+	assert.Equal(t, "SECRET", user.MFASecret)
+	assert.Equal(t, models.UserStatusEnabled, user.Status)
+
+	// 软删除的记录会被恢复
+	require.NoError(t, db.Delete(&models.RBACRole{}, "name = ?", "管理员").Error)
+	_, err = seed.LoadFiles(ctx, path)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.RBACRole{}).Where("name = ?", "管理员").Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestSeedErrors(t *testing.T) {
+	setupSQLite(t, "")
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	_, err := seed.LoadFiles(context.Background(), write("env.yaml", "users: [{username: x, password: '${SEED_TEST_UNDEFINED}'}]"))
+	assert.ErrorContains(t, err, "SEED_TEST_UNDEFINED")
+
+	// 失败时整体回滚，前面已写入的部门不会残留
+	_, err = seed.LoadFiles(context.Background(), write("bad.json", `{"departments":[{"name":"d1"}],"roles":[{"name":"r","menus":["missing"]}]}`))
+	assert.ErrorContains(t, err, `menu "missing" not found`)
+	var count int64
+	require.NoError(t, xdb.GetDB().Model(&models.Department{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	_, err = seed.LoadFiles(context.Background(), write("orphan.yaml", "departments: [{name: a, parent: nowhere}]"))
+	assert.ErrorContains(t, err, `parent "nowhere" not found`)
+}
@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"webgos/internal/cache"
+	"webgos/internal/config"
+	"webgos/internal/routes"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	useConfig(t, "", func(cfg *config.Config) { cfg.JWT.MaxSessions = 2 })
+	ctx := context.Background()
+	auth := services.NewAuthService()
+	sessions := services.NewSessionService()
+	user := createTestUser(t, "erin")
+
+	laptop, err := auth.Login(ctx, "erin", "123456", services.ClientInfo{IP: "10.0.0.1", UserAgent: "laptop"})
+	require.NoError(t, err)
+	phone, err := auth.Login(ctx, "erin", "123456", services.ClientInfo{IP: "10.0.0.2", UserAgent: "phone"})
+	require.NoError(t, err)
+	claims, err := auth.ValidateToken(ctx, phone.AccessToken)
+	require.NoError(t, err)
+	phoneID := (*claims)["sid"].(string)
+
+	list, err := sessions.List(ctx, user.ID, phoneID)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, s := range list {
+		assert.Equal(t, s.ID == phoneID, s.Current)
+		assert.Contains(t, []string{"laptop", "phone"}, s.UserAgent)
+	}
+
+	// 缓存丢失后按会话表恢复访问令牌
+	cache.GetCache().Flush()
+	_, err = auth.ValidateToken(ctx, phone.AccessToken)
+	require.NoError(t, err)
+
+	// 超出 max_sessions 时注销最久未活跃的会话
+	sessions.Touch(ctx, phoneID, "10.0.0.3")
+	tablet, err := auth.Login(ctx, "erin", "123456", services.ClientInfo{IP: "10.0.0.4", UserAgent: "tablet"})
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(ctx, laptop.AccessToken)
+	assert.Error(t, err)
+	_, err = auth.Refresh(ctx, laptop.RefreshToken, services.ClientInfo{})
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	list, err = sessions.List(ctx, user.ID, "")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	// 只能注销自己的会话
+	other := createTestUser(t, "frank")
+	assert.ErrorIs(t, sessions.Revoke(ctx, other.ID, phoneID), services.ErrSessionNotFound)
+	require.NoError(t, sessions.Revoke(ctx, user.ID, phoneID))
+	_, err = auth.ValidateToken(ctx, phone.AccessToken)
+	assert.Error(t, err)
+
+	// 强制下线：注销全部会话，缓存丢失后也不会恢复
+	revoked, err := sessions.RevokeAll(ctx, user.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	cache.GetCache().Flush()
+	_, err = auth.ValidateToken(ctx, tablet.AccessToken)
+	assert.Error(t, err)
+	list, err = sessions.List(ctx, user.ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestRevokeSessionsReportsErrors(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	ctx := context.Background()
+	auth := services.NewAuthService()
+	sessions := services.NewSessionService()
+	user := createTestUser(t, "grace")
+
+	pair, err := auth.Login(ctx, "grace", "123456", services.ClientInfo{})
+	require.NoError(t, err)
+
+	// 刷新令牌注销失败时返回错误，会话随事务回滚保持有效
+	require.NoError(t, xdb.GetDB().Migrator().DropTable("refresh_tokens"))
+	_, err = sessions.RevokeAll(ctx, user.ID, "")
+	assert.Error(t, err)
+	assert.Error(t, auth.Logout(ctx, pair.AccessToken, ""))
+	list, err := sessions.List(ctx, user.ID, "")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestSelfServiceRoutesAreNotPermissionPoints(t *testing.T) {
+	setupSQLite(t, ":memory:")
+	engine := routes.New(config.Get())
+	names := map[string]bool{}
+	for _, route := range routes.Routes() {
+		names[route.Name] = true
+	}
+	registered := map[string]bool{}
+	for _, route := range engine.Routes() {
+		registered[route.Path+"#"+route.Method] = true
+	}
+
+	// 用户自助接口登录即可访问，不注册为权限点；对应的管理接口仍为权限点
+	for _, name := range []string{"/api/session#GET", "/api/mfa#GET", "/api/mfa/setup#POST", "/api/api_key#GET", "/api/api_key#POST", "/api/user/password#POST"} {
+		assert.True(t, registered[name], name)
+		assert.False(t, names[name], name)
+	}
+	for _, name := range []string{"/api/system/session/force_logout#POST", "/api/system/mfa/reset#POST", "/api/system/api_key#POST"} {
+		assert.True(t, names[name], name)
+	}
+}
@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"webgos/internal/config"
+	"webgos/internal/dto"
+	"webgos/internal/middleware"
+	"webgos/internal/models"
+	"webgos/internal/services"
+	"webgos/internal/xdb"
+	"webgos/internal/xdb/migrate"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSQLite 使用临时目录下的 sqlite 文件库加载配置并完成迁移，测试结束后关闭连接
+// dbname 传 ":memory:" 时使用内存库
+func setupSQLite(t *testing.T, dbname string) {
+	t.Helper()
+	if dbname == "" {
+		dbname = filepath.ToSlash(filepath.Join(t.TempDir(), "webgos.db"))
+	}
+	path := writeConfigFile(t, fmt.Sprintf(`
+server:
+  mode: "debug"
+  port: 8080
+database:
+  dialect: "sqlite"
+  dbname: %q
+  max_open_conns: 4
+  max_idle_conns: 2
+log:
+  level_sql: "Silent"
+auto_migrate: true
+super_account: "super"
+`, dbname))
+	_, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	require.NoError(t, xdb.InitDB())
+	t.Cleanup(xdb.CloseDB)
+	require.NoError(t, migrate.AutoMigrate())
+}
+
+func createTestUser(t *testing.T, username string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Nickname: username, Password: "123456"}
+	require.NoError(t, services.NewUserService().CreateOrUpdateUser(context.Background(), user))
+	return user
+}
+
+func TestSQLiteRBAC(t *testing.T) {
+	setupSQLite(t, "")
+	ctx := context.Background()
+	rbac := services.NewRBACService()
+
+	user := createTestUser(t, "alice")
+	perm := models.RBACPermission{Name: "/api/products#GET", Path: "/api/products", Method: "GET"}
+	require.NoError(t, xdb.GetDB().Create(&perm).Error)
+	menu, err := services.NewMenuService().AddMenu(ctx, dto.MenuDTO{Name: "Product", Path: "/product", Type: "menu", Status: 1})
+	require.NoError(t, err)
+	require.NoError(t, services.NewMenuService().AssignPermissionsToMenu(ctx, menu.ID, []int{perm.ID}))
+
+	role, err := rbac.AddRole(ctx, dto.AddRoleDTO{Name: "仓管", Status: 1, MenuIDs: []int{menu.ID}})
+	require.NoError(t, err)
+	require.NoError(t, rbac.AssignRolesToUser(ctx, user.ID, []int{role.ID}))
+
+	perms, err := rbac.GetRolePermissions(ctx, role.ID)
+	require.NoError(t, err)
+	require.Len(t, perms, 1)
+	assert.Equal(t, perm.Name, perms[0].Name)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", user.ID) })
+	r.Use(middleware.RBAC())
+	r.GET("/api/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/products", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	assert.NotEqual(t, http.StatusOK, w.Code)
+
+	// 菜单下仍有子菜单时不允许删除
+	_, err = services.NewMenuService().AddMenu(ctx, dto.MenuDTO{Name: "ProductList", Path: "/product/list", Type: "menu", Status: 1, Pid: menu.ID})
+	require.NoError(t, err)
+	assert.Error(t, services.NewMenuService().DeleteMenu(ctx, menu.ID))
+}
+
+func TestSQLiteDepartmentTree(t *testing.T) {
+	setupSQLite(t, ":memory:")
+	ctx := context.Background()
+	svc := services.NewDepartmentService()
+
+	leader := createTestUser(t, "bob")
+	root, err := svc.Create(ctx, dto.AddDepartmentDTO{Name: "总部", LeaderID: &leader.ID})
+	require.NoError(t, err)
+	child, err := svc.Create(ctx, dto.AddDepartmentDTO{Name: "研发部", ParentID: root.ID})
+	require.NoError(t, err)
+	require.NoError(t, svc.AddUsers(ctx, child.ID, []int{leader.ID}))
+
+	tree, err := svc.GetTree(ctx)
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	assert.Equal(t, "bob", tree[0].Leader.Username)
+	require.Len(t, tree[0].Children, 1)
+	require.Len(t, tree[0].Children[0].Users, 1)
+
+	require.NoError(t, svc.RemoveUser(ctx, leader.ID))
+	require.NoError(t, svc.Delete(ctx, root.ID))
+	tree, err = svc.GetTree(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tree)
+}
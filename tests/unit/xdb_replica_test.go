@@ -0,0 +1,162 @@
+package unit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"webgos/internal/config"
+	"webgos/internal/middleware"
+	"webgos/internal/xdb"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupReplicas 以 sqlite 文件库作为主库与备库，slaves 为 database.slaves 下的 yaml 片段，{dir} 替换为临时目录
+func setupReplicas(t *testing.T, strategy, slaves string) {
+	t.Helper()
+	dir := filepath.ToSlash(t.TempDir())
+	path := writeConfigFile(t, fmt.Sprintf(`
+server:
+  port: 8080
+database:
+  dialect: "sqlite"
+  dbname: "%s/master.db"
+  read_write_separation: true
+  slave_load_balance: %q
+  health_check_interval: 60
+  slaves:
+%s
+log:
+  level_sql: "Silent"
+`, dir, strategy, strings.ReplaceAll(slaves, "{dir}", dir)))
+	_, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	require.NoError(t, xdb.InitDB())
+	t.Cleanup(xdb.CloseDB)
+}
+
+func TestReplicaWeighted(t *testing.T) {
+	setupReplicas(t, "weighted", `
+    - dialect: "sqlite"
+      dbname: "{dir}/slave0.db"
+      weight: 3
+    - dialect: "sqlite"
+      dbname: "{dir}/slave1.db"`)
+
+	hits := make(map[*gorm.DB]int)
+	for i := 0; i < 8; i++ {
+		db := xdb.GetSlaveDB()
+		require.NotSame(t, xdb.GetDB(), db)
+		hits[db]++
+	}
+	counts := make([]int, 0, len(hits))
+	for _, n := range hits {
+		counts = append(counts, n)
+	}
+	assert.ElementsMatch(t, []int{6, 2}, counts)
+}
+
+func TestReplicaFailover(t *testing.T) {
+	// 127.0.0.1:1 无服务监听，模拟宕机的备库
+	setupReplicas(t, "round_robin", `
+    - dialect: "sqlite"
+      dbname: "{dir}/slave0.db"
+    - dialect: "postgres"
+      host: "127.0.0.1"
+      port: 1
+      username: "webgos"
+      password: "webgos"
+      dbname: "webgos"`)
+
+	status := xdb.SlaveStatus()
+	require.Len(t, status, 2)
+	assert.True(t, status[0].Healthy)
+	assert.False(t, status[1].Healthy)
+	assert.Equal(t, 1, status[1].Failures)
+	assert.NotEmpty(t, status[1].LastError)
+	assert.True(t, status[1].NextProbeAt.After(status[1].LastCheckAt))
+
+	// 宕机的备库被摘除，读请求只落在健康备库上
+	healthy := xdb.GetSlaveDB()
+	for i := 0; i < 4; i++ {
+		assert.Same(t, healthy, xdb.GetSlaveDB())
+	}
+	assert.NotSame(t, xdb.GetDB(), healthy)
+}
+
+func TestReplicaFallbackToMaster(t *testing.T) {
+	setupReplicas(t, "random", `
+    - dialect: "postgres"
+      host: "127.0.0.1"
+      port: 1
+      username: "webgos"
+      password: "webgos"
+      dbname: "webgos"`)
+
+	assert.Same(t, xdb.GetDB(), xdb.GetSlaveDB())
+}
+
+func TestStickyMasterAfterWrite(t *testing.T) {
+	setupReplicas(t, "random", `
+    - dialect: "sqlite"
+      dbname: "{dir}/slave0.db"`)
+	require.NoError(t, xdb.GetDB().Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)").Error)
+
+	gin.SetMode(gin.TestMode)
+	alice, _ := gin.CreateTestContext(httptest.NewRecorder())
+	alice.Set("user_id", 1)
+	bob, _ := gin.CreateTestContext(httptest.NewRecorder())
+	bob.Set("user_id", 2)
+
+	master := xdb.GetDB()
+	assert.NotSame(t, master.Statement.ConnPool, xdb.ReadDB(alice).Statement.ConnPool)
+
+	require.NoError(t, master.WithContext(alice).Exec("INSERT INTO notes (body) VALUES (?)", "hi").Error)
+	// 写入者在粘滞窗口内读主库，其他用户不受影响
+	assert.Same(t, master.Statement.ConnPool, xdb.ReadDB(alice).Statement.ConnPool)
+	assert.NotSame(t, master.Statement.ConnPool, xdb.ReadDB(bob).Statement.ConnPool)
+
+	// 显式指定的路由优先于粘滞窗口
+	assert.NotSame(t, master.Statement.ConnPool, xdb.ReadDB(xdb.ForceReplica(alice)).Statement.ConnPool)
+	assert.Same(t, master.Statement.ConnPool, xdb.ReadDB(xdb.ForceMaster(bob)).Statement.ConnPool)
+}
+
+func TestStickyMasterAfterTransactionMiddleware(t *testing.T) {
+	setupReplicas(t, "random", `
+    - dialect: "sqlite"
+      dbname: "{dir}/slave0.db"`)
+	// 只在主库建表，读到备库时查询失败
+	require.NoError(t, xdb.GetDB().Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)").Error)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", 7) })
+	r.POST("/notes", middleware.Transaction(), func(c *gin.Context) {
+		if err := xdb.WriteDB(c).Exec("INSERT INTO notes (body) VALUES (?)", "hi").Error; err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/notes", func(c *gin.Context) {
+		var count int64
+		if err := xdb.ReadDB(c).Raw("SELECT COUNT(*) FROM notes").Scan(&count).Error; err != nil || count != 1 {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// 事务中间件内的写入同样标记粘滞窗口，同一用户随后的读请求走主库
+	for _, method := range []string{http.MethodPost, http.MethodGet} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/notes", nil))
+		assert.Equal(t, http.StatusOK, w.Code, method)
+	}
+}
@@ -0,0 +1,172 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webgos/internal/cache"
+	"webgos/internal/middleware"
+	"webgos/internal/models"
+	"webgos/internal/services"
+	"webgos/internal/utils/response"
+	"webgos/internal/xdb"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userExists(t *testing.T, username string) bool {
+	t.Helper()
+	var count int64
+	require.NoError(t, xdb.GetDB().Model(&models.User{}).Where("username = ?", username).Count(&count).Error)
+	return count > 0
+}
+
+func TestTransactionContext(t *testing.T) {
+	setupSQLite(t, "")
+	ctx := context.Background()
+	userService := services.NewUserService()
+
+	err := xdb.Transaction(ctx, func(ctx context.Context) error {
+		user := &models.User{Username: "tx_rollback", Password: "123456"}
+		require.NoError(t, userService.CreateOrUpdateUser(ctx, user))
+		// 同一事务内可读到未提交的写入
+		info, err := userService.GetUserInfo(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "tx_rollback", info.Username)
+		return errors.New("abort")
+	})
+	assert.EqualError(t, err, "abort")
+	assert.False(t, userExists(t, "tx_rollback"))
+
+	require.NoError(t, xdb.Transaction(ctx, func(ctx context.Context) error {
+		return userService.CreateOrUpdateUser(ctx, &models.User{Username: "tx_commit", Password: "123456"})
+	}))
+	assert.True(t, userExists(t, "tx_commit"))
+}
+
+func TestProductOutIsAtomic(t *testing.T) {
+	setupSQLite(t, "")
+	ctx := context.Background()
+	product := models.Product{Name: "螺丝", Stock: 5}
+	require.NoError(t, xdb.GetDB().Create(&product).Error)
+
+	svc := services.NewInventoryService()
+	require.NoError(t, svc.ProductIn(ctx, &models.InventoryRecord{ProductID: product.ID, Quantity: 3}))
+	assert.Error(t, svc.ProductOut(ctx, &models.InventoryRecord{ProductID: product.ID, Quantity: 9}))
+	require.NoError(t, svc.ProductOut(ctx, &models.InventoryRecord{ProductID: product.ID, Quantity: 8}))
+
+	var stock int
+	require.NoError(t, xdb.GetDB().Model(&models.Product{}).Where("id = ?", product.ID).Pluck("stock", &stock).Error)
+	assert.Equal(t, 0, stock)
+	var records int64
+	require.NoError(t, xdb.GetDB().Model(&models.InventoryRecord{}).Count(&records).Error)
+	assert.Equal(t, int64(2), records)
+}
+
+func TestTransactionMiddleware(t *testing.T) {
+	setupSQLite(t, "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Recovery())
+	create := func(c *gin.Context) {
+		user := &models.User{Username: c.Param("name"), Password: "123456"}
+		require.NoError(t, services.NewUserService().CreateOrUpdateUser(c, user))
+	}
+	r.POST("/ok/:name", middleware.Transaction(), func(c *gin.Context) {
+		create(c)
+		response.Success(c, "操作成功", nil)
+	})
+	r.POST("/fail/:name", middleware.Transaction(), func(c *gin.Context) {
+		create(c)
+		response.Error(c, "分配角色失败")
+	})
+	r.POST("/panic/:name", middleware.Transaction(), func(c *gin.Context) {
+		create(c)
+		panic("boom")
+	})
+
+	for path, committed := range map[string]bool{"/ok/u1": true, "/fail/u2": false, "/panic/u3": false} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.Equal(t, committed, userExists(t, path[len(path)-2:]), path)
+		assert.NotEmpty(t, w.Body.String(), path)
+	}
+}
+
+func TestTransactionAfterCommit(t *testing.T) {
+	setupSQLite(t, "")
+	ctx := context.Background()
+	var ran []string
+	hook := func(name string) func() { return func() { ran = append(ran, name) } }
+
+	// 没有事务时立即执行；嵌套事务的回调随外层提交执行，回滚的保存点与事务丢弃回调
+	xdb.AfterCommit(ctx, hook("direct"))
+	require.NoError(t, xdb.Transaction(ctx, func(ctx context.Context) error {
+		xdb.AfterCommit(ctx, hook("outer"))
+		require.NoError(t, xdb.Transaction(ctx, func(ctx context.Context) error {
+			xdb.AfterCommit(ctx, hook("nested"))
+			return nil
+		}))
+		assert.Error(t, xdb.Transaction(ctx, func(ctx context.Context) error {
+			xdb.AfterCommit(ctx, hook("savepoint"))
+			return errors.New("abort")
+		}))
+		assert.Equal(t, []string{"direct"}, ran)
+		return nil
+	}))
+	assert.Error(t, xdb.Transaction(ctx, func(ctx context.Context) error {
+		xdb.AfterCommit(ctx, hook("rollback"))
+		return errors.New("abort")
+	}))
+	assert.Equal(t, []string{"direct", "outer", "nested"}, ran)
+}
+
+func TestTransactionMiddlewareInvalidatesAfterCommit(t *testing.T) {
+	setupSQLite(t, "")
+	old := cache.SetCache(cache.NewMemoryCache())
+	t.Cleanup(func() { cache.SetCache(old) })
+	user := createTestUser(t, "tx_status")
+	auth := services.NewAuthService()
+	pair, err := auth.Login(context.Background(), "tx_status", "123456", services.ClientInfo{IP: "10.0.0.5"})
+	require.NoError(t, err)
+	tagged := "tx_status:permissions"
+	cached := func() bool {
+		_, found := cache.GetCache().Get(tagged)
+		return found
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	disable := func(c *gin.Context) {
+		cache.GetCache().Flush()
+		cache.GetCache().SetWithTags(tagged, true, time.Minute, cache.Tag(cache.TagUser, user.ID))
+		require.NoError(t, services.NewUserService().SetUserStatus(c, user.ID, 0))
+		// 提交前不清除缓存；缓存未命中时事务中读到的状态不写入缓存
+		assert.True(t, cached())
+		_, err := auth.ValidateToken(c, pair.AccessToken)
+		assert.ErrorIs(t, err, services.ErrAccountDisabled)
+	}
+	r.POST("/ok", middleware.Transaction(), func(c *gin.Context) {
+		disable(c)
+		response.Success(c, "操作成功", nil)
+	})
+	r.POST("/fail", middleware.Transaction(), func(c *gin.Context) {
+		disable(c)
+		response.Error(c, "操作失败")
+	})
+
+	// 回滚后缓存与令牌都不受影响，提交后失效
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/fail", nil))
+	assert.True(t, cached())
+	_, err = auth.ValidateToken(context.Background(), pair.AccessToken)
+	assert.NoError(t, err)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/ok", nil))
+	assert.False(t, cached())
+	_, err = auth.ValidateToken(context.Background(), pair.AccessToken)
+	assert.Error(t, err)
+}